@@ -9,11 +9,18 @@ import (
 	"time"
 
 	"github.com/WangWilly/swap-estimation/controllers/estimate"
+	"github.com/WangWilly/swap-estimation/controllers/estimate/poolmodels"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/balancer"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/curve"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/erc20"
 	"github.com/WangWilly/swap-estimation/pkgs/clients/eth"
 	"github.com/WangWilly/swap-estimation/pkgs/clients/ethwss"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/rpcpool"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/univ3"
 	"github.com/WangWilly/swap-estimation/pkgs/middleware"
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/WangWilly/swap-estimation/pkgs/tokenlist"
 	"github.com/WangWilly/swap-estimation/pkgs/utils"
-	"github.com/ethereum/go-ethereum/ethclient"
 
 	"github.com/sethvargo/go-envconfig"
 )
@@ -25,12 +32,23 @@ type envConfig struct {
 	Port string `env:"PORT,default=8080"`
 	Host string `env:"HOST,default=0.0.0.0"`
 
-	// Eth client configuration
-	GethClientURL    string `env:"GETH_CLIENT_URL,required"`
-	GethWssClientURL string `env:"GETH_WSS_CLIENT_URL,required"`
+	// Eth client configuration; each URL may list several providers,
+	// comma-separated, to fail over between
+	GethClientURLs    []string       `env:"GETH_CLIENT_URL,required"`
+	GethWssClientURLs []string       `env:"GETH_WSS_CLIENT_URL,required"`
+	RPCPoolCfg        rpcpool.Config `env:",prefix=RPC_POOL_"`
 
-	EthClientCfg    eth.Config    `env:",prefix=ETH_CLIENT_"`
-	EthWssClientCfg ethwss.Config `env:",prefix=ETH_WSS_CLIENT_"`
+	EthClientCfg      eth.Config      `env:",prefix=ETH_CLIENT_"`
+	EthWssClientCfg   ethwss.Config   `env:",prefix=ETH_WSS_CLIENT_"`
+	EthV3ClientCfg    univ3.Config    `env:",prefix=ETH_V3_CLIENT_"`
+	CurveClientCfg    curve.Config    `env:",prefix=CURVE_CLIENT_"`
+	BalancerClientCfg balancer.Config `env:",prefix=BALANCER_CLIENT_"`
+	Erc20ClientCfg    erc20.Config    `env:",prefix=ERC20_CLIENT_"`
+
+	TokenListCfg tokenlist.Config `env:",prefix=TOKEN_LIST_"`
+
+	// Controller configuration
+	EstimateCtrlCfg estimate.Config `env:",prefix=ESTIMATE_"`
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -60,26 +78,44 @@ func main() {
 	////////////////////////////////////////////////////////////////////////////
 	// Initialize modules
 
-	gethClient, err := ethclient.Dial(cfg.GethClientURL)
+	gethProviders, err := rpcpool.Dial(ctx, cfg.GethClientURLs)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Failed to connect to Ethereum node")
 	}
+	gethClient := rpcpool.New(cfg.RPCPoolCfg, gethProviders)
 	ethClient := eth.New(cfg.EthClientCfg, gethClient)
+	ethV3Client := univ3.New(cfg.EthV3ClientCfg, gethClient)
+	curveClient := curve.New(cfg.CurveClientCfg, gethClient)
+	balancerClient := balancer.New(cfg.BalancerClientCfg, gethClient)
+	erc20Client := erc20.New(cfg.Erc20ClientCfg, gethClient)
 
-	gethWssClient, err := ethclient.Dial(cfg.GethWssClientURL)
+	gethWssProviders, err := rpcpool.Dial(ctx, cfg.GethWssClientURLs)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Failed to connect to Ethereum WSS node")
 	}
+	gethWssClient := rpcpool.New(cfg.RPCPoolCfg, gethWssProviders)
 	ethWssClient := ethwss.New(cfg.EthWssClientCfg, gethWssClient)
 
 	////////////////////////////////////////////////////////////////////////////
 	// Initialize the controllers
 
-	estimateCtrlCfg := estimate.Config{}
+	tokenList, err := tokenlist.Load(cfg.TokenListCfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to load token lists")
+	}
+
+	poolModels := poolmodel.NewRegistry()
+	poolModels.Register(poolmodels.PoolTypeUniV2, poolmodels.NewUniV2(ethClient))
+	poolModels.Register(poolmodels.PoolTypeUniV3, poolmodels.NewUniV3(ethV3Client))
+	poolModels.Register(poolmodels.PoolTypeCurve, poolmodels.NewCurve(curveClient))
+	poolModels.Register(poolmodels.PoolTypeWeighted, poolmodels.NewWeighted(balancerClient))
+
 	estimateCtrl := estimate.NewController(
-		estimateCtrlCfg,
-		ethClient,
+		cfg.EstimateCtrlCfg,
+		poolModels,
 		ethWssClient,
+		erc20Client,
+		tokenList,
 	)
 	estimateCtrl.RegisterRoutes(r)
 
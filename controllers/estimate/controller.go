@@ -1,7 +1,15 @@
 package estimate
 
 import (
+	"errors"
+
+	"github.com/WangWilly/swap-estimation/controllers/estimate/ctrlutils"
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/WangWilly/swap-estimation/pkgs/tokenlist"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
 
 	"golang.org/x/sync/singleflight"
 )
@@ -9,29 +17,63 @@ import (
 ////////////////////////////////////////////////////////////////////////////////
 
 type Config struct {
+	// Chain whose token list entries symbols are resolved against
+	ChainID uint64 `env:"CHAIN_ID,default=1"`
+	// Require EIP-55 checksummed src, dst and pool addresses
+	StrictChecksum bool `env:"STRICT_CHECKSUM,default=false"`
+
+	Dexes    ctrlutils.DexList    `env:"DEXES,default=uniswapv2:0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f:0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f:30"`
+	PoolFees ctrlutils.PoolFeeMap `env:"POOL_FEES"`
+	// Transfer taxes of fee-on-transfer tokens
+	TokenTaxes ctrlutils.TokenTaxList `env:"TOKEN_TAXES"`
+
+	RouteBaseTokens      ctrlutils.AddrList `env:"ROUTE_BASE_TOKENS,default=0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2,0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48,0xdAC17F958D2ee523a2206206994597C13D831ec7,0x6B175474E89094C44Da98b954EedeAC495271d0F"`
+	RouteMaxHops         int                `env:"ROUTE_MAX_HOPS,default=3"`
+	RouteMaxAlternatives int                `env:"ROUTE_MAX_ALTERNATIVES,default=3"`
+
+	BatchMaxItems int `env:"BATCH_MAX_ITEMS,default=500"`
+	// Maximum number of pools of a request checked or fetched on their own at
+	// once, when they cannot be read together
+	BatchFetchConcurrency int `env:"BATCH_FETCH_CONCURRENCY,default=16"`
+
+	// Maximum number of pools whose tokens are remembered, least recently
+	// used first evicted, since any request may derive new pair addresses
+	PairTokensCacheSize int `env:"PAIR_TOKENS_CACHE_SIZE,default=10000"`
 }
 
 type Controller struct {
 	cfg Config
 
-	ethClient    EthClient
+	models       *poolmodel.Registry
 	ethWssClient EthWssClient
+	erc20Client  Erc20Client
+	tokenList    *tokenlist.TokenList
 
 	g4GetEstimate *singleflight.Group
+
+	// Tokens of the pools seen by the controller, keyed by pool address
+	pairTokens *lru.Cache[common.Address, ctrlutils.PairEdge]
 }
 
+var errAmbiguousSymbol = errors.New("ambiguous token symbol")
+
 func NewController(
 	cfg Config,
-	ethClient EthClient,
+	models *poolmodel.Registry,
 	ethWssClient EthWssClient,
+	erc20Client Erc20Client,
+	tokenList *tokenlist.TokenList,
 ) *Controller {
 	g4GetEstimate := &singleflight.Group{}
 
 	return &Controller{
 		cfg:           cfg,
-		ethClient:     ethClient,
+		models:        models,
 		ethWssClient:  ethWssClient,
+		erc20Client:   erc20Client,
+		tokenList:     tokenList,
 		g4GetEstimate: g4GetEstimate,
+		pairTokens:    lru.NewCache[common.Address, ctrlutils.PairEdge](max(cfg.PairTokensCacheSize, 1)),
 	}
 }
 
@@ -39,4 +81,81 @@ func (c *Controller) RegisterRoutes(r *gin.Engine) {
 	////////////////////////////////////////////////////////////////////////////
 	// price estimation
 	r.GET("/estimate", c.Get)
+	r.GET("/estimate/path", c.GetPath)
+	r.POST("/estimate/batch", c.PostBatch)
+
+	////////////////////////////////////////////////////////////////////////////
+	// route finding
+	r.GET("/route", c.GetRoute)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (c *Controller) rememberPairTokens(poolAddr, tokenA, tokenB common.Address, dexName string) {
+	c.pairTokens.Add(poolAddr, ctrlutils.PairEdge{
+		PoolAddr: poolAddr,
+		TokenA:   tokenA,
+		TokenB:   tokenB,
+		Dex:      dexName,
+	})
+}
+
+func (c *Controller) lookupPairTokens(poolAddr common.Address) (ctrlutils.PairEdge, bool) {
+	return c.pairTokens.Get(poolAddr)
+}
+
+// resolveFeeBps returns the swap fee of a pool: a per-pool override first,
+// then the fee of its DEX, then the Uniswap V2 default.
+func (c *Controller) resolveFeeBps(poolAddr common.Address, dexName string) uint64 {
+	if feeBps, ok := c.cfg.PoolFees[poolAddr]; ok {
+		return feeBps
+	}
+	if dex, ok := c.cfg.Dexes.Find(dexName); ok {
+		return dex.FeeBps
+	}
+	return ctrlutils.DefaultFeeBps
+}
+
+// parseAddr parses a pool or token address, in the strict checksum mode when
+// it is enabled.
+func (c *Controller) parseAddr(addrStr string) (common.Address, error) {
+	return ctrlutils.ParseAddr(addrStr, c.cfg.StrictChecksum)
+}
+
+// parseToken returns the address of a token given by address or by symbol.
+// A symbol listed more than once fails with its candidates; an unknown one
+// fails as an invalid address. Addresses resolved from a symbol come from the
+// token lists and are not held to the strict checksum mode.
+func (c *Controller) parseToken(token string) (common.Address, []tokenlist.Token, error) {
+	if ctrlutils.IsValidAddr(token) {
+		addr, err := c.parseAddr(token)
+		return addr, nil, err
+	}
+
+	candidates := c.tokenList.Lookup(c.cfg.ChainID, token)
+	switch len(candidates) {
+	case 0:
+		return common.Address{}, nil, ctrlutils.ErrInvalidAddr
+	case 1:
+		return common.HexToAddress(candidates[0].Address), nil, nil
+	}
+	return common.Address{}, candidates, errAmbiguousSymbol
+}
+
+// writeTokenError maps an error of parseToken to a response. name is the role
+// of the token in the messages, e.g. "source".
+func writeTokenError(ctx *gin.Context, err error, name, token string, candidates []tokenlist.Token) {
+	logger := log.Ctx(ctx.Request.Context())
+
+	switch {
+	case errors.Is(err, errAmbiguousSymbol):
+		logger.Error().Str("symbol", token).Msgf("Ambiguous %s token symbol", name)
+		ctx.JSON(400, gin.H{"error": "ambiguous " + name + " token symbol", "candidates": candidates})
+	case errors.Is(err, ctrlutils.ErrInvalidAddrChecksum):
+		logger.Error().Str("token_address", token).Msgf("Invalid %s token address checksum", name)
+		ctx.JSON(400, gin.H{"error": "invalid " + name + " token address checksum"})
+	default:
+		logger.Error().Str("token_address", token).Msgf("Invalid %s token address format", name)
+		ctx.JSON(400, gin.H{"error": "invalid " + name + " token address format"})
+	}
 }
@@ -3,16 +3,26 @@ package estimate
 import (
 	"testing"
 
+	"github.com/WangWilly/swap-estimation/controllers/estimate/ctrlutils"
+	"github.com/WangWilly/swap-estimation/controllers/estimate/poolmodels"
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
 	"github.com/WangWilly/swap-estimation/pkgs/testutils"
+	"github.com/WangWilly/swap-estimation/pkgs/tokenlist"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/sethvargo/go-envconfig"
+	. "github.com/smartystreets/goconvey/convey"
 	"go.uber.org/mock/gomock"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
 
 type testSuite struct {
-	ethClient    *MockEthClient
-	ethWssClient *MockEthWssClient
+	ethClient      *poolmodels.MockEthClient
+	ethV3Client    *poolmodels.MockEthV3Client
+	curveClient    *poolmodels.MockCurveClient
+	weightedClient *poolmodels.MockWeightedClient
+	ethWssClient   *MockEthWssClient
+	erc20Client    *MockErc20Client
 
 	controller *Controller
 	testServer testutils.TestHttpServer
@@ -22,21 +32,84 @@ func testInit(t *testing.T, test func(*testSuite)) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ethClient := NewMockEthClient(ctrl)
+	ethClient := poolmodels.NewMockEthClient(ctrl)
+	ethV3Client := poolmodels.NewMockEthV3Client(ctrl)
+	curveClient := poolmodels.NewMockCurveClient(ctrl)
+	weightedClient := poolmodels.NewMockWeightedClient(ctrl)
 	ethWssClient := NewMockEthWssClient(ctrl)
+	erc20Client := NewMockErc20Client(ctrl)
 	cfg := Config{}
 	if err := envconfig.Process(t.Context(), &cfg); err != nil {
 		t.Fatal(err)
 	}
 
-	controller := NewController(cfg, ethClient, ethWssClient)
+	models := poolmodel.NewRegistry()
+	models.Register(poolmodels.PoolTypeUniV2, poolmodels.NewUniV2(ethClient))
+	models.Register(poolmodels.PoolTypeUniV3, poolmodels.NewUniV3(ethV3Client))
+	models.Register(poolmodels.PoolTypeCurve, poolmodels.NewCurve(curveClient))
+	models.Register(poolmodels.PoolTypeWeighted, poolmodels.NewWeighted(weightedClient))
+
+	tokenList := tokenlist.New()
+	err := tokenList.Add(
+		tokenlist.Token{ChainID: 1, Address: "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", Symbol: "WETH", Name: "Wrapped Ether", Decimals: 18},
+		tokenlist.Token{ChainID: 1, Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", Symbol: "USDC", Name: "USD Coin", Decimals: 6},
+		tokenlist.Token{ChainID: 1, Address: "0xdAC17F958D2ee523a2206206994597C13D831ec7", Symbol: "USDT", Name: "Tether USD", Decimals: 6},
+		tokenlist.Token{ChainID: 1, Address: "0xD46bA6D942050d489DBd938a2C909A5d5039A161", Symbol: "AMPL", Name: "Ampleforth", Decimals: 9},
+		tokenlist.Token{ChainID: 1, Address: "0xFF20817765cB7f73d4bde2e66e067E58D11095C2", Symbol: "AMPL", Name: "Amp", Decimals: 18},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	controller := NewController(cfg, models, ethWssClient, erc20Client, tokenList)
 	testServer := testutils.NewTestHttpServer(controller)
 	suite := &testSuite{
-		ethClient:    ethClient,
-		ethWssClient: ethWssClient,
-		controller:   controller,
-		testServer:   testServer,
+		ethClient:      ethClient,
+		ethV3Client:    ethV3Client,
+		curveClient:    curveClient,
+		weightedClient: weightedClient,
+		ethWssClient:   ethWssClient,
+		erc20Client:    erc20Client,
+		controller:     controller,
+		testServer:     testServer,
 	}
 
 	test(suite)
 }
+
+func TestRememberPairTokens(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given a controller remembering the tokens of at most two pools", t, func() {
+			cfg := s.controller.cfg
+			cfg.PairTokensCacheSize = 2
+			controller := NewController(cfg, s.controller.models, s.ethWssClient, s.erc20Client, s.controller.tokenList)
+
+			weth := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+			usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+			usdt := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+			wethUsdcPoolAddr := ctrlutils.ComputeUniV2PairAddr(weth, usdc)
+			wethUsdtPoolAddr := ctrlutils.ComputeUniV2PairAddr(weth, usdt)
+			usdcUsdtPoolAddr := ctrlutils.ComputeUniV2PairAddr(usdc, usdt)
+
+			Convey("When the tokens of a third pool are remembered", func() {
+				controller.rememberPairTokens(wethUsdcPoolAddr, weth, usdc, "uniswapv2")
+				controller.rememberPairTokens(wethUsdtPoolAddr, weth, usdt, "uniswapv2")
+				controller.rememberPairTokens(usdcUsdtPoolAddr, usdc, usdt, "uniswapv2")
+
+				Convey("Then the least recently used pool should be forgotten", func() {
+					_, ok := controller.lookupPairTokens(wethUsdcPoolAddr)
+					So(ok, ShouldBeFalse)
+
+					edge, ok := controller.lookupPairTokens(usdcUsdtPoolAddr)
+					So(ok, ShouldBeTrue)
+					So(edge, ShouldResemble, ctrlutils.PairEdge{
+						PoolAddr: usdcUsdtPoolAddr,
+						TokenA:   usdc,
+						TokenB:   usdt,
+						Dex:      "uniswapv2",
+					})
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,35 @@
+package ctrlutils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// AddrList is a list of addresses. It can be loaded from an environment
+// variable formatted as comma-separated addresses.
+type AddrList []common.Address
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (l *AddrList) EnvDecode(val string) error {
+	addrs := AddrList{}
+
+	for _, entry := range strings.Split(val, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !IsValidAddr(entry) {
+			return fmt.Errorf("invalid address %q", entry)
+		}
+		addrs = append(addrs, common.HexToAddress(entry))
+	}
+
+	*l = addrs
+	return nil
+}
@@ -0,0 +1,46 @@
+package ctrlutils
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAddrListEnvDecode(t *testing.T) {
+	Convey("Given the address list decoder", t, func() {
+		Convey("When decoding valid addresses", func() {
+			addrs := AddrList{}
+			err := addrs.EnvDecode("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2, 0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+
+			Convey("Then they should be decoded in order", func() {
+				So(err, ShouldBeNil)
+				So(addrs, ShouldResemble, AddrList{
+					common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"),
+					common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
+				})
+			})
+		})
+
+		Convey("When decoding no addresses", func() {
+			addrs := AddrList{}
+			err := addrs.EnvDecode("")
+
+			Convey("Then the list should be empty", func() {
+				So(err, ShouldBeNil)
+				So(addrs, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When decoding an invalid address", func() {
+			addrs := AddrList{}
+
+			So(addrs.EnvDecode("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2,WETH"), ShouldNotBeNil)
+			So(addrs.EnvDecode("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc"), ShouldNotBeNil)
+
+			Convey("Then the list should be left untouched", func() {
+				So(addrs, ShouldBeEmpty)
+			})
+		})
+	})
+}
@@ -1,47 +1,70 @@
 package ctrlutils
 
 import (
+	"bytes"
+	"errors"
 	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// DefaultFeeBps is the Uniswap V2 swap fee (0.3%) in basis points.
+const DefaultFeeBps uint64 = 30
+
+const feeBpsDenominator = 10000
+
+var (
+	ErrInvalidAmountInput    = errors.New("invalid amount calculation input")
+	ErrInsufficientLiquidity = errors.New("insufficient liquidity for the requested output amount")
 )
 
 ////////////////////////////////////////////////////////////////////////////////
 
 func CalOutAmount(
-	srcTokenAddrStr string,
-	dstTokenAddrStr string,
+	srcTokenAddr common.Address,
+	dstTokenAddr common.Address,
+	amountIn, reserve0, reserve1 *big.Int,
+) *big.Int {
+	return CalOutAmountWithFee(srcTokenAddr, dstTokenAddr, amountIn, reserve0, reserve1, DefaultFeeBps)
+}
+
+// CalOutAmountWithFee is CalOutAmount with an explicit swap fee in basis
+// points, e.g. 30 for Uniswap V2 or 25 for PancakeSwap.
+func CalOutAmountWithFee(
+	srcTokenAddr common.Address,
+	dstTokenAddr common.Address,
 	amountIn, reserve0, reserve1 *big.Int,
+	feeBps uint64,
 ) *big.Int {
-	if srcTokenAddrStr == "" || dstTokenAddrStr == "" {
+	if srcTokenAddr == (common.Address{}) || dstTokenAddr == (common.Address{}) {
 		return nil
 	}
 	if amountIn == nil || reserve0 == nil || reserve1 == nil {
 		return nil
 	}
-	if srcTokenAddrStr == dstTokenAddrStr {
+	if srcTokenAddr == dstTokenAddr {
 		// If source and destination tokens are the same, return the input amount
 		return new(big.Int).Set(amountIn)
 	}
 	if amountIn.Sign() < 0 || reserve0.Sign() < 0 || reserve1.Sign() < 0 {
 		return nil
 	}
+	if feeBps >= feeBpsDenominator {
+		return nil
+	}
 	if amountIn.Sign() == 0 {
 		// If input amount is zero, return zero
 		return big.NewInt(0)
 	}
 
-	var reserveIn, reserveOut *big.Int
-	if srcTokenAddrStr < dstTokenAddrStr {
-		reserveIn = reserve0
-		reserveOut = reserve1
-	} else {
-		reserveIn = reserve1
-		reserveOut = reserve0
-	}
+	reserveIn, reserveOut := orientReserves(srcTokenAddr, dstTokenAddr, reserve0, reserve1)
 
 	// Calculate amount out using Uniswap V2 formula
-	amountInWithFee := new(big.Int).Mul(amountIn, big.NewInt(997))
+	amountInWithFee := new(big.Int).Mul(amountIn, new(big.Int).SetUint64(feeBpsDenominator-feeBps))
 	numerator := new(big.Int).Mul(amountInWithFee, reserveOut)
-	denominator := new(big.Int).Add(new(big.Int).Mul(reserveIn, big.NewInt(1000)), amountInWithFee)
+	denominator := new(big.Int).Add(new(big.Int).Mul(reserveIn, big.NewInt(feeBpsDenominator)), amountInWithFee)
 	amountOut := new(big.Int).Div(numerator, denominator)
 
 	if amountOut.Sign() < 0 {
@@ -51,3 +74,91 @@ func CalOutAmount(
 
 	return amountOut
 }
+
+// CalInAmount returns the amount of the source token required to receive
+// exactly amountOut of the destination token. It mirrors the Uniswap V2
+// getAmountIn formula, including its +1 round-up of the integer division.
+func CalInAmount(
+	srcTokenAddr common.Address,
+	dstTokenAddr common.Address,
+	amountOut, reserve0, reserve1 *big.Int,
+) (*big.Int, error) {
+	return CalInAmountWithFee(srcTokenAddr, dstTokenAddr, amountOut, reserve0, reserve1, DefaultFeeBps)
+}
+
+// CalInAmountWithFee is CalInAmount with an explicit swap fee in basis points.
+func CalInAmountWithFee(
+	srcTokenAddr common.Address,
+	dstTokenAddr common.Address,
+	amountOut, reserve0, reserve1 *big.Int,
+	feeBps uint64,
+) (*big.Int, error) {
+	if srcTokenAddr == (common.Address{}) || dstTokenAddr == (common.Address{}) {
+		return nil, ErrInvalidAmountInput
+	}
+	if amountOut == nil || reserve0 == nil || reserve1 == nil {
+		return nil, ErrInvalidAmountInput
+	}
+	if srcTokenAddr == dstTokenAddr {
+		// If source and destination tokens are the same, return the output amount
+		return new(big.Int).Set(amountOut), nil
+	}
+	if amountOut.Sign() < 0 || reserve0.Sign() < 0 || reserve1.Sign() < 0 {
+		return nil, ErrInvalidAmountInput
+	}
+	if feeBps >= feeBpsDenominator {
+		return nil, ErrInvalidAmountInput
+	}
+	if amountOut.Sign() == 0 {
+		// If output amount is zero, no input is required
+		return big.NewInt(0), nil
+	}
+
+	reserveIn, reserveOut := orientReserves(srcTokenAddr, dstTokenAddr, reserve0, reserve1)
+	if amountOut.Cmp(reserveOut) >= 0 {
+		return nil, ErrInsufficientLiquidity
+	}
+
+	// Calculate amount in using Uniswap V2 formula
+	numerator := new(big.Int).Mul(new(big.Int).Mul(reserveIn, amountOut), big.NewInt(feeBpsDenominator))
+	denominator := new(big.Int).Mul(new(big.Int).Sub(reserveOut, amountOut), new(big.Int).SetUint64(feeBpsDenominator-feeBps))
+	amountIn := new(big.Int).Div(numerator, denominator)
+
+	return amountIn.Add(amountIn, big.NewInt(1)), nil
+}
+
+// CalSpotPrice returns the marginal price of a Uniswap V2 pair before a trade,
+// in destination token units per source token unit, fees excluded.
+func CalSpotPrice(
+	srcTokenAddr common.Address,
+	dstTokenAddr common.Address,
+	reserve0, reserve1 *big.Int,
+) (*big.Rat, error) {
+	if srcTokenAddr == (common.Address{}) || dstTokenAddr == (common.Address{}) {
+		return nil, ErrInvalidAmountInput
+	}
+	if reserve0 == nil || reserve1 == nil || reserve0.Sign() < 0 || reserve1.Sign() < 0 {
+		return nil, ErrInvalidAmountInput
+	}
+
+	reserveIn, reserveOut := orientReserves(srcTokenAddr, dstTokenAddr, reserve0, reserve1)
+	if reserveIn.Sign() == 0 {
+		return nil, ErrInsufficientLiquidity
+	}
+	return new(big.Rat).SetFrac(reserveOut, reserveIn), nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// orientReserves maps the pair reserves to the swap direction. token0 is the
+// token with the lower address, compared as bytes like the pair contract does.
+func orientReserves(
+	srcTokenAddr common.Address,
+	dstTokenAddr common.Address,
+	reserve0, reserve1 *big.Int,
+) (reserveIn, reserveOut *big.Int) {
+	if bytes.Compare(srcTokenAddr.Bytes(), dstTokenAddr.Bytes()) < 0 {
+		return reserve0, reserve1
+	}
+	return reserve1, reserve0
+}
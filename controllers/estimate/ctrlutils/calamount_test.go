@@ -4,14 +4,15 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
 func TestCalOutAmount(t *testing.T) {
 	Convey("Given the CalOutAmount function for token swap calculations", t, func() {
 		// Common test data
-		srcAddr := "0x1000000000000000000000000000000000000000"
-		dstAddr := "0x2000000000000000000000000000000000000000"
+		srcAddr := common.HexToAddress("0x1000000000000000000000000000000000000000")
+		dstAddr := common.HexToAddress("0x2000000000000000000000000000000000000000")
 		reserve0 := big.NewInt(1000000)
 		reserve1 := big.NewInt(2000000)
 
@@ -45,6 +46,21 @@ func TestCalOutAmount(t *testing.T) {
 			})
 		})
 
+		Convey("When the token addresses differ in case", func() {
+			// "0xC02a..." sorts before "0xa0b8..." as a string, but USDC is token0
+			usdcAddr := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+			wethAddr := common.HexToAddress("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2")
+			usdcReserve := big.NewInt(200000000000)
+			wethReserve, _ := new(big.Int).SetString("100000000000000000000", 10)
+			amountIn, _ := new(big.Int).SetString("1000000000000000000", 10)
+
+			result := CalOutAmount(wethAddr, usdcAddr, amountIn, usdcReserve, wethReserve)
+
+			Convey("Then the reserves should be oriented by address value", func() {
+				So(result.String(), ShouldEqual, "1974316068")
+			})
+		})
+
 		Convey("When input amount is zero", func() {
 			amountIn := big.NewInt(0)
 			result := CalOutAmount(srcAddr, dstAddr, amountIn, reserve0, reserve1)
@@ -55,13 +71,13 @@ func TestCalOutAmount(t *testing.T) {
 		})
 
 		Convey("When testing edge cases", func() {
-			Convey("With empty source address", func() {
-				result := CalOutAmount("", dstAddr, big.NewInt(1000), reserve0, reserve1)
+			Convey("With zero source address", func() {
+				result := CalOutAmount(common.Address{}, dstAddr, big.NewInt(1000), reserve0, reserve1)
 				So(result, ShouldBeNil)
 			})
 
-			Convey("With empty destination address", func() {
-				result := CalOutAmount(srcAddr, "", big.NewInt(1000), reserve0, reserve1)
+			Convey("With zero destination address", func() {
+				result := CalOutAmount(srcAddr, common.Address{}, big.NewInt(1000), reserve0, reserve1)
 				So(result, ShouldBeNil)
 			})
 
@@ -93,3 +109,151 @@ func TestCalOutAmount(t *testing.T) {
 		})
 	})
 }
+
+func TestCalInAmount(t *testing.T) {
+	Convey("Given the CalInAmount function for exact-output swap calculations", t, func() {
+		// Common test data
+		srcAddr := common.HexToAddress("0x1000000000000000000000000000000000000000")
+		dstAddr := common.HexToAddress("0x2000000000000000000000000000000000000000")
+		reserve0 := big.NewInt(1000000)
+		reserve1 := big.NewInt(2000000)
+
+		Convey("When calculating with valid inputs", func() {
+			amountOut := big.NewInt(1992)
+			result, err := CalInAmount(srcAddr, dstAddr, amountOut, reserve0, reserve1)
+
+			Convey("Then the input amount should be correctly calculated and rounded up", func() {
+				// (1000000 * 1992 * 1000) / ((2000000 - 1992) * 997) + 1
+				So(err, ShouldBeNil)
+				So(result.Cmp(big.NewInt(1000)), ShouldEqual, 0)
+			})
+
+			Convey("Then swapping the calculated input should yield at least the requested output", func() {
+				So(err, ShouldBeNil)
+				out := CalOutAmount(srcAddr, dstAddr, result, reserve0, reserve1)
+				So(out.Cmp(amountOut), ShouldBeGreaterThanOrEqualTo, 0)
+			})
+		})
+
+		Convey("When source and destination tokens are the same", func() {
+			amountOut := big.NewInt(1000)
+			result, err := CalInAmount(srcAddr, srcAddr, amountOut, reserve0, reserve1)
+
+			Convey("Then the input should equal the output", func() {
+				So(err, ShouldBeNil)
+				So(result.Cmp(amountOut), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When output amount is zero", func() {
+			result, err := CalInAmount(srcAddr, dstAddr, big.NewInt(0), reserve0, reserve1)
+
+			Convey("Then input should be zero", func() {
+				So(err, ShouldBeNil)
+				So(result.Cmp(big.NewInt(0)), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When output amount is equal to or greater than the output reserve", func() {
+			_, errEqual := CalInAmount(srcAddr, dstAddr, big.NewInt(2000000), reserve0, reserve1)
+			_, errGreater := CalInAmount(srcAddr, dstAddr, big.NewInt(2000001), reserve0, reserve1)
+
+			Convey("Then it should report insufficient liquidity", func() {
+				So(errEqual, ShouldEqual, ErrInsufficientLiquidity)
+				So(errGreater, ShouldEqual, ErrInsufficientLiquidity)
+			})
+		})
+
+		Convey("When testing edge cases", func() {
+			Convey("With zero token addresses", func() {
+				_, err := CalInAmount(common.Address{}, dstAddr, big.NewInt(1000), reserve0, reserve1)
+				So(err, ShouldEqual, ErrInvalidAmountInput)
+
+				_, err = CalInAmount(srcAddr, common.Address{}, big.NewInt(1000), reserve0, reserve1)
+				So(err, ShouldEqual, ErrInvalidAmountInput)
+			})
+
+			Convey("With nil values", func() {
+				_, err := CalInAmount(srcAddr, dstAddr, nil, reserve0, reserve1)
+				So(err, ShouldEqual, ErrInvalidAmountInput)
+
+				_, err = CalInAmount(srcAddr, dstAddr, big.NewInt(1000), nil, reserve1)
+				So(err, ShouldEqual, ErrInvalidAmountInput)
+			})
+
+			Convey("With negative amount out", func() {
+				_, err := CalInAmount(srcAddr, dstAddr, big.NewInt(-1000), reserve0, reserve1)
+				So(err, ShouldEqual, ErrInvalidAmountInput)
+			})
+		})
+	})
+}
+
+func TestCalAmountWithFee(t *testing.T) {
+	Convey("Given the fee-aware amount calculation functions", t, func() {
+		// Common test data
+		srcAddr := common.HexToAddress("0x1000000000000000000000000000000000000000")
+		dstAddr := common.HexToAddress("0x2000000000000000000000000000000000000000")
+		reserve0 := big.NewInt(1000000)
+		reserve1 := big.NewInt(2000000)
+
+		Convey("When using the default Uniswap V2 fee", func() {
+			Convey("Then the results should match the fee-less variants", func() {
+				for _, amount := range []int64{1, 999, 1000, 123456, 999999} {
+					out := CalOutAmountWithFee(srcAddr, dstAddr, big.NewInt(amount), reserve0, reserve1, DefaultFeeBps)
+					So(out.Cmp(CalOutAmount(srcAddr, dstAddr, big.NewInt(amount), reserve0, reserve1)), ShouldEqual, 0)
+
+					in, err := CalInAmountWithFee(srcAddr, dstAddr, big.NewInt(amount), reserve0, reserve1, DefaultFeeBps)
+					So(err, ShouldBeNil)
+					expected, _ := CalInAmount(srcAddr, dstAddr, big.NewInt(amount), reserve0, reserve1)
+					So(in.Cmp(expected), ShouldEqual, 0)
+				}
+			})
+		})
+
+		Convey("When using a 0.25% fee", func() {
+			out := CalOutAmountWithFee(srcAddr, dstAddr, big.NewInt(1000), reserve0, reserve1, 25)
+			in, err := CalInAmountWithFee(srcAddr, dstAddr, big.NewInt(1993), reserve0, reserve1, 25)
+
+			Convey("Then the amounts should reflect the lower fee", func() {
+				// (1000 * 9975 * 2000000) / (1000000 * 10000 + 1000 * 9975)
+				So(out.Cmp(big.NewInt(1993)), ShouldEqual, 0)
+				// (1000000 * 1993 * 10000) / ((2000000 - 1993) * 9975) + 1
+				So(err, ShouldBeNil)
+				So(in.Cmp(big.NewInt(1000)), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When using a zero fee", func() {
+			out := CalOutAmountWithFee(srcAddr, dstAddr, big.NewInt(1000), reserve0, reserve1, 0)
+
+			Convey("Then the amount should follow the plain constant product", func() {
+				So(out.Cmp(big.NewInt(1998)), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When pricing the pair at the margin", func() {
+			spot, err := CalSpotPrice(srcAddr, dstAddr, reserve0, reserve1)
+			reverse, errReverse := CalSpotPrice(dstAddr, srcAddr, reserve0, reserve1)
+			_, errEmpty := CalSpotPrice(srcAddr, dstAddr, big.NewInt(0), reserve1)
+
+			Convey("Then it should be the ratio of the oriented reserves", func() {
+				So(err, ShouldBeNil)
+				So(spot.RatString(), ShouldEqual, "2")
+				So(errReverse, ShouldBeNil)
+				So(reverse.RatString(), ShouldEqual, "1/2")
+				So(errEmpty, ShouldEqual, ErrInsufficientLiquidity)
+			})
+		})
+
+		Convey("When the fee is 100% or more", func() {
+			out := CalOutAmountWithFee(srcAddr, dstAddr, big.NewInt(1000), reserve0, reserve1, 10000)
+			_, err := CalInAmountWithFee(srcAddr, dstAddr, big.NewInt(1000), reserve0, reserve1, 10000)
+
+			Convey("Then the calculation should be rejected", func() {
+				So(out, ShouldBeNil)
+				So(err, ShouldEqual, ErrInvalidAmountInput)
+			})
+		})
+	})
+}
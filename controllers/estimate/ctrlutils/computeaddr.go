@@ -1,7 +1,7 @@
 package ctrlutils
 
 import (
-	"strings"
+	"bytes"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -12,7 +12,7 @@ import (
 func ComputePairAddr(factory, tokenA, tokenB common.Address, initCodeHash common.Hash) common.Address {
 	// Sort token addresses to determine token0 and token1
 	var token0, token1 common.Address
-	if strings.ToLower(tokenA.Hex()) < strings.ToLower(tokenB.Hex()) {
+	if bytes.Compare(tokenA.Bytes(), tokenB.Bytes()) < 0 {
 		token0 = tokenA
 		token1 = tokenB
 	} else {
@@ -37,22 +37,12 @@ func ComputePairAddr(factory, tokenA, tokenB common.Address, initCodeHash common
 	return common.BytesToAddress(hash.Bytes()[12:])
 }
 
-func ComputePairAddrStr(factoryStr, tokenAStr, tokenBStr, initCodeHashStr string) string {
-	factory := common.HexToAddress(factoryStr)
-	tokenA := common.HexToAddress(tokenAStr)
-	tokenB := common.HexToAddress(tokenBStr)
-	initCodeHash := common.HexToHash(initCodeHashStr)
-
-	pairAddr := ComputePairAddr(factory, tokenA, tokenB, initCodeHash)
-	return pairAddr.Hex()
-}
-
-func ComputeUniV2PairAddrStr(tokenAStr, tokenBStr string) string {
+func ComputeUniV2PairAddr(tokenA, tokenB common.Address) common.Address {
 	// Uniswap V2 factory address on Ethereum mainnet
 	factory := common.HexToAddress("0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f")
 
 	// Uniswap V2 pair contract init code hash
 	initCodeHash := common.HexToHash("0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f")
 
-	return ComputePairAddrStr(factory.Hex(), tokenAStr, tokenBStr, initCodeHash.Hex())
+	return ComputePairAddr(factory, tokenA, tokenB, initCodeHash)
 }
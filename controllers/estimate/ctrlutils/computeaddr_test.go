@@ -1,7 +1,6 @@
 package ctrlutils
 
 import (
-	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -46,73 +45,38 @@ func TestComputePairAddr(t *testing.T) {
 	})
 }
 
-func TestComputePairAddrStr(t *testing.T) {
-	Convey("Given the string-based pair address computation function", t, func() {
-		factoryStr := "0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f"
-		wethStr := "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
-		usdcStr := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
-		initCodeHashStr := "0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f"
-
-		// Known WETH-USDC pair address on mainnet
-		expectedPairAddrStr := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
-
-		Convey("When computing pair address with string inputs", func() {
-			result := ComputePairAddrStr(factoryStr, wethStr, usdcStr, initCodeHashStr)
-
-			Convey("Then the computed address should match the expected pair address", func() {
-				So(strings.EqualFold(result, expectedPairAddrStr), ShouldBeTrue)
-			})
-		})
-
-		Convey("When computing with reversed token order", func() {
-			result := ComputePairAddrStr(factoryStr, usdcStr, wethStr, initCodeHashStr)
-
-			Convey("Then the computed address should still match the expected pair address", func() {
-				So(strings.EqualFold(result, expectedPairAddrStr), ShouldBeTrue)
-			})
-		})
-
-		Convey("When comparing results for both token orders", func() {
-			result1 := ComputePairAddrStr(factoryStr, wethStr, usdcStr, initCodeHashStr)
-			result2 := ComputePairAddrStr(factoryStr, usdcStr, wethStr, initCodeHashStr)
-
-			Convey("Then both results should be identical", func() {
-				So(strings.EqualFold(result1, result2), ShouldBeTrue)
-			})
-		})
-	})
-}
-
-func TestComputeUniV2PairAddrStr(t *testing.T) {
+func TestComputeUniV2PairAddr(t *testing.T) {
 	Convey("Given the Uniswap V2 specific pair computation function", t, func() {
-		wethStr := "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
-		usdcStr := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+		weth := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+		usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
 
 		// Known WETH-USDC pair address on Uniswap V2
-		expectedPairAddrStr := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+		expectedPairAddr := common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc")
 
 		Convey("When computing Uniswap V2 pair address", func() {
-			result := ComputeUniV2PairAddrStr(wethStr, usdcStr)
+			result := ComputeUniV2PairAddr(weth, usdc)
 
 			Convey("Then the computed address should match the expected Uniswap V2 pair address", func() {
-				So(strings.EqualFold(result, expectedPairAddrStr), ShouldBeTrue)
+				So(result, ShouldEqual, expectedPairAddr)
 			})
 		})
 
 		Convey("When computing with reversed token order", func() {
-			result := ComputeUniV2PairAddrStr(usdcStr, wethStr)
+			result := ComputeUniV2PairAddr(usdc, weth)
 
 			Convey("Then the computed address should still be correct", func() {
-				So(strings.EqualFold(result, expectedPairAddrStr), ShouldBeTrue)
+				So(result, ShouldEqual, expectedPairAddr)
 			})
 		})
 
-		Convey("When comparing results for both orders", func() {
-			result1 := ComputeUniV2PairAddrStr(wethStr, usdcStr)
-			result2 := ComputeUniV2PairAddrStr(usdcStr, wethStr)
+		Convey("When computing with lowercase token addresses", func() {
+			result := ComputeUniV2PairAddr(
+				common.HexToAddress("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"),
+				common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"),
+			)
 
-			Convey("Then both results should be identical", func() {
-				So(strings.EqualFold(result1, result2), ShouldBeTrue)
+			Convey("Then the computed address should not depend on the case", func() {
+				So(result, ShouldEqual, expectedPairAddr)
 			})
 		})
 	})
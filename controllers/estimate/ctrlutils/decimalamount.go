@@ -0,0 +1,57 @@
+package ctrlutils
+
+import (
+	"errors"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+var ErrInvalidDecimalAmount = errors.New("invalid decimal amount")
+
+var decimalAmountRegex = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// ParseDecimalAmount converts a human readable amount such as "1.5" into base
+// units of a token with the given decimals. No rounding is done: an amount
+// with more fractional digits than the token has decimals is rejected.
+func ParseDecimalAmount(amountStr string, decimals uint8) (*big.Int, error) {
+	if !decimalAmountRegex.MatchString(amountStr) {
+		return nil, ErrInvalidDecimalAmount
+	}
+
+	integerPart, fractionalPart, _ := strings.Cut(amountStr, ".")
+	fractionalPart = strings.TrimRight(fractionalPart, "0")
+	if len(fractionalPart) > int(decimals) {
+		return nil, ErrInvalidDecimalAmount
+	}
+
+	digits := integerPart + fractionalPart + strings.Repeat("0", int(decimals)-len(fractionalPart))
+	amount, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, ErrInvalidDecimalAmount
+	}
+	return amount, nil
+}
+
+// FormatDecimalAmount renders an amount in base units as an exact decimal
+// number, without trailing fractional zeros.
+func FormatDecimalAmount(amount *big.Int, decimals uint8) string {
+	digits := new(big.Int).Abs(amount).String()
+	if len(digits) <= int(decimals) {
+		digits = strings.Repeat("0", int(decimals)-len(digits)+1) + digits
+	}
+
+	split := len(digits) - int(decimals)
+	res := digits[:split]
+	if fractionalPart := strings.TrimRight(digits[split:], "0"); fractionalPart != "" {
+		res += "." + fractionalPart
+	}
+	if amount.Sign() < 0 {
+		res = "-" + res
+	}
+	return res
+}
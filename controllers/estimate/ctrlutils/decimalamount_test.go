@@ -0,0 +1,81 @@
+package ctrlutils
+
+import (
+	"math/big"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseDecimalAmount(t *testing.T) {
+	Convey("Given a decimal amount parser", t, func() {
+		Convey("When parsing valid amounts", func() {
+			for _, tc := range []struct {
+				amountStr string
+				decimals  uint8
+				expected  string
+			}{
+				{"1.5", 18, "1500000000000000000"},
+				{"1", 6, "1000000"},
+				{"0.000001", 6, "1"},
+				{"2.500000", 6, "2500000"},
+				{"0.10000000", 6, "100000"},
+				{"42", 0, "42"},
+			} {
+				amount, err := ParseDecimalAmount(tc.amountStr, tc.decimals)
+				So(err, ShouldBeNil)
+				So(amount.String(), ShouldEqual, tc.expected)
+			}
+		})
+
+		Convey("When parsing invalid amounts", func() {
+			for _, tc := range []struct {
+				amountStr string
+				decimals  uint8
+			}{
+				{"", 18},
+				{"-1", 18},
+				{"1.", 18},
+				{".5", 18},
+				{"1e18", 18},
+				{"1,5", 18},
+				{"0.0000001", 6}, // more fractional digits than decimals
+				{"1.5", 0},
+			} {
+				_, err := ParseDecimalAmount(tc.amountStr, tc.decimals)
+				So(err, ShouldEqual, ErrInvalidDecimalAmount)
+			}
+		})
+	})
+}
+
+func TestFormatDecimalAmount(t *testing.T) {
+	Convey("Given a decimal amount formatter", t, func() {
+		Convey("When formatting amounts", func() {
+			for _, tc := range []struct {
+				amount   int64
+				decimals uint8
+				expected string
+			}{
+				{1974316068, 6, "1974.316068"},
+				{1500000, 6, "1.5"},
+				{1000000, 6, "1"},
+				{1, 6, "0.000001"},
+				{0, 18, "0"},
+				{42, 0, "42"},
+				{-1500000, 6, "-1.5"},
+			} {
+				So(FormatDecimalAmount(big.NewInt(tc.amount), tc.decimals), ShouldEqual, tc.expected)
+			}
+		})
+
+		Convey("When formatting a parsed amount", func() {
+			amount, err := ParseDecimalAmount("123.456", 18)
+			So(err, ShouldBeNil)
+
+			Convey("Then the round trip should be exact", func() {
+				So(FormatDecimalAmount(amount, 18), ShouldEqual, "123.456")
+			})
+		})
+	})
+}
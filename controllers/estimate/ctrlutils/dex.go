@@ -0,0 +1,107 @@
+package ctrlutils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Dex describes a Uniswap V2 compatible exchange deployment.
+type Dex struct {
+	Name         string
+	Factory      common.Address
+	InitCodeHash common.Hash
+	FeeBps       uint64
+}
+
+// DexList is the registry of known Uniswap V2 forks. It can be loaded from an
+// environment variable formatted as comma-separated
+// "name:factory:initCodeHash:feeBps" entries.
+type DexList []Dex
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (d Dex) PairAddr(tokenA, tokenB common.Address) common.Address {
+	return ComputePairAddr(d.Factory, tokenA, tokenB, d.InitCodeHash)
+}
+
+func (d Dex) IsValidPairAddr(tokenA, tokenB, pairAddr common.Address) bool {
+	return d.PairAddr(tokenA, tokenB) == pairAddr
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (l *DexList) EnvDecode(val string) error {
+	dexes := DexList{}
+	seenNames := make(map[string]bool)
+
+	for _, entry := range strings.Split(val, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) != 4 {
+			return fmt.Errorf("invalid dex entry %q: expected name:factory:initCodeHash:feeBps", entry)
+		}
+		name, factoryStr, initCodeHashStr, feeStr := parts[0], parts[1], parts[2], parts[3]
+
+		if name == "" {
+			return fmt.Errorf("invalid dex entry %q: empty name", entry)
+		}
+		if seenNames[name] {
+			return fmt.Errorf("invalid dex entry %q: duplicated name", entry)
+		}
+		if !IsValidAddr(factoryStr) {
+			return fmt.Errorf("invalid dex entry %q: invalid factory address", entry)
+		}
+		if !IsValidHash(initCodeHashStr) {
+			return fmt.Errorf("invalid dex entry %q: invalid init code hash", entry)
+		}
+		fee, err := strconv.ParseUint(feeStr, 10, 64)
+		if err != nil || fee >= 10000 {
+			return fmt.Errorf("invalid dex entry %q: invalid fee in basis points", entry)
+		}
+
+		seenNames[name] = true
+		dexes = append(dexes, Dex{
+			Name:         name,
+			Factory:      common.HexToAddress(factoryStr),
+			InitCodeHash: common.HexToHash(initCodeHashStr),
+			FeeBps:       fee,
+		})
+	}
+
+	if len(dexes) == 0 {
+		return fmt.Errorf("no dex configured")
+	}
+
+	*l = dexes
+	return nil
+}
+
+// Find returns the registered DEX with the given name.
+func (l DexList) Find(name string) (Dex, bool) {
+	for _, dex := range l {
+		if strings.EqualFold(dex.Name, name) {
+			return dex, true
+		}
+	}
+	return Dex{}, false
+}
+
+// MatchPair returns the first registered DEX whose CREATE2 derivation of the
+// token pair yields pairAddr.
+func (l DexList) MatchPair(tokenA, tokenB, pairAddr common.Address) (Dex, bool) {
+	for _, dex := range l {
+		if dex.IsValidPairAddr(tokenA, tokenB, pairAddr) {
+			return dex, true
+		}
+	}
+	return Dex{}, false
+}
@@ -0,0 +1,101 @@
+package ctrlutils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const (
+	testUniswapDexEntry = "uniswapv2:0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f:0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f:30"
+	testForkDexEntry    = "testfork:0x1000000000000000000000000000000000000001:0x1111111111111111111111111111111111111111111111111111111111111111:25"
+)
+
+func TestDexListEnvDecode(t *testing.T) {
+	Convey("Given the DEX registry decoder", t, func() {
+		Convey("When decoding a valid list of DEXes", func() {
+			dexes := DexList{}
+			err := dexes.EnvDecode(testUniswapDexEntry + "," + testForkDexEntry)
+
+			Convey("Then every DEX should be registered in order", func() {
+				So(err, ShouldBeNil)
+				So(dexes, ShouldHaveLength, 2)
+				So(dexes[0].Name, ShouldEqual, "uniswapv2")
+				So(dexes[0].Factory.Hex(), ShouldEqual, "0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f")
+				So(dexes[0].FeeBps, ShouldEqual, 30)
+				So(dexes[1].Name, ShouldEqual, "testfork")
+				So(dexes[1].FeeBps, ShouldEqual, 25)
+			})
+		})
+
+		Convey("When decoding malformed entries", func() {
+			dexes := DexList{}
+
+			So(dexes.EnvDecode("uniswapv2:0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f"), ShouldNotBeNil)
+			So(dexes.EnvDecode(strings.Replace(testUniswapDexEntry, "0x5C69", "0xZZ69", 1)), ShouldNotBeNil)
+			So(dexes.EnvDecode(strings.Replace(testUniswapDexEntry, ":0x96e8", ":0x96", 1)), ShouldNotBeNil)
+			So(dexes.EnvDecode(strings.Replace(testUniswapDexEntry, ":30", ":10000", 1)), ShouldNotBeNil)
+			So(dexes.EnvDecode(testUniswapDexEntry+","+testUniswapDexEntry), ShouldNotBeNil)
+			So(dexes.EnvDecode(""), ShouldNotBeNil)
+
+			Convey("Then the registry should be left untouched", func() {
+				So(dexes, ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestDexListMatchPair(t *testing.T) {
+	Convey("Given a registry with Uniswap V2 and a fork", t, func() {
+		dexes := DexList{}
+		So(dexes.EnvDecode(testUniswapDexEntry+","+testForkDexEntry), ShouldBeNil)
+
+		weth := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+		usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+
+		Convey("When matching the Uniswap V2 WETH-USDC pair", func() {
+			dex, ok := dexes.MatchPair(weth, usdc, common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"))
+
+			Convey("Then Uniswap V2 should be reported", func() {
+				So(ok, ShouldBeTrue)
+				So(dex.Name, ShouldEqual, "uniswapv2")
+			})
+		})
+
+		Convey("When matching the fork WETH-USDC pair", func() {
+			forkPairAddr := ComputePairAddr(
+				common.HexToAddress("0x1000000000000000000000000000000000000001"),
+				weth,
+				usdc,
+				common.HexToHash("0x1111111111111111111111111111111111111111111111111111111111111111"),
+			)
+			dex, ok := dexes.MatchPair(usdc, weth, forkPairAddr)
+
+			Convey("Then the fork should be reported", func() {
+				So(ok, ShouldBeTrue)
+				So(dex.Name, ShouldEqual, "testfork")
+			})
+		})
+
+		Convey("When matching an unknown pair", func() {
+			_, ok := dexes.MatchPair(weth, usdc, common.Address{})
+
+			Convey("Then no DEX should match", func() {
+				So(ok, ShouldBeFalse)
+			})
+		})
+
+		Convey("When looking a DEX up by name", func() {
+			dex, ok := dexes.Find("TestFork")
+			_, missing := dexes.Find("pancakeswap")
+
+			Convey("Then the lookup should be case-insensitive", func() {
+				So(ok, ShouldBeTrue)
+				So(dex.Name, ShouldEqual, "testfork")
+				So(missing, ShouldBeFalse)
+			})
+		})
+	})
+}
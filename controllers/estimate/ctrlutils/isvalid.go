@@ -1,25 +1,58 @@
 package ctrlutils
 
-import "strings"
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+)
 
 ////////////////////////////////////////////////////////////////////////////////
 
+var (
+	ErrInvalidAddr         = errors.New("invalid address format")
+	ErrInvalidAddrChecksum = errors.New("invalid address checksum")
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// ParseAddr parses a hex address. With strict set, the address must be in its
+// EIP-55 checksummed form; otherwise any case is accepted.
+func ParseAddr(addrStr string, strict bool) (common.Address, error) {
+	if !IsValidAddr(addrStr) {
+		return common.Address{}, ErrInvalidAddr
+	}
+	addr := common.HexToAddress(addrStr)
+	if strict && addr.Hex() != addrStr {
+		return common.Address{}, ErrInvalidAddrChecksum
+	}
+	return addr, nil
+}
+
 func IsValidAddr(addr string) bool {
-	if len(addr) != 42 {
+	return isValidHexStr(addr, 20)
+}
+
+func IsValidHash(hash string) bool {
+	return isValidHexStr(hash, 32)
+}
+
+func IsValidUniV2PairAddr(tokenA, tokenB, pairAddr common.Address) bool {
+	return ComputeUniV2PairAddr(tokenA, tokenB) == pairAddr
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func isValidHexStr(s string, byteLen int) bool {
+	if len(s) != 2+byteLen*2 {
 		return false
 	}
-	if addr[:2] != "0x" {
+	if s[:2] != "0x" {
 		return false
 	}
-	for _, c := range addr[2:] {
+	for _, c := range s[2:] {
 		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')) {
 			return false
 		}
 	}
 	return true
 }
-
-func IsValidUniV2PairAddr(tokenAStr, tokenBStr, pairAddrStr string) bool {
-	computedAddr := ComputeUniV2PairAddrStr(tokenAStr, tokenBStr)
-	return strings.EqualFold(computedAddr, pairAddrStr)
-}
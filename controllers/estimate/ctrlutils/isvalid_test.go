@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -55,14 +56,73 @@ func TestIsValidAddr(t *testing.T) {
 	})
 }
 
+func TestParseAddr(t *testing.T) {
+	Convey("Given the address parsing function", t, func() {
+		checksummed := "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+		expected := common.HexToAddress(checksummed)
+
+		Convey("When parsing without strict checksums", func() {
+			lower, lowerErr := ParseAddr(strings.ToLower(checksummed), false)
+			mixed, mixedErr := ParseAddr("0xc02AAA39b223FE8D0A0e5C4F27eAD9083C756Cc2", false)
+
+			Convey("Then any case should be accepted", func() {
+				So(lowerErr, ShouldBeNil)
+				So(lower, ShouldEqual, expected)
+				So(mixedErr, ShouldBeNil)
+				So(mixed, ShouldEqual, expected)
+			})
+		})
+
+		Convey("When parsing with strict checksums", func() {
+			valid, validErr := ParseAddr(checksummed, true)
+			_, lowerErr := ParseAddr(strings.ToLower(checksummed), true)
+			_, mixedErr := ParseAddr("0xc02AAA39b223FE8D0A0e5C4F27eAD9083C756Cc2", true)
+
+			Convey("Then only the EIP-55 form should be accepted", func() {
+				So(validErr, ShouldBeNil)
+				So(valid, ShouldEqual, expected)
+				So(lowerErr, ShouldEqual, ErrInvalidAddrChecksum)
+				So(mixedErr, ShouldEqual, ErrInvalidAddrChecksum)
+			})
+		})
+
+		Convey("When parsing a malformed address", func() {
+			_, err := ParseAddr("0x1234", true)
+
+			Convey("Then it should be rejected as malformed", func() {
+				So(err, ShouldEqual, ErrInvalidAddr)
+			})
+		})
+	})
+}
+
+func TestIsValidHash(t *testing.T) {
+	Convey("Given the 32-byte hash validation function", t, func() {
+		Convey("When validating a correctly formatted hash", func() {
+			result := IsValidHash("0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f")
+			So(result, ShouldBeTrue)
+		})
+
+		Convey("When validating an address-sized value", func() {
+			result := IsValidHash("0x1234567890abcdef1234567890abcdef12345678")
+			So(result, ShouldBeFalse)
+		})
+
+		Convey("When validating a hash containing invalid characters", func() {
+			result := IsValidHash("0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da34884zz")
+			So(result, ShouldBeFalse)
+		})
+	})
+}
+
 func TestIsValidUniV2PairAddr(t *testing.T) {
 	Convey("Given the Uniswap V2 pair address validation function", t, func() {
 		// Real world token addresses
-		weth := "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
-		usdc := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+		weth := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+		usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
 
 		// Compute the expected pair address
-		expectedPairAddr := ComputeUniV2PairAddrStr(weth, usdc)
+		expectedPairAddr := ComputeUniV2PairAddr(weth, usdc)
 
 		Convey("When validating a correct pair address", func() {
 			result := IsValidUniV2PairAddr(weth, usdc, expectedPairAddr)
@@ -81,16 +141,16 @@ func TestIsValidUniV2PairAddr(t *testing.T) {
 		})
 
 		Convey("When validating with a different case in the pair address", func() {
-			upperPairAddr := expectedPairAddr[:2] + strings.ToUpper(expectedPairAddr[2:])
+			upperPairAddr := common.HexToAddress("0x" + strings.ToUpper(expectedPairAddr.Hex()[2:]))
 			result := IsValidUniV2PairAddr(weth, usdc, upperPairAddr)
 
-			Convey("Then it should return true due to case-insensitive comparison", func() {
+			Convey("Then it should return true since addresses are compared by value", func() {
 				So(result, ShouldBeTrue)
 			})
 		})
 
 		Convey("When validating an incorrect pair address", func() {
-			invalidPairAddr := "0x0000000000000000000000000000000000000000"
+			invalidPairAddr := common.Address{}
 			result := IsValidUniV2PairAddr(weth, usdc, invalidPairAddr)
 
 			Convey("Then it should return false", func() {
@@ -0,0 +1,52 @@
+package ctrlutils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// PoolFeeMap holds the swap fee overrides of pools in basis points, keyed by
+// pool address. It can be loaded from an environment variable formatted as
+// comma-separated "pool:feeBps" entries.
+type PoolFeeMap map[common.Address]uint64
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (m *PoolFeeMap) EnvDecode(val string) error {
+	fees := PoolFeeMap{}
+
+	for _, entry := range strings.Split(val, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid pool fee entry %q: expected pool:feeBps", entry)
+		}
+		poolStr, feeStr := parts[0], parts[1]
+
+		if !IsValidAddr(poolStr) {
+			return fmt.Errorf("invalid pool fee entry %q: invalid pool address", entry)
+		}
+		pool := common.HexToAddress(poolStr)
+		if _, ok := fees[pool]; ok {
+			return fmt.Errorf("invalid pool fee entry %q: duplicated pool", entry)
+		}
+		fee, err := strconv.ParseUint(feeStr, 10, 64)
+		if err != nil || fee >= 10000 {
+			return fmt.Errorf("invalid pool fee entry %q: invalid fee in basis points", entry)
+		}
+
+		fees[pool] = fee
+	}
+
+	*m = fees
+	return nil
+}
@@ -0,0 +1,51 @@
+package ctrlutils
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testPoolFeeEntry = "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc:25"
+
+func TestPoolFeeMapEnvDecode(t *testing.T) {
+	Convey("Given the pool fee overrides decoder", t, func() {
+		Convey("When decoding valid overrides", func() {
+			fees := PoolFeeMap{}
+			err := fees.EnvDecode(testPoolFeeEntry + ", 0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852:5")
+
+			Convey("Then every pool should be keyed by its address", func() {
+				So(err, ShouldBeNil)
+				So(fees, ShouldHaveLength, 2)
+				So(fees[common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc")], ShouldEqual, 25)
+				So(fees[common.HexToAddress("0x0d4a11d5EEaaC28EC3F61d100daF4d40471f1852")], ShouldEqual, 5)
+			})
+		})
+
+		Convey("When decoding no overrides", func() {
+			fees := PoolFeeMap{}
+			err := fees.EnvDecode("")
+
+			Convey("Then no pool should be overridden", func() {
+				So(err, ShouldBeNil)
+				So(fees, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When decoding malformed entries", func() {
+			fees := PoolFeeMap{}
+
+			So(fees.EnvDecode("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"), ShouldNotBeNil)
+			So(fees.EnvDecode("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9D:25"), ShouldNotBeNil)
+			So(fees.EnvDecode("pool:25"), ShouldNotBeNil)
+			So(fees.EnvDecode("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc:10000"), ShouldNotBeNil)
+			So(fees.EnvDecode("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc:abc"), ShouldNotBeNil)
+			So(fees.EnvDecode(testPoolFeeEntry+","+testPoolFeeEntry), ShouldNotBeNil)
+
+			Convey("Then the overrides should be left untouched", func() {
+				So(fees, ShouldBeEmpty)
+			})
+		})
+	})
+}
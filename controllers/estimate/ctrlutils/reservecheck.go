@@ -0,0 +1,84 @@
+package ctrlutils
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+var (
+	// ErrReserveOverflow is returned for a reserve a Uniswap V2 pair cannot
+	// hold, which means the state was corrupted on its way from the chain.
+	ErrReserveOverflow = errors.New("reserve exceeds uint112")
+	// ErrZeroReserve is returned for a pair without liquidity on either side.
+	ErrZeroReserve = errors.New("pair has a zero reserve")
+	// ErrInvariantViolated is returned for a quote that would decrease the
+	// constant product of the pair, fee included.
+	ErrInvariantViolated = errors.New("constant product invariant violated")
+)
+
+// maxUint112 is the largest reserve of a Uniswap V2 pair, stored as uint112.
+var maxUint112 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 112), big.NewInt(1))
+
+////////////////////////////////////////////////////////////////////////////////
+
+// ValidateReserves checks the reserves of a Uniswap V2 pair before they are
+// quoted: both must fit in a uint112 and neither may be zero.
+func ValidateReserves(reserve0, reserve1 *big.Int) error {
+	if reserve0 == nil || reserve1 == nil || reserve0.Sign() < 0 || reserve1.Sign() < 0 {
+		return ErrInvalidAmountInput
+	}
+	if reserve0.Cmp(maxUint112) > 0 || reserve1.Cmp(maxUint112) > 0 {
+		return ErrReserveOverflow
+	}
+	if reserve0.Sign() == 0 || reserve1.Sign() == 0 {
+		return ErrZeroReserve
+	}
+	return nil
+}
+
+// ValidateSwap checks a quoted swap of amountIn for amountOut against the
+// pair: the output must be below the output reserve, and the balances after
+// the swap must keep the constant product, fee included, as the pair's swap
+// function requires:
+//
+//	(balanceIn*10000 - amountIn*feeBps) * balanceOut*10000 >= reserveIn*reserveOut*10000^2
+func ValidateSwap(
+	srcTokenAddr common.Address,
+	dstTokenAddr common.Address,
+	amountIn, amountOut, reserve0, reserve1 *big.Int,
+	feeBps uint64,
+) error {
+	if amountIn == nil || amountOut == nil || amountIn.Sign() < 0 || amountOut.Sign() < 0 {
+		return ErrInvalidAmountInput
+	}
+	if feeBps >= feeBpsDenominator {
+		return ErrInvalidAmountInput
+	}
+	if srcTokenAddr == dstTokenAddr {
+		return nil
+	}
+
+	reserveIn, reserveOut := orientReserves(srcTokenAddr, dstTokenAddr, reserve0, reserve1)
+	if amountOut.Cmp(reserveOut) >= 0 {
+		return ErrInsufficientLiquidity
+	}
+
+	denominator := big.NewInt(feeBpsDenominator)
+	balanceIn := new(big.Int).Add(reserveIn, amountIn)
+	balanceOut := new(big.Int).Sub(reserveOut, amountOut)
+
+	balanceInAdjusted := new(big.Int).Mul(balanceIn, denominator)
+	balanceInAdjusted.Sub(balanceInAdjusted, new(big.Int).Mul(amountIn, new(big.Int).SetUint64(feeBps)))
+	balanceOutAdjusted := new(big.Int).Mul(balanceOut, denominator)
+
+	kAfter := new(big.Int).Mul(balanceInAdjusted, balanceOutAdjusted)
+	kBefore := new(big.Int).Mul(new(big.Int).Mul(reserveIn, reserveOut), new(big.Int).Mul(denominator, denominator))
+	if kAfter.Cmp(kBefore) < 0 {
+		return ErrInvariantViolated
+	}
+	return nil
+}
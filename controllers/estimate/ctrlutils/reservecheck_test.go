@@ -0,0 +1,92 @@
+package ctrlutils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidateReserves(t *testing.T) {
+	Convey("Given the ValidateReserves function", t, func() {
+		Convey("When the reserves are in range", func() {
+			err := ValidateReserves(big.NewInt(1000000), new(big.Int).Set(maxUint112))
+
+			Convey("Then they should be accepted", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+
+		Convey("When a reserve exceeds uint112", func() {
+			err := ValidateReserves(big.NewInt(1000000), new(big.Int).Add(maxUint112, big.NewInt(1)))
+
+			Convey("Then it should return ErrReserveOverflow", func() {
+				So(err, ShouldEqual, ErrReserveOverflow)
+			})
+		})
+
+		Convey("When a reserve is zero", func() {
+			err := ValidateReserves(big.NewInt(0), big.NewInt(1000000))
+
+			Convey("Then it should return ErrZeroReserve", func() {
+				So(err, ShouldEqual, ErrZeroReserve)
+			})
+		})
+
+		Convey("When a reserve is missing or negative", func() {
+			Convey("Then it should return ErrInvalidAmountInput", func() {
+				So(ValidateReserves(nil, big.NewInt(1)), ShouldEqual, ErrInvalidAmountInput)
+				So(ValidateReserves(big.NewInt(-1), big.NewInt(1)), ShouldEqual, ErrInvalidAmountInput)
+			})
+		})
+	})
+}
+
+func TestValidateSwap(t *testing.T) {
+	Convey("Given the ValidateSwap function", t, func() {
+		srcAddr := common.HexToAddress("0x1000000000000000000000000000000000000000")
+		dstAddr := common.HexToAddress("0x2000000000000000000000000000000000000000")
+		reserve0 := big.NewInt(1000000)
+		reserve1 := big.NewInt(2000000)
+
+		Convey("When the swap was quoted with the pair formula", func() {
+			amountIn := big.NewInt(1000)
+			amountOut := CalOutAmount(srcAddr, dstAddr, amountIn, reserve0, reserve1)
+			err := ValidateSwap(srcAddr, dstAddr, amountIn, amountOut, reserve0, reserve1, DefaultFeeBps)
+
+			Convey("Then the invariant should hold", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+
+		Convey("When the exact output swap was quoted with the pair formula", func() {
+			amountOut := big.NewInt(1992)
+			amountIn, err := CalInAmount(dstAddr, srcAddr, amountOut, reserve0, reserve1)
+			So(err, ShouldBeNil)
+			err = ValidateSwap(dstAddr, srcAddr, amountIn, amountOut, reserve0, reserve1, DefaultFeeBps)
+
+			Convey("Then the invariant should hold", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+
+		Convey("When the output is one unit more than the formula gives", func() {
+			amountIn := big.NewInt(1000)
+			amountOut := CalOutAmount(srcAddr, dstAddr, amountIn, reserve0, reserve1)
+			err := ValidateSwap(srcAddr, dstAddr, amountIn, new(big.Int).Add(amountOut, big.NewInt(1)), reserve0, reserve1, DefaultFeeBps)
+
+			Convey("Then it should return ErrInvariantViolated", func() {
+				So(err, ShouldEqual, ErrInvariantViolated)
+			})
+		})
+
+		Convey("When the output drains the output reserve", func() {
+			err := ValidateSwap(srcAddr, dstAddr, big.NewInt(1000), reserve1, reserve0, reserve1, DefaultFeeBps)
+
+			Convey("Then it should return ErrInsufficientLiquidity", func() {
+				So(err, ShouldEqual, ErrInsufficientLiquidity)
+			})
+		})
+	})
+}
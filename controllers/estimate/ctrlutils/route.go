@@ -0,0 +1,77 @@
+package ctrlutils
+
+import "github.com/ethereum/go-ethereum/common"
+
+////////////////////////////////////////////////////////////////////////////////
+
+type PairEdge struct {
+	PoolAddr common.Address
+	TokenA   common.Address
+	TokenB   common.Address
+	Dex      string
+}
+
+type Route struct {
+	Tokens []common.Address
+	Pools  []common.Address
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// FindRoutes enumerates every simple route from src to dst over the given
+// pair edges using at most maxHops hops.
+func FindRoutes(edges []PairEdge, src, dst common.Address, maxHops int) []Route {
+	if maxHops <= 0 || src == dst {
+		return nil
+	}
+
+	// Build the adjacency list, skipping duplicated pools
+	type neighbour struct {
+		token    common.Address
+		poolAddr common.Address
+	}
+	adjacency := make(map[common.Address][]neighbour)
+	seenPools := make(map[common.Address]bool)
+	for _, edge := range edges {
+		if seenPools[edge.PoolAddr] || edge.TokenA == edge.TokenB {
+			continue
+		}
+		seenPools[edge.PoolAddr] = true
+		adjacency[edge.TokenA] = append(adjacency[edge.TokenA], neighbour{token: edge.TokenB, poolAddr: edge.PoolAddr})
+		adjacency[edge.TokenB] = append(adjacency[edge.TokenB], neighbour{token: edge.TokenA, poolAddr: edge.PoolAddr})
+	}
+
+	// Depth-first search without revisiting tokens
+	var routes []Route
+	visited := map[common.Address]bool{src: true}
+	tokens := []common.Address{src}
+	pools := []common.Address{}
+
+	var walk func(curr common.Address)
+	walk = func(curr common.Address) {
+		for _, next := range adjacency[curr] {
+			if visited[next.token] {
+				continue
+			}
+			tokens = append(tokens, next.token)
+			pools = append(pools, next.poolAddr)
+
+			if next.token == dst {
+				routes = append(routes, Route{
+					Tokens: append([]common.Address(nil), tokens...),
+					Pools:  append([]common.Address(nil), pools...),
+				})
+			} else if len(pools) < maxHops {
+				visited[next.token] = true
+				walk(next.token)
+				visited[next.token] = false
+			}
+
+			tokens = tokens[:len(tokens)-1]
+			pools = pools[:len(pools)-1]
+		}
+	}
+	walk(src)
+
+	return routes
+}
@@ -0,0 +1,86 @@
+package ctrlutils
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFindRoutes(t *testing.T) {
+	Convey("Given the FindRoutes function over a small token graph", t, func() {
+		tokenA := common.HexToAddress("0x1000000000000000000000000000000000000000")
+		tokenB := common.HexToAddress("0x2000000000000000000000000000000000000000")
+		tokenC := common.HexToAddress("0x3000000000000000000000000000000000000000")
+		tokenD := common.HexToAddress("0x4000000000000000000000000000000000000000")
+
+		poolAB := common.HexToAddress("0xAB")
+		poolBC := common.HexToAddress("0xBC")
+		poolAC := common.HexToAddress("0xAC")
+		poolCD := common.HexToAddress("0xCD")
+
+		edges := []PairEdge{
+			{PoolAddr: poolAB, TokenA: tokenA, TokenB: tokenB},
+			{PoolAddr: poolBC, TokenA: tokenB, TokenB: tokenC},
+			{PoolAddr: poolAC, TokenA: tokenA, TokenB: tokenC},
+			{PoolAddr: poolCD, TokenA: tokenC, TokenB: tokenD},
+		}
+
+		Convey("When searching with a single hop", func() {
+			routes := FindRoutes(edges, tokenA, tokenC, 1)
+
+			Convey("Then only the direct route should be returned", func() {
+				So(routes, ShouldHaveLength, 1)
+				So(routes[0].Pools, ShouldResemble, []common.Address{poolAC})
+				So(routes[0].Tokens, ShouldResemble, []common.Address{tokenA, tokenC})
+			})
+		})
+
+		Convey("When searching with two hops", func() {
+			routes := FindRoutes(edges, tokenA, tokenC, 2)
+
+			Convey("Then both the direct and the intermediate routes should be returned", func() {
+				So(routes, ShouldHaveLength, 2)
+				pools := [][]common.Address{routes[0].Pools, routes[1].Pools}
+				So(pools, ShouldContain, []common.Address{poolAC})
+				So(pools, ShouldContain, []common.Address{poolAB, poolBC})
+			})
+		})
+
+		Convey("When the destination is not reachable within the hop limit", func() {
+			routes := FindRoutes(edges, tokenA, tokenD, 1)
+
+			Convey("Then no route should be returned", func() {
+				So(routes, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When token addresses differ only by case", func() {
+			mixedEdges := []PairEdge{
+				{PoolAddr: common.HexToAddress("0xEF"), TokenA: common.HexToAddress("0xABCDEF0000000000000000000000000000000000"), TokenB: tokenA},
+			}
+			routes := FindRoutes(mixedEdges, common.HexToAddress("0xabcdef0000000000000000000000000000000000"), tokenA, 1)
+
+			Convey("Then they should be treated as the same token", func() {
+				So(routes, ShouldHaveLength, 1)
+				So(routes[0].Tokens[0], ShouldEqual, common.HexToAddress("0xABCDEF0000000000000000000000000000000000"))
+			})
+		})
+
+		Convey("When duplicated pools are supplied", func() {
+			routes := FindRoutes(append(edges, edges[2]), tokenA, tokenC, 1)
+
+			Convey("Then each pool should only be used once", func() {
+				So(routes, ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When source and destination are the same token", func() {
+			routes := FindRoutes(edges, tokenA, tokenA, 3)
+
+			Convey("Then no route should be returned", func() {
+				So(routes, ShouldBeEmpty)
+			})
+		})
+	})
+}
@@ -0,0 +1,252 @@
+package ctrlutils
+
+import (
+	"errors"
+	"math/big"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+const stableSwapMaxIterations = 255
+
+var (
+	ErrInvalidCoinIndex = errors.New("invalid coin index")
+	ErrNoConvergence    = errors.New("stable swap invariant did not converge")
+)
+
+var (
+	stableSwapPrecision      = big.NewInt(1e18)
+	stableSwapFeeDenominator = big.NewInt(1e10)
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// StableSwapPool is the state of a Curve StableSwap pool. Fee is expressed in
+// 1e10 units like the pool's fee(), and Rates scale every balance to 18
+// decimals with a 1e18 precision, i.e. 10^(36-decimals) for a plain coin.
+type StableSwapPool struct {
+	A        *big.Int
+	Fee      *big.Int
+	Balances []*big.Int
+	Rates    []*big.Int
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// CalStableSwapOutAmount returns the amount of coin j received for amountIn of
+// coin i, mirroring StableSwap.get_dy.
+func CalStableSwapOutAmount(pool StableSwapPool, i, j int, amountIn *big.Int) (*big.Int, error) {
+	if amountIn == nil || amountIn.Sign() < 0 {
+		return nil, ErrInvalidAmountInput
+	}
+	xp, err := pool.xp(i, j)
+	if err != nil {
+		return nil, err
+	}
+
+	x := new(big.Int).Mul(amountIn, pool.Rates[i])
+	x.Div(x, stableSwapPrecision)
+	x.Add(x, xp[i])
+
+	y, err := stableSwapGetY(pool.A, i, j, x, xp)
+	if err != nil {
+		return nil, err
+	}
+
+	dy := new(big.Int).Sub(xp[j], y)
+	dy.Sub(dy, big.NewInt(1))
+	if dy.Sign() <= 0 {
+		return big.NewInt(0), nil
+	}
+	dy.Mul(dy, stableSwapPrecision)
+	dy.Div(dy, pool.Rates[j])
+
+	fee := new(big.Int).Mul(pool.Fee, dy)
+	fee.Div(fee, stableSwapFeeDenominator)
+	return dy.Sub(dy, fee), nil
+}
+
+// CalStableSwapInAmount returns the amount of coin i required to receive
+// amountOut of coin j, mirroring StableSwap.get_dx and rounding up.
+func CalStableSwapInAmount(pool StableSwapPool, i, j int, amountOut *big.Int) (*big.Int, error) {
+	if amountOut == nil || amountOut.Sign() < 0 {
+		return nil, ErrInvalidAmountInput
+	}
+	xp, err := pool.xp(i, j)
+	if err != nil {
+		return nil, err
+	}
+	if amountOut.Sign() == 0 {
+		return big.NewInt(0), nil
+	}
+
+	// Gross up the output by the fee charged on it
+	dyWithFee := new(big.Int).Mul(amountOut, stableSwapFeeDenominator)
+	dyWithFee.Div(dyWithFee, new(big.Int).Sub(stableSwapFeeDenominator, pool.Fee))
+
+	y := new(big.Int).Mul(dyWithFee, pool.Rates[j])
+	y.Div(y, stableSwapPrecision)
+	y.Sub(xp[j], y)
+	if y.Sign() <= 0 {
+		return nil, ErrInsufficientLiquidity
+	}
+
+	x, err := stableSwapGetY(pool.A, j, i, y, xp)
+	if err != nil {
+		return nil, err
+	}
+
+	dx := new(big.Int).Sub(x, xp[i])
+	dx.Mul(dx, stableSwapPrecision)
+	dx.Div(dx, pool.Rates[i])
+	return dx.Add(dx, big.NewInt(1)), nil
+}
+
+// CalStableSwapSpotPrice returns the marginal price of coin i in coin j before
+// a trade, fees excluded. It is the ratio of the partial derivatives of the
+// invariant on the scaled balances:
+//
+//	price = (Ann + D_P / x_i) / (Ann + D_P / x_j) * rate_i / rate_j
+func CalStableSwapSpotPrice(pool StableSwapPool, i, j int) (*big.Rat, error) {
+	xp, err := pool.xp(i, j)
+	if err != nil {
+		return nil, err
+	}
+	d, err := stableSwapGetD(xp, pool.A)
+	if err != nil {
+		return nil, err
+	}
+
+	// D_P = D^(n+1) / (n^n * prod(x))
+	n := big.NewInt(int64(len(xp)))
+	dP := new(big.Rat).SetInt(d)
+	for _, x := range xp {
+		dP.Mul(dP, new(big.Rat).SetFrac(d, new(big.Int).Mul(x, n)))
+	}
+
+	ann := new(big.Rat).SetInt(new(big.Int).Mul(pool.A, n))
+	numerator := new(big.Rat).Add(ann, new(big.Rat).Quo(dP, new(big.Rat).SetInt(xp[i])))
+	denominator := new(big.Rat).Add(ann, new(big.Rat).Quo(dP, new(big.Rat).SetInt(xp[j])))
+
+	price := new(big.Rat).Quo(numerator, denominator)
+	return price.Mul(price, new(big.Rat).SetFrac(pool.Rates[i], pool.Rates[j])), nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// xp returns the balances scaled by the rates after validating the pool.
+func (p StableSwapPool) xp(i, j int) ([]*big.Int, error) {
+	n := len(p.Balances)
+	if n < 2 || len(p.Rates) != n {
+		return nil, ErrInvalidAmountInput
+	}
+	if i < 0 || j < 0 || i >= n || j >= n || i == j {
+		return nil, ErrInvalidCoinIndex
+	}
+	if p.A == nil || p.A.Sign() <= 0 || p.Fee == nil || p.Fee.Sign() < 0 ||
+		p.Fee.Cmp(stableSwapFeeDenominator) >= 0 {
+		return nil, ErrInvalidAmountInput
+	}
+
+	xp := make([]*big.Int, n)
+	for k := range n {
+		if p.Balances[k] == nil || p.Rates[k] == nil || p.Rates[k].Sign() <= 0 {
+			return nil, ErrInvalidAmountInput
+		}
+		xp[k] = new(big.Int).Mul(p.Balances[k], p.Rates[k])
+		xp[k].Div(xp[k], stableSwapPrecision)
+		if xp[k].Sign() <= 0 {
+			return nil, ErrInsufficientLiquidity
+		}
+	}
+	return xp, nil
+}
+
+// stableSwapGetD solves the StableSwap invariant D for the scaled balances xp
+// by Newton iteration, mirroring StableSwap.get_D.
+func stableSwapGetD(xp []*big.Int, amp *big.Int) (*big.Int, error) {
+	n := big.NewInt(int64(len(xp)))
+	s := new(big.Int)
+	for _, x := range xp {
+		s.Add(s, x)
+	}
+	if s.Sign() == 0 {
+		return s, nil
+	}
+
+	d := new(big.Int).Set(s)
+	ann := new(big.Int).Mul(amp, n)
+	annS := new(big.Int).Mul(ann, s)
+	annMinusOne := new(big.Int).Sub(ann, big.NewInt(1))
+	nPlusOne := new(big.Int).Add(n, big.NewInt(1))
+
+	for range stableSwapMaxIterations {
+		dP := new(big.Int).Set(d)
+		for _, x := range xp {
+			dP.Mul(dP, d)
+			dP.Div(dP, new(big.Int).Mul(x, n))
+		}
+		dPrev := d
+
+		// D = (Ann*S + D_P*N) * D / ((Ann - 1) * D + (N + 1) * D_P)
+		numerator := new(big.Int).Mul(dP, n)
+		numerator.Add(numerator, annS)
+		numerator.Mul(numerator, d)
+		denominator := new(big.Int).Mul(annMinusOne, d)
+		denominator.Add(denominator, new(big.Int).Mul(nPlusOne, dP))
+		d = numerator.Div(numerator, denominator)
+
+		if new(big.Int).Sub(d, dPrev).CmpAbs(big.NewInt(1)) <= 0 {
+			return d, nil
+		}
+	}
+	return nil, ErrNoConvergence
+}
+
+// stableSwapGetY returns the new balance of coin j once coin i is set to x,
+// keeping D constant, mirroring StableSwap.get_y.
+func stableSwapGetY(amp *big.Int, i, j int, x *big.Int, xp []*big.Int) (*big.Int, error) {
+	d, err := stableSwapGetD(xp, amp)
+	if err != nil {
+		return nil, err
+	}
+
+	n := big.NewInt(int64(len(xp)))
+	ann := new(big.Int).Mul(amp, n)
+	c := new(big.Int).Set(d)
+	s := new(big.Int)
+	for k := range xp {
+		if k == j {
+			continue
+		}
+		xk := xp[k]
+		if k == i {
+			xk = x
+		}
+		s.Add(s, xk)
+		c.Mul(c, d)
+		c.Div(c, new(big.Int).Mul(xk, n))
+	}
+	c.Mul(c, d)
+	c.Div(c, new(big.Int).Mul(ann, n))
+	b := new(big.Int).Div(d, ann)
+	b.Add(b, s)
+
+	y := new(big.Int).Set(d)
+	for range stableSwapMaxIterations {
+		yPrev := y
+
+		// y = (y*y + c) / (2*y + b - D)
+		numerator := new(big.Int).Mul(y, y)
+		numerator.Add(numerator, c)
+		denominator := new(big.Int).Lsh(y, 1)
+		denominator.Add(denominator, b)
+		denominator.Sub(denominator, d)
+		y = numerator.Div(numerator, denominator)
+
+		if new(big.Int).Sub(y, yPrev).CmpAbs(big.NewInt(1)) <= 0 {
+			return y, nil
+		}
+	}
+	return nil, ErrNoConvergence
+}
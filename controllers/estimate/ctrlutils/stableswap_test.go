@@ -0,0 +1,167 @@
+package ctrlutils
+
+import (
+	"math/big"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCalStableSwapAmount(t *testing.T) {
+	Convey("Given a balanced DAI/USDC/USDT StableSwap pool", t, func() {
+		pool := StableSwapPool{
+			A:   big.NewInt(2000),
+			Fee: big.NewInt(4000000), // 0.04%
+			Balances: []*big.Int{
+				new(big.Int).Exp(big.NewInt(10), big.NewInt(24), nil), // 1M DAI
+				big.NewInt(1000000000000),                             // 1M USDC
+				big.NewInt(1000000000000),                             // 1M USDT
+			},
+			Rates: []*big.Int{
+				new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil),
+				new(big.Int).Exp(big.NewInt(10), big.NewInt(30), nil),
+				new(big.Int).Exp(big.NewInt(10), big.NewInt(30), nil),
+			},
+		}
+
+		Convey("When computing the invariant", func() {
+			xp, err := pool.xp(0, 1)
+			So(err, ShouldBeNil)
+			d, err := stableSwapGetD(xp, pool.A)
+
+			Convey("Then D should equal the sum of balances for a balanced pool", func() {
+				So(err, ShouldBeNil)
+				So(d.String(), ShouldEqual, "3000000000000000000000000")
+			})
+		})
+
+		Convey("When swapping small amounts between coins of different decimals", func() {
+			usdcToDai, errUsdcToDai := CalStableSwapOutAmount(pool, 1, 0, big.NewInt(1000000000))
+			daiIn, _ := new(big.Int).SetString("1000000000000000000000", 10)
+			daiToUsdt, errDaiToUsdt := CalStableSwapOutAmount(pool, 0, 2, daiIn)
+
+			Convey("Then the output should be close to one-to-one minus the fee", func() {
+				So(errUsdcToDai, ShouldBeNil)
+				So(usdcToDai.String(), ShouldEqual, "999599500449525711807")
+				So(errDaiToUsdt, ShouldBeNil)
+				So(daiToUsdt.String(), ShouldEqual, "999599501")
+			})
+		})
+
+		Convey("When swapping a large share of the pool", func() {
+			result, err := CalStableSwapOutAmount(pool, 1, 0, big.NewInt(900000000000))
+
+			Convey("Then the output should show the curve's price impact", func() {
+				So(err, ShouldBeNil)
+				So(result.String(), ShouldEqual, "897562823456416379554551")
+			})
+		})
+
+		Convey("When pricing coins at the margin", func() {
+			usdcToDai, errUsdcToDai := CalStableSwapSpotPrice(pool, 1, 0)
+			daiToUsdc, errDaiToUsdc := CalStableSwapSpotPrice(pool, 0, 1)
+
+			Convey("Then a balanced pool should only convert decimals", func() {
+				So(errUsdcToDai, ShouldBeNil)
+				So(usdcToDai.RatString(), ShouldEqual, "1000000000000")
+				So(errDaiToUsdc, ShouldBeNil)
+				So(daiToUsdc.RatString(), ShouldEqual, "1/1000000000000")
+			})
+		})
+
+		Convey("When requesting an exact output amount", func() {
+			daiOut, _ := new(big.Int).SetString("1000000000000000000000", 10)
+			result, err := CalStableSwapInAmount(pool, 1, 0, daiOut)
+
+			Convey("Then the input should be enough to receive the output", func() {
+				So(err, ShouldBeNil)
+				So(result.String(), ShouldEqual, "1000400661")
+
+				out, err := CalStableSwapOutAmount(pool, 1, 0, result)
+				So(err, ShouldBeNil)
+				So(out.Cmp(daiOut), ShouldBeGreaterThanOrEqualTo, 0)
+			})
+		})
+
+		Convey("When requesting more than the pool holds", func() {
+			daiOut, _ := new(big.Int).SetString("2000000000000000000000000", 10)
+			_, err := CalStableSwapInAmount(pool, 1, 0, daiOut)
+
+			Convey("Then an insufficient liquidity error should be returned", func() {
+				So(err, ShouldEqual, ErrInsufficientLiquidity)
+			})
+		})
+
+		Convey("When testing edge cases", func() {
+			Convey("With invalid coin indexes", func() {
+				_, err := CalStableSwapOutAmount(pool, 1, 1, big.NewInt(1000))
+				So(err, ShouldEqual, ErrInvalidCoinIndex)
+
+				_, err = CalStableSwapOutAmount(pool, 0, 3, big.NewInt(1000))
+				So(err, ShouldEqual, ErrInvalidCoinIndex)
+			})
+
+			Convey("With a nil or negative amount", func() {
+				_, err := CalStableSwapOutAmount(pool, 0, 1, nil)
+				So(err, ShouldEqual, ErrInvalidAmountInput)
+
+				_, err = CalStableSwapInAmount(pool, 0, 1, big.NewInt(-1))
+				So(err, ShouldEqual, ErrInvalidAmountInput)
+			})
+
+			Convey("With a zero amount", func() {
+				result, err := CalStableSwapOutAmount(pool, 0, 1, big.NewInt(0))
+				So(err, ShouldBeNil)
+				So(result.Sign(), ShouldEqual, 0)
+			})
+
+			Convey("With an empty balance", func() {
+				emptyPool := pool
+				emptyPool.Balances = []*big.Int{big.NewInt(0), big.NewInt(0), big.NewInt(0)}
+				_, err := CalStableSwapOutAmount(emptyPool, 0, 1, big.NewInt(1000))
+				So(err, ShouldEqual, ErrInsufficientLiquidity)
+			})
+		})
+	})
+
+	Convey("Given an imbalanced two-coin StableSwap pool with a low amplification", t, func() {
+		pool := StableSwapPool{
+			A:   big.NewInt(100),
+			Fee: big.NewInt(4000000),
+			Balances: []*big.Int{
+				new(big.Int).Mul(big.NewInt(2), new(big.Int).Exp(big.NewInt(10), big.NewInt(24), nil)), // 2M DAI
+				big.NewInt(500000000000), // 500k USDC
+			},
+			Rates: []*big.Int{
+				new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil),
+				new(big.Int).Exp(big.NewInt(10), big.NewInt(30), nil),
+			},
+		}
+
+		Convey("When swapping the scarce coin into the pool", func() {
+			result, err := CalStableSwapOutAmount(pool, 1, 0, big.NewInt(100000000000))
+
+			Convey("Then the output should be priced above one-to-one", func() {
+				So(err, ShouldBeNil)
+				So(result.String(), ShouldEqual, "102316703577476632089694")
+			})
+		})
+
+		Convey("When pricing the scarce coin at the margin", func() {
+			spot, err := CalStableSwapSpotPrice(pool, 1, 0)
+
+			// A 1 USDC trade without fee should be quoted at the spot price
+			noFeePool := pool
+			noFeePool.Fee = big.NewInt(0)
+			smallOut, _ := CalStableSwapOutAmount(noFeePool, 1, 0, big.NewInt(1000000))
+
+			Convey("Then it should match the output of a tiny trade", func() {
+				So(err, ShouldBeNil)
+				spotOut, _ := new(big.Rat).Mul(spot, big.NewRat(1000000, 1)).Float64()
+				smallOutF, _ := new(big.Float).SetInt(smallOut).Float64()
+				So(spotOut, ShouldAlmostEqual, smallOutF, spotOut*1e-6)
+				So(spot.Cmp(big.NewRat(1000000000000, 1)), ShouldBeGreaterThan, 0)
+			})
+		})
+	})
+}
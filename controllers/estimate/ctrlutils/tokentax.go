@@ -0,0 +1,116 @@
+package ctrlutils
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// TokenTax is the transfer tax of a fee-on-transfer token. SellBps is taken
+// from the amount sent to a pool, BuyBps from the amount sent out of one.
+type TokenTax struct {
+	Token   common.Address
+	SellBps uint64
+	BuyBps  uint64
+}
+
+// TokenTaxList is the table of taxed tokens. It can be loaded from an
+// environment variable formatted as comma-separated "token:sellBps:buyBps"
+// entries.
+type TokenTaxList []TokenTax
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (l *TokenTaxList) EnvDecode(val string) error {
+	taxes := TokenTaxList{}
+	seenTokens := make(map[common.Address]bool)
+
+	for _, entry := range strings.Split(val, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return fmt.Errorf("invalid token tax entry %q: expected token:sellBps:buyBps", entry)
+		}
+		tokenStr, sellStr, buyStr := parts[0], parts[1], parts[2]
+
+		if !IsValidAddr(tokenStr) {
+			return fmt.Errorf("invalid token tax entry %q: invalid token address", entry)
+		}
+		token := common.HexToAddress(tokenStr)
+		if seenTokens[token] {
+			return fmt.Errorf("invalid token tax entry %q: duplicated token", entry)
+		}
+		sellBps, err := strconv.ParseUint(sellStr, 10, 64)
+		if err != nil || sellBps >= 10000 {
+			return fmt.Errorf("invalid token tax entry %q: invalid sell tax in basis points", entry)
+		}
+		buyBps, err := strconv.ParseUint(buyStr, 10, 64)
+		if err != nil || buyBps >= 10000 {
+			return fmt.Errorf("invalid token tax entry %q: invalid buy tax in basis points", entry)
+		}
+
+		seenTokens[token] = true
+		taxes = append(taxes, TokenTax{
+			Token:   token,
+			SellBps: sellBps,
+			BuyBps:  buyBps,
+		})
+	}
+
+	*l = taxes
+	return nil
+}
+
+// Find returns the transfer tax of a token, if it is taxed.
+func (l TokenTaxList) Find(token common.Address) (TokenTax, bool) {
+	for _, tax := range l {
+		if tax.Token == token {
+			return tax, true
+		}
+	}
+	return TokenTax{}, false
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// ApplyTransferTax returns the amount received from a transfer of amount,
+// with the tax rounded down like the token contracts do.
+func ApplyTransferTax(amount *big.Int, taxBps uint64) *big.Int {
+	tax := new(big.Int).Mul(amount, new(big.Int).SetUint64(taxBps))
+	tax.Quo(tax, big.NewInt(10000))
+	return tax.Sub(amount, tax)
+}
+
+// GrossUpTransferTax returns the smallest amount whose transfer delivers at
+// least received.
+func GrossUpTransferTax(received *big.Int, taxBps uint64) *big.Int {
+	if taxBps == 0 {
+		return new(big.Int).Set(received)
+	}
+
+	// ceil(received * 10000 / (10000 - taxBps)) is enough; the tax being
+	// rounded down, one unit less may be too
+	numerator := new(big.Int).Mul(received, big.NewInt(10000))
+	denominator := new(big.Int).SetUint64(10000 - taxBps)
+	amount, rem := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	if rem.Sign() > 0 {
+		amount.Add(amount, big.NewInt(1))
+	}
+	for amount.Sign() > 0 {
+		lower := new(big.Int).Sub(amount, big.NewInt(1))
+		if ApplyTransferTax(lower, taxBps).Cmp(received) < 0 {
+			break
+		}
+		amount = lower
+	}
+	return amount
+}
@@ -0,0 +1,87 @@
+package ctrlutils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testTaxedTokenEntry = "0x1000000000000000000000000000000000000002:500:300"
+
+func TestTokenTaxListEnvDecode(t *testing.T) {
+	Convey("Given the token tax table decoder", t, func() {
+		Convey("When decoding a valid table", func() {
+			taxes := TokenTaxList{}
+			err := taxes.EnvDecode(testTaxedTokenEntry + ",0x1000000000000000000000000000000000000003:100:0")
+
+			Convey("Then every taxed token should be found", func() {
+				So(err, ShouldBeNil)
+				So(taxes, ShouldHaveLength, 2)
+
+				tax, ok := taxes.Find(common.HexToAddress("0x1000000000000000000000000000000000000002"))
+				So(ok, ShouldBeTrue)
+				So(tax.SellBps, ShouldEqual, 500)
+				So(tax.BuyBps, ShouldEqual, 300)
+
+				_, ok = taxes.Find(common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"))
+				So(ok, ShouldBeFalse)
+			})
+		})
+
+		Convey("When decoding an empty table", func() {
+			taxes := TokenTaxList{}
+			err := taxes.EnvDecode("")
+
+			Convey("Then no token should be taxed", func() {
+				So(err, ShouldBeNil)
+				So(taxes, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When decoding malformed entries", func() {
+			taxes := TokenTaxList{}
+
+			So(taxes.EnvDecode("0x1000000000000000000000000000000000000002:500"), ShouldNotBeNil)
+			So(taxes.EnvDecode("0xZZ00000000000000000000000000000000000002:500:300"), ShouldNotBeNil)
+			So(taxes.EnvDecode("0x1000000000000000000000000000000000000002:10000:300"), ShouldNotBeNil)
+			So(taxes.EnvDecode("0x1000000000000000000000000000000000000002:500:abc"), ShouldNotBeNil)
+			So(taxes.EnvDecode(testTaxedTokenEntry+","+testTaxedTokenEntry), ShouldNotBeNil)
+
+			Convey("Then the table should be left untouched", func() {
+				So(taxes, ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestTransferTax(t *testing.T) {
+	Convey("Given a 5% transfer tax", t, func() {
+		Convey("When applying it to a transfer", func() {
+			received := ApplyTransferTax(big.NewInt(1000001), 500)
+
+			Convey("Then the tax should be rounded down", func() {
+				So(received.String(), ShouldEqual, "950001")
+			})
+		})
+
+		Convey("When grossing up an amount to receive", func() {
+			for _, want := range []int64{1, 19, 20, 950000, 950001, 999999} {
+				amount := GrossUpTransferTax(big.NewInt(want), 500)
+
+				// The smallest amount delivering at least want
+				So(ApplyTransferTax(amount, 500).Int64(), ShouldBeGreaterThanOrEqualTo, want)
+				So(ApplyTransferTax(new(big.Int).Sub(amount, big.NewInt(1)), 500).Int64(), ShouldBeLessThan, want)
+			}
+		})
+
+		Convey("When grossing up without tax", func() {
+			amount := GrossUpTransferTax(big.NewInt(1234), 0)
+
+			Convey("Then the amount should be unchanged", func() {
+				So(amount.String(), ShouldEqual, "1234")
+			})
+		})
+	})
+}
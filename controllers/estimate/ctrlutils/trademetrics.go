@@ -0,0 +1,86 @@
+package ctrlutils
+
+import (
+	"math/big"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// TradeMetrics compares a quote with the price of the pool before the trade.
+// Prices are in destination token units per source token unit.
+type TradeMetrics struct {
+	SpotPrice      *big.Rat
+	ExecutionPrice *big.Rat
+	// PriceImpactBps is how far the execution price falls short of the spot
+	// price once the LP fee is deducted, so the fee is not counted twice.
+	PriceImpactBps int64
+	// LpFee is the part of the source amount kept by liquidity providers.
+	LpFee *big.Int
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// CalTradeMetrics derives the execution price, price impact and LP fee of a
+// swap of amountIn for amountOut, given the spot price (fees excluded) and
+// the fee rate of the pool.
+func CalTradeMetrics(spotPrice, feeRate *big.Rat, amountIn, amountOut *big.Int) (*TradeMetrics, error) {
+	if spotPrice == nil || spotPrice.Sign() <= 0 || feeRate == nil || feeRate.Sign() < 0 {
+		return nil, ErrInvalidAmountInput
+	}
+	if amountIn == nil || amountIn.Sign() <= 0 || amountOut == nil || amountOut.Sign() < 0 {
+		return nil, ErrInvalidAmountInput
+	}
+
+	feeComplement := new(big.Rat).Sub(big.NewRat(1, 1), feeRate)
+	if feeComplement.Sign() <= 0 {
+		return nil, ErrInvalidAmountInput
+	}
+	netSpotPrice := new(big.Rat).Mul(spotPrice, feeComplement)
+	executionPrice := new(big.Rat).SetFrac(amountOut, amountIn)
+
+	// impact = (1 - execution / net spot) * 10000, truncated
+	impact := new(big.Rat).Quo(executionPrice, netSpotPrice)
+	impact.Sub(big.NewRat(1, 1), impact)
+	impact.Mul(impact, big.NewRat(feeBpsDenominator, 1))
+	impactBps := new(big.Int).Quo(impact.Num(), impact.Denom())
+
+	lpFee := new(big.Rat).Mul(new(big.Rat).SetInt(amountIn), feeRate)
+
+	return &TradeMetrics{
+		SpotPrice:      spotPrice,
+		ExecutionPrice: executionPrice,
+		PriceImpactBps: impactBps.Int64(),
+		LpFee:          new(big.Int).Quo(lpFee.Num(), lpFee.Denom()),
+	}, nil
+}
+
+// CalMinOutAmount returns the least output accepted for amountOut under a
+// slippage tolerance in basis points, rounded down.
+func CalMinOutAmount(amountOut *big.Int, slippageBps uint64) (*big.Int, error) {
+	if amountOut == nil || amountOut.Sign() < 0 {
+		return nil, ErrInvalidAmountInput
+	}
+	if slippageBps > feeBpsDenominator {
+		return nil, ErrInvalidAmountInput
+	}
+
+	minOut := new(big.Int).Mul(amountOut, new(big.Int).SetUint64(feeBpsDenominator-slippageBps))
+	return minOut.Div(minOut, big.NewInt(feeBpsDenominator)), nil
+}
+
+// CalMaxInAmount returns the most input accepted for amountIn under a slippage
+// tolerance in basis points, rounded up. It bounds the input of an
+// exact-output swap, as CalMinOutAmount bounds the output of an exact-input
+// one.
+func CalMaxInAmount(amountIn *big.Int, slippageBps uint64) (*big.Int, error) {
+	if amountIn == nil || amountIn.Sign() < 0 {
+		return nil, ErrInvalidAmountInput
+	}
+	if slippageBps > feeBpsDenominator {
+		return nil, ErrInvalidAmountInput
+	}
+
+	maxIn := new(big.Int).Mul(amountIn, new(big.Int).SetUint64(feeBpsDenominator+slippageBps))
+	maxIn.Add(maxIn, big.NewInt(feeBpsDenominator-1))
+	return maxIn.Div(maxIn, big.NewInt(feeBpsDenominator)), nil
+}
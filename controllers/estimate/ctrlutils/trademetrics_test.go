@@ -0,0 +1,95 @@
+package ctrlutils
+
+import (
+	"math/big"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCalTradeMetrics(t *testing.T) {
+	Convey("Given a 1 ETH quote against a 100 ETH / 200k USDC pair", t, func() {
+		amountIn, _ := new(big.Int).SetString("1000000000000000000", 10)
+		amountOut := big.NewInt(1974316068)
+		spotPrice := new(big.Rat).SetFrac(big.NewInt(200000000000), new(big.Int).Mul(big.NewInt(100), amountIn))
+		feeRate := big.NewRat(30, 10000)
+
+		Convey("When deriving the trade metrics", func() {
+			metrics, err := CalTradeMetrics(spotPrice, feeRate, amountIn, amountOut)
+
+			Convey("Then the impact should exclude the LP fee", func() {
+				So(err, ShouldBeNil)
+				So(metrics.SpotPrice.RatString(), ShouldEqual, "1/500000000")
+				So(metrics.ExecutionPrice.RatString(), ShouldEqual, "493579017/250000000000000000")
+				// 1 - 0.997 / 100.997 of the net spot price is lost to the curve
+				So(metrics.PriceImpactBps, ShouldEqual, 98)
+				So(metrics.LpFee.String(), ShouldEqual, "3000000000000000")
+			})
+		})
+
+		Convey("When the pool price is unknown", func() {
+			_, errSpot := CalTradeMetrics(new(big.Rat), feeRate, amountIn, amountOut)
+			_, errAmount := CalTradeMetrics(spotPrice, feeRate, big.NewInt(0), amountOut)
+
+			Convey("Then the metrics should be rejected", func() {
+				So(errSpot, ShouldEqual, ErrInvalidAmountInput)
+				So(errAmount, ShouldEqual, ErrInvalidAmountInput)
+			})
+		})
+	})
+}
+
+func TestCalMinOutAmount(t *testing.T) {
+	Convey("Given an output amount", t, func() {
+		amountOut := big.NewInt(1974316068)
+
+		Convey("When applying a 0.5% slippage tolerance", func() {
+			minOut, err := CalMinOutAmount(amountOut, 50)
+
+			Convey("Then the minimum should be rounded down", func() {
+				So(err, ShouldBeNil)
+				So(minOut.String(), ShouldEqual, "1964444487")
+			})
+		})
+
+		Convey("When the tolerance is above 100%", func() {
+			_, err := CalMinOutAmount(amountOut, 10001)
+
+			Convey("Then it should be rejected", func() {
+				So(err, ShouldEqual, ErrInvalidAmountInput)
+			})
+		})
+	})
+}
+
+func TestCalMaxInAmount(t *testing.T) {
+	Convey("Given an input amount", t, func() {
+		amountIn := big.NewInt(1974316068)
+
+		Convey("When applying a 0.5% slippage tolerance", func() {
+			maxIn, err := CalMaxInAmount(amountIn, 50)
+
+			Convey("Then the maximum should be rounded up", func() {
+				So(err, ShouldBeNil)
+				So(maxIn.String(), ShouldEqual, "1984187649")
+			})
+		})
+
+		Convey("When the tolerance is zero", func() {
+			maxIn, err := CalMaxInAmount(amountIn, 0)
+
+			Convey("Then the maximum should be the input itself", func() {
+				So(err, ShouldBeNil)
+				So(maxIn.String(), ShouldEqual, "1974316068")
+			})
+		})
+
+		Convey("When the tolerance is above 100%", func() {
+			_, err := CalMaxInAmount(amountIn, 10001)
+
+			Convey("Then it should be rejected", func() {
+				So(err, ShouldEqual, ErrInvalidAmountInput)
+			})
+		})
+	})
+}
@@ -0,0 +1,489 @@
+package ctrlutils
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	UniV3MinTick = -887272
+	UniV3MaxTick = 887272
+
+	uniV3FeePipsDenominator = 1000000
+)
+
+var (
+	ErrInvalidTick          = errors.New("tick out of range")
+	ErrInsufficientTickData = errors.New("swap crosses ticks beyond the loaded tick bitmap")
+)
+
+var (
+	uniV3Q96          = new(big.Int).Lsh(big.NewInt(1), 96)
+	uniV3Q128         = new(big.Int).Lsh(big.NewInt(1), 128)
+	uniV3MaxUint256   = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	uniV3MinSqrtRatio = big.NewInt(4295128739)
+	uniV3MaxSqrtRatio = mustBigIntFromStr("1461446703485210103287273052203988822378723970342")
+
+	// sqrt(1.0001)^(-2^i) in Q128.128, as used by TickMath.getSqrtRatioAtTick
+	uniV3TickRatios = []*big.Int{
+		mustBigIntFromHex("fffcb933bd6fad37aa2d162d1a594001"),
+		mustBigIntFromHex("fff97272373d413259a46990580e213a"),
+		mustBigIntFromHex("fff2e50f5f656932ef12357cf3c7fdcc"),
+		mustBigIntFromHex("ffe5caca7e10e4e61c3624eaa0941cd0"),
+		mustBigIntFromHex("ffcb9843d60f6159c9db58835c926644"),
+		mustBigIntFromHex("ff973b41fa98c081472e6896dfb254c0"),
+		mustBigIntFromHex("ff2ea16466c96a3843ec78b326b52861"),
+		mustBigIntFromHex("fe5dee046a99a2a811c461f1969c3053"),
+		mustBigIntFromHex("fcbe86c7900a88aedcffc83b479aa3a4"),
+		mustBigIntFromHex("f987a7253ac413176f2b074cf7815e54"),
+		mustBigIntFromHex("f3392b0822b70005940c7a398e4b70f3"),
+		mustBigIntFromHex("e7159475a2c29b7443b29c7fa6e889d9"),
+		mustBigIntFromHex("d097f3bdfd2022b8845ad8f792aa5825"),
+		mustBigIntFromHex("a9f746462d870fdf8a65dc1f90e061e5"),
+		mustBigIntFromHex("70d869a156d2a1b890bb3df62baf32f7"),
+		mustBigIntFromHex("31be135f97d08fd981231505542fcfa6"),
+		mustBigIntFromHex("9aa508b5b7a84e1c677de54f3e99bc9"),
+		mustBigIntFromHex("5d6af8dedb81196699c329225ee604"),
+		mustBigIntFromHex("2216e584f5fa1ea926041bedfe98"),
+		mustBigIntFromHex("48a170391f7dc42444e8fa2"),
+	}
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+type UniV3Tick struct {
+	Index        int
+	LiquidityNet *big.Int
+}
+
+// UniV3Pool is the subset of a Uniswap V3 pool state needed to simulate a
+// swap. Ticks must hold every initialized tick inside the tick bitmap words
+// [MinWordPos, MaxWordPos]; a swap that needs a word outside of that range
+// fails with ErrInsufficientTickData.
+type UniV3Pool struct {
+	FeePips      uint64
+	TickSpacing  int
+	SqrtPriceX96 *big.Int
+	Tick         int
+	Liquidity    *big.Int
+	Ticks        []UniV3Tick
+	MinWordPos   int
+	MaxWordPos   int
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// CalUniV3OutAmount simulates an exact-input swap against a Uniswap V3 pool
+// tick by tick, mirroring UniswapV3Pool.swap and SwapMath.computeSwapStep.
+func CalUniV3OutAmount(pool UniV3Pool, zeroForOne bool, amountIn *big.Int) (*big.Int, error) {
+	if amountIn == nil || amountIn.Sign() < 0 {
+		return nil, ErrInvalidAmountInput
+	}
+	_, amountOut, err := simulateUniV3Swap(pool, zeroForOne, amountIn)
+	return amountOut, err
+}
+
+// CalUniV3InAmount simulates an exact-output swap against a Uniswap V3 pool
+// and returns the input amount required, fee included.
+func CalUniV3InAmount(pool UniV3Pool, zeroForOne bool, amountOut *big.Int) (*big.Int, error) {
+	if amountOut == nil || amountOut.Sign() < 0 {
+		return nil, ErrInvalidAmountInput
+	}
+	amountIn, _, err := simulateUniV3Swap(pool, zeroForOne, new(big.Int).Neg(amountOut))
+	return amountIn, err
+}
+
+// CalUniV3SpotPrice returns the price of a Uniswap V3 pool at its current
+// sqrtPriceX96, in output token units per input token unit, fees excluded.
+func CalUniV3SpotPrice(pool UniV3Pool, zeroForOne bool) (*big.Rat, error) {
+	if pool.SqrtPriceX96 == nil || pool.SqrtPriceX96.Sign() <= 0 {
+		return nil, ErrInvalidAmountInput
+	}
+
+	// price of token0 in token1 = sqrtPriceX96^2 / 2^192
+	priceX192 := new(big.Int).Mul(pool.SqrtPriceX96, pool.SqrtPriceX96)
+	q192 := new(big.Int).Lsh(big.NewInt(1), 192)
+	if zeroForOne {
+		return new(big.Rat).SetFrac(priceX192, q192), nil
+	}
+	return new(big.Rat).SetFrac(q192, priceX192), nil
+}
+
+// GetSqrtRatioAtTick returns sqrt(1.0001^tick) as a Q64.96, rounded up like
+// TickMath.getSqrtRatioAtTick.
+func GetSqrtRatioAtTick(tick int) (*big.Int, error) {
+	if tick < UniV3MinTick || tick > UniV3MaxTick {
+		return nil, ErrInvalidTick
+	}
+
+	absTick := tick
+	if absTick < 0 {
+		absTick = -absTick
+	}
+
+	ratio := new(big.Int).Set(uniV3Q128)
+	for i, tickRatio := range uniV3TickRatios {
+		if absTick&(1<<i) != 0 {
+			ratio.Mul(ratio, tickRatio)
+			ratio.Rsh(ratio, 128)
+		}
+	}
+	if tick > 0 {
+		ratio.Div(uniV3MaxUint256, ratio)
+	}
+
+	// Round up to the nearest Q64.96
+	sqrtPriceX96 := new(big.Int).Rsh(ratio, 32)
+	if new(big.Int).And(ratio, big.NewInt(0xffffffff)).Sign() != 0 {
+		sqrtPriceX96.Add(sqrtPriceX96, big.NewInt(1))
+	}
+	return sqrtPriceX96, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// simulateUniV3Swap runs the swap loop of UniswapV3Pool.swap. A positive
+// amountSpecified is an exact input, a negative one an exact output.
+func simulateUniV3Swap(pool UniV3Pool, zeroForOne bool, amountSpecified *big.Int) (amountIn, amountOut *big.Int, err error) {
+	if pool.SqrtPriceX96 == nil || pool.Liquidity == nil || pool.TickSpacing <= 0 {
+		return nil, nil, ErrInvalidAmountInput
+	}
+	if pool.FeePips >= uniV3FeePipsDenominator {
+		return nil, nil, ErrInvalidAmountInput
+	}
+
+	exactInput := amountSpecified.Sign() > 0
+	sqrtPriceLimitX96 := new(big.Int).Sub(uniV3MaxSqrtRatio, big.NewInt(1))
+	if zeroForOne {
+		sqrtPriceLimitX96 = new(big.Int).Add(uniV3MinSqrtRatio, big.NewInt(1))
+	}
+
+	initialized := make(map[int]*big.Int, len(pool.Ticks))
+	compressedTicks := make([]int, 0, len(pool.Ticks))
+	for _, tick := range pool.Ticks {
+		initialized[tick.Index] = tick.LiquidityNet
+		compressedTicks = append(compressedTicks, floorDiv(tick.Index, pool.TickSpacing))
+	}
+	sort.Ints(compressedTicks)
+
+	amountRemaining := new(big.Int).Set(amountSpecified)
+	amountCalculated := new(big.Int)
+	sqrtPriceX96 := new(big.Int).Set(pool.SqrtPriceX96)
+	liquidity := new(big.Int).Set(pool.Liquidity)
+	tick := pool.Tick
+
+	for amountRemaining.Sign() != 0 && sqrtPriceX96.Cmp(sqrtPriceLimitX96) != 0 {
+		sqrtPriceStartX96 := new(big.Int).Set(sqrtPriceX96)
+
+		tickNext, isInitialized, err := nextInitializedTickWithinOneWord(
+			compressedTicks, tick, pool.TickSpacing, zeroForOne, pool.MinWordPos, pool.MaxWordPos,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		if tickNext < UniV3MinTick {
+			tickNext = UniV3MinTick
+		} else if tickNext > UniV3MaxTick {
+			tickNext = UniV3MaxTick
+		}
+
+		sqrtPriceNextX96, err := GetSqrtRatioAtTick(tickNext)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		sqrtPriceTargetX96 := sqrtPriceNextX96
+		if (zeroForOne && sqrtPriceNextX96.Cmp(sqrtPriceLimitX96) < 0) ||
+			(!zeroForOne && sqrtPriceNextX96.Cmp(sqrtPriceLimitX96) > 0) {
+			sqrtPriceTargetX96 = sqrtPriceLimitX96
+		}
+
+		var stepAmountIn, stepAmountOut, feeAmount *big.Int
+		sqrtPriceX96, stepAmountIn, stepAmountOut, feeAmount = computeSwapStep(
+			sqrtPriceX96, sqrtPriceTargetX96, liquidity, amountRemaining, pool.FeePips,
+		)
+
+		if exactInput {
+			amountRemaining.Sub(amountRemaining, new(big.Int).Add(stepAmountIn, feeAmount))
+			amountCalculated.Sub(amountCalculated, stepAmountOut)
+		} else {
+			amountRemaining.Add(amountRemaining, stepAmountOut)
+			amountCalculated.Add(amountCalculated, new(big.Int).Add(stepAmountIn, feeAmount))
+		}
+
+		if sqrtPriceX96.Cmp(sqrtPriceNextX96) == 0 {
+			// Cross the tick and apply its liquidity change
+			if isInitialized {
+				liquidityNet := new(big.Int).Set(initialized[tickNext])
+				if zeroForOne {
+					liquidityNet.Neg(liquidityNet)
+				}
+				liquidity.Add(liquidity, liquidityNet)
+				if liquidity.Sign() < 0 {
+					return nil, nil, ErrInsufficientLiquidity
+				}
+			}
+			if zeroForOne {
+				tick = tickNext - 1
+			} else {
+				tick = tickNext
+			}
+		} else if sqrtPriceX96.Cmp(sqrtPriceStartX96) != 0 {
+			// The step ended inside the tick range; the swap is complete
+			break
+		}
+	}
+
+	if amountRemaining.Sign() != 0 {
+		// The price limit was reached before the amount was filled
+		return nil, nil, ErrInsufficientLiquidity
+	}
+
+	if exactInput {
+		return new(big.Int).Set(amountSpecified), amountCalculated.Neg(amountCalculated), nil
+	}
+	return amountCalculated, new(big.Int).Neg(amountSpecified), nil
+}
+
+// nextInitializedTickWithinOneWord mirrors TickBitmap.nextInitializedTickWithinOneWord
+// over the sorted compressed initialized ticks loaded from the bitmap.
+func nextInitializedTickWithinOneWord(
+	compressedTicks []int,
+	tick int,
+	tickSpacing int,
+	lte bool,
+	minWordPos int,
+	maxWordPos int,
+) (int, bool, error) {
+	compressed := floorDiv(tick, tickSpacing)
+
+	if lte {
+		wordPos := floorDiv(compressed, 256)
+		if wordPos < minWordPos || wordPos > maxWordPos {
+			return 0, false, ErrInsufficientTickData
+		}
+		wordStart := wordPos * 256
+
+		// Largest initialized compressed tick in [wordStart, compressed]
+		i := sort.SearchInts(compressedTicks, compressed+1) - 1
+		if i >= 0 && compressedTicks[i] >= wordStart {
+			return compressedTicks[i] * tickSpacing, true, nil
+		}
+		return wordStart * tickSpacing, false, nil
+	}
+
+	next := compressed + 1
+	wordPos := floorDiv(next, 256)
+	if wordPos < minWordPos || wordPos > maxWordPos {
+		return 0, false, ErrInsufficientTickData
+	}
+	wordEnd := wordPos*256 + 255
+
+	// Smallest initialized compressed tick in [next, wordEnd]
+	i := sort.SearchInts(compressedTicks, next)
+	if i < len(compressedTicks) && compressedTicks[i] <= wordEnd {
+		return compressedTicks[i] * tickSpacing, true, nil
+	}
+	return wordEnd * tickSpacing, false, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// SwapMath
+
+func computeSwapStep(
+	sqrtRatioCurrentX96, sqrtRatioTargetX96, liquidity, amountRemaining *big.Int,
+	feePips uint64,
+) (sqrtRatioNextX96, amountIn, amountOut, feeAmount *big.Int) {
+	zeroForOne := sqrtRatioCurrentX96.Cmp(sqrtRatioTargetX96) >= 0
+	exactIn := amountRemaining.Sign() >= 0
+	fee := new(big.Int).SetUint64(feePips)
+	feeComplement := new(big.Int).SetUint64(uniV3FeePipsDenominator - feePips)
+	feeDenominator := big.NewInt(uniV3FeePipsDenominator)
+
+	if exactIn {
+		amountRemainingLessFee := mulDiv(amountRemaining, feeComplement, feeDenominator)
+		if zeroForOne {
+			amountIn = getAmount0Delta(sqrtRatioTargetX96, sqrtRatioCurrentX96, liquidity, true)
+		} else {
+			amountIn = getAmount1Delta(sqrtRatioCurrentX96, sqrtRatioTargetX96, liquidity, true)
+		}
+		if amountRemainingLessFee.Cmp(amountIn) >= 0 {
+			sqrtRatioNextX96 = sqrtRatioTargetX96
+		} else {
+			sqrtRatioNextX96 = getNextSqrtPriceFromInput(sqrtRatioCurrentX96, liquidity, amountRemainingLessFee, zeroForOne)
+		}
+	} else {
+		amountRemainingAbs := new(big.Int).Neg(amountRemaining)
+		if zeroForOne {
+			amountOut = getAmount1Delta(sqrtRatioTargetX96, sqrtRatioCurrentX96, liquidity, false)
+		} else {
+			amountOut = getAmount0Delta(sqrtRatioCurrentX96, sqrtRatioTargetX96, liquidity, false)
+		}
+		if amountRemainingAbs.Cmp(amountOut) >= 0 {
+			sqrtRatioNextX96 = sqrtRatioTargetX96
+		} else {
+			sqrtRatioNextX96 = getNextSqrtPriceFromOutput(sqrtRatioCurrentX96, liquidity, amountRemainingAbs, zeroForOne)
+		}
+	}
+
+	reachedTarget := sqrtRatioTargetX96.Cmp(sqrtRatioNextX96) == 0
+
+	if zeroForOne {
+		if !(reachedTarget && exactIn) {
+			amountIn = getAmount0Delta(sqrtRatioNextX96, sqrtRatioCurrentX96, liquidity, true)
+		}
+		if !(reachedTarget && !exactIn) {
+			amountOut = getAmount1Delta(sqrtRatioNextX96, sqrtRatioCurrentX96, liquidity, false)
+		}
+	} else {
+		if !(reachedTarget && exactIn) {
+			amountIn = getAmount1Delta(sqrtRatioCurrentX96, sqrtRatioNextX96, liquidity, true)
+		}
+		if !(reachedTarget && !exactIn) {
+			amountOut = getAmount0Delta(sqrtRatioCurrentX96, sqrtRatioNextX96, liquidity, false)
+		}
+	}
+
+	// Cap the output amount to not exceed the remaining output amount
+	if !exactIn && amountOut.Cmp(new(big.Int).Neg(amountRemaining)) > 0 {
+		amountOut = new(big.Int).Neg(amountRemaining)
+	}
+
+	if exactIn && sqrtRatioNextX96.Cmp(sqrtRatioTargetX96) != 0 {
+		// Take the remainder of the maximum input as fee
+		feeAmount = new(big.Int).Sub(amountRemaining, amountIn)
+	} else {
+		feeAmount = mulDivRoundingUp(amountIn, fee, feeComplement)
+	}
+
+	return sqrtRatioNextX96, amountIn, amountOut, feeAmount
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// SqrtPriceMath
+
+func getAmount0Delta(sqrtRatioAX96, sqrtRatioBX96, liquidity *big.Int, roundUp bool) *big.Int {
+	if sqrtRatioAX96.Cmp(sqrtRatioBX96) > 0 {
+		sqrtRatioAX96, sqrtRatioBX96 = sqrtRatioBX96, sqrtRatioAX96
+	}
+
+	numerator1 := new(big.Int).Lsh(liquidity, 96)
+	numerator2 := new(big.Int).Sub(sqrtRatioBX96, sqrtRatioAX96)
+
+	if roundUp {
+		return divRoundingUp(mulDivRoundingUp(numerator1, numerator2, sqrtRatioBX96), sqrtRatioAX96)
+	}
+	return new(big.Int).Div(mulDiv(numerator1, numerator2, sqrtRatioBX96), sqrtRatioAX96)
+}
+
+func getAmount1Delta(sqrtRatioAX96, sqrtRatioBX96, liquidity *big.Int, roundUp bool) *big.Int {
+	if sqrtRatioAX96.Cmp(sqrtRatioBX96) > 0 {
+		sqrtRatioAX96, sqrtRatioBX96 = sqrtRatioBX96, sqrtRatioAX96
+	}
+
+	diff := new(big.Int).Sub(sqrtRatioBX96, sqrtRatioAX96)
+	if roundUp {
+		return mulDivRoundingUp(liquidity, diff, uniV3Q96)
+	}
+	return mulDiv(liquidity, diff, uniV3Q96)
+}
+
+func getNextSqrtPriceFromInput(sqrtPX96, liquidity, amountIn *big.Int, zeroForOne bool) *big.Int {
+	if zeroForOne {
+		return getNextSqrtPriceFromAmount0RoundingUp(sqrtPX96, liquidity, amountIn, true)
+	}
+	return getNextSqrtPriceFromAmount1RoundingDown(sqrtPX96, liquidity, amountIn, true)
+}
+
+func getNextSqrtPriceFromOutput(sqrtPX96, liquidity, amountOut *big.Int, zeroForOne bool) *big.Int {
+	if zeroForOne {
+		return getNextSqrtPriceFromAmount1RoundingDown(sqrtPX96, liquidity, amountOut, false)
+	}
+	return getNextSqrtPriceFromAmount0RoundingUp(sqrtPX96, liquidity, amountOut, false)
+}
+
+func getNextSqrtPriceFromAmount0RoundingUp(sqrtPX96, liquidity, amount *big.Int, add bool) *big.Int {
+	if amount.Sign() == 0 {
+		return new(big.Int).Set(sqrtPX96)
+	}
+	numerator1 := new(big.Int).Lsh(liquidity, 96)
+	product := new(big.Int).Mul(amount, sqrtPX96)
+
+	if add {
+		// The Solidity version falls back to a less precise formula when the
+		// intermediate values overflow 256 bits; keep the same branches
+		if product.Cmp(uniV3MaxUint256) <= 0 {
+			denominator := new(big.Int).Add(numerator1, product)
+			if denominator.Cmp(uniV3MaxUint256) <= 0 {
+				return mulDivRoundingUp(numerator1, sqrtPX96, denominator)
+			}
+		}
+		return divRoundingUp(numerator1, new(big.Int).Add(new(big.Int).Div(numerator1, sqrtPX96), amount))
+	}
+
+	denominator := new(big.Int).Sub(numerator1, product)
+	return mulDivRoundingUp(numerator1, sqrtPX96, denominator)
+}
+
+func getNextSqrtPriceFromAmount1RoundingDown(sqrtPX96, liquidity, amount *big.Int, add bool) *big.Int {
+	if add {
+		quotient := mulDiv(amount, uniV3Q96, liquidity)
+		return quotient.Add(quotient, sqrtPX96)
+	}
+	quotient := mulDivRoundingUp(amount, uniV3Q96, liquidity)
+	return quotient.Sub(sqrtPX96, quotient)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// FullMath
+
+func mulDiv(a, b, denominator *big.Int) *big.Int {
+	product := new(big.Int).Mul(a, b)
+	return product.Div(product, denominator)
+}
+
+func mulDivRoundingUp(a, b, denominator *big.Int) *big.Int {
+	product := new(big.Int).Mul(a, b)
+	quotient, remainder := new(big.Int).QuoRem(product, denominator, new(big.Int))
+	if remainder.Sign() > 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	return quotient
+}
+
+func divRoundingUp(a, b *big.Int) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(a, b, new(big.Int))
+	if remainder.Sign() > 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	return quotient
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+func mustBigIntFromHex(s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("invalid hex constant: " + s)
+	}
+	return v
+}
+
+func mustBigIntFromStr(s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("invalid decimal constant: " + s)
+	}
+	return v
+}
@@ -0,0 +1,153 @@
+package ctrlutils
+
+import (
+	"math/big"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetSqrtRatioAtTick(t *testing.T) {
+	Convey("Given the Uniswap V3 tick to sqrt price conversion", t, func() {
+		Convey("When converting well-known ticks", func() {
+			Convey("Then tick 0 should map to 2^96", func() {
+				result, err := GetSqrtRatioAtTick(0)
+				So(err, ShouldBeNil)
+				So(result.String(), ShouldEqual, "79228162514264337593543950336")
+			})
+
+			Convey("Then ticks 1 and -1 should map to sqrt(1.0001)^±1", func() {
+				result, err := GetSqrtRatioAtTick(1)
+				So(err, ShouldBeNil)
+				So(result.String(), ShouldEqual, "79232123823359799118286999568")
+
+				result, err = GetSqrtRatioAtTick(-1)
+				So(err, ShouldBeNil)
+				So(result.String(), ShouldEqual, "79224201403219477170569942574")
+			})
+
+			Convey("Then the tick bounds should map to the TickMath sqrt ratio bounds", func() {
+				result, err := GetSqrtRatioAtTick(UniV3MinTick)
+				So(err, ShouldBeNil)
+				So(result.String(), ShouldEqual, "4295128739")
+
+				result, err = GetSqrtRatioAtTick(UniV3MaxTick)
+				So(err, ShouldBeNil)
+				So(result.String(), ShouldEqual, "1461446703485210103287273052203988822378723970342")
+			})
+		})
+
+		Convey("When converting ticks out of range", func() {
+			_, errLow := GetSqrtRatioAtTick(UniV3MinTick - 1)
+			_, errHigh := GetSqrtRatioAtTick(UniV3MaxTick + 1)
+
+			Convey("Then an error should be returned", func() {
+				So(errLow, ShouldEqual, ErrInvalidTick)
+				So(errHigh, ShouldEqual, ErrInvalidTick)
+			})
+		})
+	})
+}
+
+func TestCalUniV3Amount(t *testing.T) {
+	Convey("Given a Uniswap V3 pool with two nested positions around tick 0", t, func() {
+		liquidity := new(big.Int)
+		liquidity.SetString("3000000000000000000", 10)
+		pool := UniV3Pool{
+			FeePips:      3000,
+			TickSpacing:  60,
+			SqrtPriceX96: new(big.Int).Lsh(big.NewInt(1), 96),
+			Tick:         0,
+			Liquidity:    liquidity,
+			Ticks: []UniV3Tick{
+				{Index: -1200, LiquidityNet: big.NewInt(2000000000000000000)},
+				{Index: -600, LiquidityNet: big.NewInt(1000000000000000000)},
+				{Index: 600, LiquidityNet: big.NewInt(-1000000000000000000)},
+				{Index: 1200, LiquidityNet: big.NewInt(-2000000000000000000)},
+			},
+			MinWordPos: -1,
+			MaxWordPos: 0,
+		}
+
+		Convey("When swapping a small amount within the current tick range", func() {
+			result, err := CalUniV3OutAmount(pool, true, big.NewInt(1000000000000000))
+
+			Convey("Then the output should follow the single range formula", func() {
+				So(err, ShouldBeNil)
+				So(result.String(), ShouldEqual, "996668773744192")
+			})
+		})
+
+		Convey("When pricing the pool at its current tick", func() {
+			zeroForOne, errZeroForOne := CalUniV3SpotPrice(pool, true)
+			pool.SqrtPriceX96 = new(big.Int).Lsh(big.NewInt(2), 96)
+			oneForZero, errOneForZero := CalUniV3SpotPrice(pool, false)
+
+			Convey("Then the price should be the square of sqrtPriceX96", func() {
+				So(errZeroForOne, ShouldBeNil)
+				So(zeroForOne.RatString(), ShouldEqual, "1")
+				So(errOneForZero, ShouldBeNil)
+				So(oneForZero.RatString(), ShouldEqual, "1/4")
+			})
+		})
+
+		Convey("When swapping enough to cross an initialized tick", func() {
+			amountIn, _ := new(big.Int).SetString("120000000000000000", 10)
+			zeroForOne, errZeroForOne := CalUniV3OutAmount(pool, true, amountIn)
+			oneForZero, errOneForZero := CalUniV3OutAmount(pool, false, amountIn)
+
+			Convey("Then the output should account for the liquidity change", func() {
+				So(errZeroForOne, ShouldBeNil)
+				So(zeroForOne.String(), ShouldEqual, "114932645280324887")
+				So(errOneForZero, ShouldBeNil)
+				So(oneForZero.String(), ShouldEqual, "114932645280324887")
+			})
+		})
+
+		Convey("When requesting an exact output amount", func() {
+			amountOut, _ := new(big.Int).SetString("100000000000000000", 10)
+			result, err := CalUniV3InAmount(pool, true, amountOut)
+
+			Convey("Then the input should include the fee and swap to at least the output", func() {
+				So(err, ShouldBeNil)
+				So(result.String(), ShouldEqual, "103783310809923073")
+
+				out, err := CalUniV3OutAmount(pool, true, result)
+				So(err, ShouldBeNil)
+				So(out.Cmp(amountOut), ShouldBeGreaterThanOrEqualTo, 0)
+			})
+		})
+
+		Convey("When the swap needs ticks beyond the loaded bitmap words", func() {
+			amountIn, _ := new(big.Int).SetString("300000000000000000", 10)
+			_, err := CalUniV3OutAmount(pool, true, amountIn)
+
+			Convey("Then an insufficient tick data error should be returned", func() {
+				So(err, ShouldEqual, ErrInsufficientTickData)
+			})
+		})
+
+		Convey("When testing edge cases", func() {
+			Convey("With a nil or negative amount", func() {
+				_, err := CalUniV3OutAmount(pool, true, nil)
+				So(err, ShouldEqual, ErrInvalidAmountInput)
+
+				_, err = CalUniV3InAmount(pool, true, big.NewInt(-1))
+				So(err, ShouldEqual, ErrInvalidAmountInput)
+			})
+
+			Convey("With a zero amount", func() {
+				result, err := CalUniV3OutAmount(pool, true, big.NewInt(0))
+				So(err, ShouldBeNil)
+				So(result.Sign(), ShouldEqual, 0)
+			})
+
+			Convey("With an invalid fee", func() {
+				invalidPool := pool
+				invalidPool.FeePips = 1000000
+				_, err := CalUniV3OutAmount(invalidPool, true, big.NewInt(1000))
+				So(err, ShouldEqual, ErrInvalidAmountInput)
+			})
+		})
+	})
+}
@@ -0,0 +1,232 @@
+package ctrlutils
+
+import (
+	"errors"
+	"math/big"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+var ErrSwapRatioExceeded = errors.New("swap amount exceeds the pool's max ratio")
+
+var (
+	// 18 decimal fixed point, as used by the pool weights and swap fee
+	weightedOne = big.NewInt(1e18)
+
+	// Balancer caps an exact-in (out) amount at 30% of the in (out) balance
+	weightedMaxRatio = big.NewInt(3e17)
+
+	// Working precision of weightedPow
+	weightedPowOne = new(big.Int).Exp(big.NewInt(10), big.NewInt(40), nil)
+	weightedPowLn2 = weightedPowLnMantissa(new(big.Int).Lsh(weightedPowOne, 1))
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// WeightedPool is the state of a Balancer-style weighted pool. Weights are
+// normalized and SwapFee is a share of the input, both with 18 decimals.
+type WeightedPool struct {
+	SwapFee  *big.Int
+	Balances []*big.Int
+	Weights  []*big.Int
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// CalWeightedOutAmount returns the amount of token j received for amountIn of
+// token i, mirroring WeightedMath._calcOutGivenIn:
+//
+//	out = Bo * (1 - (Bi / (Bi + Ai * (1 - fee))) ^ (Wi / Wo))
+func CalWeightedOutAmount(pool WeightedPool, i, j int, amountIn *big.Int) (*big.Int, error) {
+	if amountIn == nil || amountIn.Sign() < 0 {
+		return nil, ErrInvalidAmountInput
+	}
+	if err := pool.validate(i, j); err != nil {
+		return nil, err
+	}
+	balanceIn, balanceOut := pool.Balances[i], pool.Balances[j]
+
+	if amountIn.Cmp(fixedMulDown(balanceIn, weightedMaxRatio)) > 0 {
+		return nil, ErrSwapRatioExceeded
+	}
+
+	amountInAfterFee := new(big.Int).Sub(amountIn, fixedMulUp(amountIn, pool.SwapFee))
+	denominator := new(big.Int).Add(balanceIn, amountInAfterFee)
+	base := fixedDivUp(balanceIn, denominator)
+	exponent := fixedDivDown(pool.Weights[i], pool.Weights[j])
+	power := weightedPow(base, exponent, true)
+
+	complement := new(big.Int).Sub(weightedOne, power)
+	if complement.Sign() < 0 {
+		complement.SetInt64(0)
+	}
+	return fixedMulDown(balanceOut, complement), nil
+}
+
+// CalWeightedInAmount returns the amount of token i required to receive
+// amountOut of token j, mirroring WeightedMath._calcInGivenOut:
+//
+//	in = Bi * ((Bo / (Bo - Ao)) ^ (Wo / Wi) - 1) / (1 - fee)
+func CalWeightedInAmount(pool WeightedPool, i, j int, amountOut *big.Int) (*big.Int, error) {
+	if amountOut == nil || amountOut.Sign() < 0 {
+		return nil, ErrInvalidAmountInput
+	}
+	if err := pool.validate(i, j); err != nil {
+		return nil, err
+	}
+	balanceIn, balanceOut := pool.Balances[i], pool.Balances[j]
+
+	if amountOut.Cmp(fixedMulDown(balanceOut, weightedMaxRatio)) > 0 {
+		return nil, ErrSwapRatioExceeded
+	}
+
+	base := fixedDivUp(balanceOut, new(big.Int).Sub(balanceOut, amountOut))
+	exponent := fixedDivUp(pool.Weights[j], pool.Weights[i])
+	power := weightedPow(base, exponent, true)
+
+	ratio := new(big.Int).Sub(power, weightedOne)
+	amountIn := fixedMulUp(balanceIn, ratio)
+	return fixedDivUp(amountIn, new(big.Int).Sub(weightedOne, pool.SwapFee)), nil
+}
+
+// CalWeightedSpotPrice returns the marginal price of token i in token j before
+// a trade, fees excluded:
+//
+//	price = (Bo / Wo) / (Bi / Wi)
+func CalWeightedSpotPrice(pool WeightedPool, i, j int) (*big.Rat, error) {
+	if err := pool.validate(i, j); err != nil {
+		return nil, err
+	}
+
+	numerator := new(big.Int).Mul(pool.Balances[j], pool.Weights[i])
+	denominator := new(big.Int).Mul(pool.Balances[i], pool.Weights[j])
+	return new(big.Rat).SetFrac(numerator, denominator), nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (p WeightedPool) validate(i, j int) error {
+	n := len(p.Balances)
+	if n < 2 || len(p.Weights) != n {
+		return ErrInvalidAmountInput
+	}
+	if i < 0 || j < 0 || i >= n || j >= n || i == j {
+		return ErrInvalidCoinIndex
+	}
+	if p.SwapFee == nil || p.SwapFee.Sign() < 0 || p.SwapFee.Cmp(weightedOne) >= 0 {
+		return ErrInvalidAmountInput
+	}
+	for k := range n {
+		if p.Weights[k] == nil || p.Weights[k].Sign() <= 0 {
+			return ErrInvalidAmountInput
+		}
+		if p.Balances[k] == nil || p.Balances[k].Sign() <= 0 {
+			return ErrInsufficientLiquidity
+		}
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// FixedPoint
+
+func fixedMulDown(a, b *big.Int) *big.Int {
+	return mulDiv(a, b, weightedOne)
+}
+
+func fixedMulUp(a, b *big.Int) *big.Int {
+	return mulDivRoundingUp(a, b, weightedOne)
+}
+
+func fixedDivDown(a, b *big.Int) *big.Int {
+	return mulDiv(a, weightedOne, b)
+}
+
+func fixedDivUp(a, b *big.Int) *big.Int {
+	return mulDivRoundingUp(a, weightedOne, b)
+}
+
+// weightedPow returns x^y for 18 decimal fixed point x > 0 and y >= 0, as
+// exp(y * ln(x)) evaluated with 40 decimals and rounded to 18 decimals.
+func weightedPow(x, y *big.Int, roundUp bool) *big.Int {
+	if y.Sign() == 0 {
+		return new(big.Int).Set(weightedOne)
+	}
+	if y.Cmp(weightedOne) == 0 {
+		return new(big.Int).Set(x)
+	}
+
+	scale := new(big.Int).Div(weightedPowOne, weightedOne)
+	lnX := weightedPowLn(new(big.Int).Mul(x, scale))
+
+	// big.Int.Div is Euclidean, which floors for a positive divisor
+	exponent := new(big.Int).Mul(lnX, y)
+	exponent.Div(exponent, weightedOne)
+	result := weightedPowExp(exponent)
+
+	if roundUp {
+		return divRoundingUp(result, scale)
+	}
+	return result.Div(result, scale)
+}
+
+// weightedPowLn returns ln(x) for a positive x scaled by weightedPowOne,
+// reducing x to [1, 2) by powers of two.
+func weightedPowLn(x *big.Int) *big.Int {
+	two := new(big.Int).Lsh(weightedPowOne, 1)
+	m := new(big.Int).Set(x)
+	k := int64(0)
+	for m.Cmp(two) >= 0 {
+		m.Rsh(m, 1)
+		k++
+	}
+	for m.Cmp(weightedPowOne) < 0 {
+		m.Lsh(m, 1)
+		k--
+	}
+
+	result := weightedPowLnMantissa(m)
+	return result.Add(result, new(big.Int).Mul(big.NewInt(k), weightedPowLn2))
+}
+
+// weightedPowLnMantissa returns ln(m) for m in [1, 2] scaled by weightedPowOne,
+// with the series ln(m) = 2 * (z + z^3/3 + z^5/5 + ...), z = (m-1)/(m+1).
+func weightedPowLnMantissa(m *big.Int) *big.Int {
+	z := new(big.Int).Sub(m, weightedPowOne)
+	z.Mul(z, weightedPowOne)
+	z.Div(z, new(big.Int).Add(m, weightedPowOne))
+	zSquared := new(big.Int).Mul(z, z)
+	zSquared.Div(zSquared, weightedPowOne)
+
+	sum := new(big.Int)
+	term := new(big.Int).Set(z)
+	for n := int64(1); term.Sign() != 0; n += 2 {
+		sum.Add(sum, new(big.Int).Div(term, big.NewInt(n)))
+		term.Mul(term, zSquared)
+		term.Div(term, weightedPowOne)
+	}
+	return sum.Lsh(sum, 1)
+}
+
+// weightedPowExp returns e^x for x scaled by weightedPowOne, reducing x by a
+// multiple of ln(2) before summing the Taylor series.
+func weightedPowExp(x *big.Int) *big.Int {
+	half := new(big.Int).Rsh(weightedPowLn2, 1)
+	k := new(big.Int).Add(x, half)
+	k.Div(k, weightedPowLn2)
+	r := new(big.Int).Sub(x, new(big.Int).Mul(k, weightedPowLn2))
+
+	sum := new(big.Int).Set(weightedPowOne)
+	term := new(big.Int).Set(weightedPowOne)
+	for n := int64(1); term.Sign() != 0; n++ {
+		term.Mul(term, r)
+		term.Quo(term, weightedPowOne)
+		term.Quo(term, big.NewInt(n))
+		sum.Add(sum, term)
+	}
+
+	if k.Sign() >= 0 {
+		return sum.Lsh(sum, uint(k.Int64()))
+	}
+	return sum.Rsh(sum, uint(-k.Int64()))
+}
@@ -0,0 +1,144 @@
+package ctrlutils
+
+import (
+	"math/big"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCalWeightedAmount(t *testing.T) {
+	Convey("Given an 80/20 weighted pool with a 0.3% swap fee", t, func() {
+		tokenA, _ := new(big.Int).SetString("1000000000000000000000000", 10) // 1M, 80%
+		tokenB, _ := new(big.Int).SetString("200000000000000000000000", 10)  // 200k, 20%
+		pool := WeightedPool{
+			SwapFee:  big.NewInt(3000000000000000),
+			Balances: []*big.Int{tokenA, tokenB},
+			Weights:  []*big.Int{big.NewInt(800000000000000000), big.NewInt(200000000000000000)},
+		}
+
+		Convey("When swapping the heavy token for the light token", func() {
+			amountIn, _ := new(big.Int).SetString("1000000000000000000000", 10)
+			result, err := CalWeightedOutAmount(pool, 0, 1, amountIn)
+
+			Convey("Then the output should follow the weighted invariant", func() {
+				So(err, ShouldBeNil)
+				So(result.String(), ShouldEqual, "795615939202531000000")
+			})
+		})
+
+		Convey("When swapping the light token for the heavy token", func() {
+			amountIn, _ := new(big.Int).SetString("100000000000000000000", 10)
+			result, err := CalWeightedOutAmount(pool, 1, 0, amountIn)
+
+			Convey("Then the output should follow the weighted invariant", func() {
+				So(err, ShouldBeNil)
+				So(result.String(), ShouldEqual, "124586186034556000000")
+			})
+		})
+
+		Convey("When pricing the heavy token at the margin", func() {
+			spot, err := CalWeightedSpotPrice(pool, 0, 1)
+
+			Convey("Then it should be the ratio of weighted balances", func() {
+				// (200k / 0.2) / (1M / 0.8)
+				So(err, ShouldBeNil)
+				So(spot.RatString(), ShouldEqual, "4/5")
+			})
+		})
+
+		Convey("When requesting an exact output amount", func() {
+			amountOut, _ := new(big.Int).SetString("100000000000000000000", 10)
+			result, err := CalWeightedInAmount(pool, 0, 1, amountOut)
+
+			Convey("Then the input should be enough to receive the output", func() {
+				So(err, ShouldBeNil)
+				So(result.String(), ShouldEqual, "125415323123763289870")
+
+				out, err := CalWeightedOutAmount(pool, 0, 1, result)
+				So(err, ShouldBeNil)
+				So(out.Cmp(amountOut), ShouldBeGreaterThanOrEqualTo, 0)
+			})
+		})
+
+		Convey("When swapping more than the max in ratio", func() {
+			amountIn, _ := new(big.Int).SetString("300000000000000000000001", 10)
+			_, err := CalWeightedOutAmount(pool, 0, 1, amountIn)
+
+			Convey("Then a ratio exceeded error should be returned", func() {
+				So(err, ShouldEqual, ErrSwapRatioExceeded)
+			})
+		})
+
+		Convey("When testing edge cases", func() {
+			Convey("With invalid token indexes", func() {
+				_, err := CalWeightedOutAmount(pool, 0, 0, big.NewInt(1000))
+				So(err, ShouldEqual, ErrInvalidCoinIndex)
+
+				_, err = CalWeightedInAmount(pool, 2, 0, big.NewInt(1000))
+				So(err, ShouldEqual, ErrInvalidCoinIndex)
+			})
+
+			Convey("With a nil or negative amount", func() {
+				_, err := CalWeightedOutAmount(pool, 0, 1, nil)
+				So(err, ShouldEqual, ErrInvalidAmountInput)
+
+				_, err = CalWeightedInAmount(pool, 0, 1, big.NewInt(-1))
+				So(err, ShouldEqual, ErrInvalidAmountInput)
+			})
+
+			Convey("With a zero amount", func() {
+				result, err := CalWeightedOutAmount(pool, 0, 1, big.NewInt(0))
+				So(err, ShouldBeNil)
+				So(result.Sign(), ShouldEqual, 0)
+			})
+
+			Convey("With an empty balance", func() {
+				emptyPool := pool
+				emptyPool.Balances = []*big.Int{big.NewInt(0), tokenB}
+				_, err := CalWeightedOutAmount(emptyPool, 0, 1, big.NewInt(1000))
+				So(err, ShouldEqual, ErrInsufficientLiquidity)
+			})
+		})
+	})
+
+	Convey("Given equally weighted pools", t, func() {
+		Convey("When swapping in a 50/50 pool", func() {
+			balance, _ := new(big.Int).SetString("1000000000000000000000000", 10)
+			pool := WeightedPool{
+				SwapFee:  big.NewInt(3000000000000000),
+				Balances: []*big.Int{balance, balance},
+				Weights:  []*big.Int{big.NewInt(500000000000000000), big.NewInt(500000000000000000)},
+			}
+			amountIn, _ := new(big.Int).SetString("1000000000000000000000", 10)
+			result, err := CalWeightedOutAmount(pool, 0, 1, amountIn)
+
+			Convey("Then the output should match the constant product formula", func() {
+				So(err, ShouldBeNil)
+				So(result.String(), ShouldEqual, "996006981039903000000")
+			})
+		})
+
+		Convey("When swapping in a three-token pool", func() {
+			balance0, _ := new(big.Int).SetString("500000000000000000000000", 10)
+			balance1, _ := new(big.Int).SetString("700000000000000000000000", 10)
+			balance2, _ := new(big.Int).SetString("900000000000000000000000", 10)
+			pool := WeightedPool{
+				SwapFee:  big.NewInt(3000000000000000),
+				Balances: []*big.Int{balance0, balance1, balance2},
+				Weights: []*big.Int{
+					big.NewInt(333333333333333333),
+					big.NewInt(333333333333333334),
+					big.NewInt(333333333333333333),
+				},
+			}
+			amountIn, _ := new(big.Int).SetString("1000000000000000000000", 10)
+			result, err := CalWeightedOutAmount(pool, 0, 1, amountIn)
+
+			Convey("Then only the two swapped tokens should matter", func() {
+				So(err, ShouldBeNil)
+				So(result.String(), ShouldEqual, "1393022313506866000000")
+			})
+		})
+	})
+}
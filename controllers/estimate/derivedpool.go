@@ -0,0 +1,220 @@
+package estimate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// derivedPool is the pair of a swap on one of the registered DEXes.
+type derivedPool struct {
+	dex    string
+	swap   poolmodel.Swap
+	state  poolmodel.State
+	source string
+}
+
+var (
+	errNoDerivedPool   = errors.New("no pool found for the pair")
+	errPoolNotDeployed = errors.New("pool is not deployed")
+	// errDerivedPoolUnavailable is returned when no derived pool could be
+	// read, and at least one failed for another reason than not existing.
+	errDerivedPoolUnavailable = errors.New("derived pool state unavailable")
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// bestDerivedPool derives the pair of the swap tokens on every registered DEX
+// and returns, among the ones that exist, the pool quoting best: the highest
+// amount out, or the lowest amount in for an exact output. When no existing
+// pool can quote the swap, the error of the last one is returned. When none
+// could be read at all, errDerivedPoolUnavailable is returned if a pool failed
+// for another reason than not being deployed, so that a node outage is not
+// reported as a missing pool.
+func (c *Controller) bestDerivedPool(
+	ctx context.Context,
+	model poolmodel.PoolModel,
+	swap poolmodel.Swap,
+	amount *big.Int,
+	isExactOut bool,
+	srcTaxBps, dstTaxBps uint64,
+) (*derivedPool, error) {
+	logger := log.Ctx(ctx)
+
+	// Each goroutine writes its own slots
+	candidates := make([]*derivedPool, len(c.cfg.Dexes))
+	fetchErrs := make([]error, len(c.cfg.Dexes))
+	var wg sync.WaitGroup
+	for i, dex := range c.cfg.Dexes {
+		poolAddr := dex.PairAddr(swap.Src, swap.Dst)
+		c.rememberPairTokens(poolAddr, swap.Src, swap.Dst, dex.Name)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			state, source, err := c.derivedPoolState(ctx, poolAddr, model)
+			if errors.Is(err, errPoolNotDeployed) {
+				logger.Debug().
+					Str("pool_address", poolAddr.Hex()).
+					Str("dex", dex.Name).
+					Msg("Skipping undeployed derived pool")
+				return
+			}
+			if err != nil {
+				logger.Error().
+					Err(err).
+					Str("pool_address", poolAddr.Hex()).
+					Str("dex", dex.Name).
+					Msg("Failed to get derived pool state")
+				fetchErrs[i] = err
+				return
+			}
+			candidates[i] = &derivedPool{
+				dex: dex.Name,
+				swap: poolmodel.Swap{
+					Pool:   poolAddr,
+					Src:    swap.Src,
+					Dst:    swap.Dst,
+					FeeBps: c.resolveFeeBps(poolAddr, dex.Name),
+				},
+				state:  state,
+				source: source,
+			}
+		}()
+	}
+	wg.Wait()
+
+	var (
+		best       *derivedPool
+		bestAmount *big.Int
+		lastErr    = errNoDerivedPool
+	)
+	for _, candidate := range candidates {
+		if candidate == nil {
+			continue
+		}
+
+		result, err := calTaxedAmount(model, candidate.state, candidate.swap, amount, isExactOut, srcTaxBps, dstTaxBps)
+		if err != nil {
+			logger.Debug().
+				Err(err).
+				Str("pool_address", candidate.swap.Pool.Hex()).
+				Msg("Derived pool cannot quote the swap")
+			lastErr = err
+			continue
+		}
+
+		better := best == nil ||
+			(!isExactOut && result.Cmp(bestAmount) > 0) ||
+			(isExactOut && result.Cmp(bestAmount) < 0)
+		if better {
+			best, bestAmount = candidate, result
+		}
+	}
+	if best == nil {
+		if errors.Is(lastErr, errNoDerivedPool) {
+			for _, err := range fetchErrs {
+				if err != nil {
+					return nil, fmt.Errorf("%w: %v", errDerivedPoolUnavailable, err)
+				}
+			}
+		}
+		return nil, lastErr
+	}
+
+	logger.Debug().
+		Str("pool_address", best.swap.Pool.Hex()).
+		Str("dex", best.dex).
+		Str("amount", bestAmount.String()).
+		Msg("Best derived pool selected")
+	return best, nil
+}
+
+// derivedPoolState returns the state of a derived pool. A pool not in the
+// cache has its contract code checked first when the model supports it, so
+// that an address without a pair is not read; a pair that never had any
+// liquidity is then read with zero reserves and fails to quote.
+func (c *Controller) derivedPoolState(
+	ctx context.Context,
+	poolAddr common.Address,
+	model poolmodel.PoolModel,
+) (poolmodel.State, string, error) {
+	if state := c.ethWssClient.GetPool(ctx, poolAddr); state != nil {
+		return state, sourceCache, nil
+	}
+
+	if deployedModel, ok := model.(poolmodel.DeployedPoolModel); ok {
+		deployed, err := deployedModel.IsDeployed(ctx, poolAddr)
+		if err != nil {
+			return nil, "", err
+		}
+		if !deployed {
+			return nil, "", errPoolNotDeployed
+		}
+	}
+
+	state, err := c.fetchPoolState(ctx, poolAddr, model)
+	if err != nil {
+		return nil, "", err
+	}
+	return state, sourceRPC, nil
+}
+
+// deployedPools returns the pools whose contract is deployed, checked at most
+// BatchFetchConcurrency at once, in their original order. Without a model able
+// to tell, every pool is returned; pools whose check fails are left out.
+func (c *Controller) deployedPools(
+	ctx context.Context,
+	pools []common.Address,
+	model poolmodel.PoolModel,
+) []common.Address {
+	logger := log.Ctx(ctx)
+
+	deployedModel, ok := model.(poolmodel.DeployedPoolModel)
+	if !ok {
+		return pools
+	}
+
+	// Each goroutine writes its own slot
+	deployed := make([]bool, len(pools))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(c.cfg.BatchFetchConcurrency, 1))
+	for i, poolAddr := range pools {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			ok, err := deployedModel.IsDeployed(ctx, poolAddr)
+			if err != nil {
+				logger.Error().
+					Err(err).
+					Str("pool_address", poolAddr.Hex()).
+					Msg("Failed to check pool deployment")
+				return
+			}
+			deployed[i] = ok
+		}()
+	}
+	wg.Wait()
+
+	result := make([]common.Address, 0, len(pools))
+	for i, poolAddr := range pools {
+		if deployed[i] {
+			result = append(result, poolAddr)
+		}
+	}
+	return result
+}
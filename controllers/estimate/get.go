@@ -2,12 +2,18 @@ package estimate
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
+	"strconv"
+	"strings"
 
 	"github.com/WangWilly/swap-estimation/controllers/estimate/ctrlutils"
+	"github.com/WangWilly/swap-estimation/controllers/estimate/poolmodels"
 	"github.com/WangWilly/swap-estimation/pkgs/clients/eth"
-	"github.com/WangWilly/swap-estimation/pkgs/clients/ethwss"
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/WangWilly/swap-estimation/pkgs/utils"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 )
@@ -15,10 +21,75 @@ import (
 ////////////////////////////////////////////////////////////////////////////////
 
 type GetQuery struct {
-	PoolAddr      string `form:"pool" binding:"required"`
+	// Derived on every registered DEX when omitted
+	PoolAddr string `form:"pool"`
+	// Token addresses, or symbols of the loaded token lists
 	SrcTokenAddr  string `form:"src" binding:"required"`
 	DestTokenAddr string `form:"dst" binding:"required"`
-	SrcAmountStr  string `form:"src_amount" binding:"required"`
+	SrcAmountStr  string `form:"src_amount"`
+	DstAmountStr  string `form:"dst_amount"`
+	// Source amount in token units, e.g. 1.5, scaled by the token decimals
+	SrcAmountDecimalStr string `form:"src_amount_decimal"`
+	PoolType            string `form:"pool_type"`
+	SlippageBpsStr      string `form:"slippage_bps"`
+	Format              string `form:"format"`
+	BlockStr            string `form:"block"`
+}
+
+// GetResponse is the JSON estimate response. Amounts are decimal strings in
+// raw token units.
+type GetResponse struct {
+	AmountIn  string `json:"amount_in"`
+	AmountOut string `json:"amount_out"`
+	// min_out is set on exact-input quotes and max_in on exact-output ones
+	// when a slippage tolerance is given
+	MinOut string `json:"min_out,omitempty"`
+	MaxIn  string `json:"max_in,omitempty"`
+	// amount_out in token units; omitted when the decimals cannot be read
+	AmountOutDecimal string `json:"amount_out_decimal,omitempty"`
+
+	Pool     string `json:"pool"`
+	PoolType string `json:"pool_type"`
+	Dex      string `json:"dex"`
+	Token0   string `json:"token0"`
+	Token1   string `json:"token1"`
+	Reserve0 string `json:"reserve0,omitempty"`
+	Reserve1 string `json:"reserve1,omitempty"`
+
+	SpotPrice      string `json:"spot_price,omitempty"`
+	ExecutionPrice string `json:"execution_price,omitempty"`
+	PriceImpactBps *int64 `json:"price_impact_bps,omitempty"`
+	LpFee          string `json:"lp_fee,omitempty"`
+
+	// Whether a transfer tax was applied to the source or destination token
+	SrcTaxed bool `json:"src_taxed"`
+	DstTaxed bool `json:"dst_taxed"`
+
+	BlockNumber uint64 `json:"block_number,omitempty"`
+	BlockHash   string `json:"block_hash,omitempty"`
+	// Source is "cache" when the pool state came from ethwss and "rpc" when
+	// it was fetched for this request, as historical states always are
+	Source string `json:"source"`
+}
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+const (
+	sourceCache = "cache"
+	sourceRPC   = "rpc"
+)
+
+// amount returns the requested amount and whether it is an exact output.
+func (q *GetQuery) amount() (*big.Int, bool, bool) {
+	if q.DstAmountStr != "" {
+		amount, ok := new(big.Int).SetString(q.DstAmountStr, 10)
+		return amount, true, ok
+	}
+	amount, ok := new(big.Int).SetString(q.SrcAmountStr, 10)
+	return amount, false, ok
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -36,115 +107,581 @@ func (c *Controller) Get(ctx *gin.Context) {
 		return
 	}
 
-	if ok := ctrlutils.IsValidAddr(q.PoolAddr); !ok {
-		logger.Error().Msg("Invalid pool address format")
-		ctx.JSON(400, gin.H{"error": "invalid pool address format"})
+	// Exactly one of src_amount, src_amount_decimal (exact input) or
+	// dst_amount (exact output)
+	amountCount := 0
+	for _, amountStr := range []string{q.SrcAmountStr, q.SrcAmountDecimalStr, q.DstAmountStr} {
+		if amountStr != "" {
+			amountCount++
+		}
+	}
+	if amountCount != 1 {
+		logger.Error().Msg("Exactly one of src_amount, src_amount_decimal or dst_amount is required")
+		ctx.JSON(400, gin.H{"error": "invalid query parameters"})
 		return
 	}
 
-	if ok := ctrlutils.IsValidAddr(q.SrcTokenAddr); !ok {
-		logger.Error().Msg("Invalid source token address format")
-		ctx.JSON(400, gin.H{"error": "invalid source token address format"})
+	srcTokenAddr, candidates, err := c.parseToken(q.SrcTokenAddr)
+	if err != nil {
+		writeTokenError(ctx, err, "source", q.SrcTokenAddr, candidates)
 		return
 	}
 
-	if ok := ctrlutils.IsValidAddr(q.DestTokenAddr); !ok {
-		logger.Error().Msg("Invalid destination token address format")
-		ctx.JSON(400, gin.H{"error": "invalid destination token address format"})
+	destTokenAddr, candidates, err := c.parseToken(q.DestTokenAddr)
+	if err != nil {
+		writeTokenError(ctx, err, "destination", q.DestTokenAddr, candidates)
 		return
 	}
 
-	if ok := ctrlutils.IsValidUniV2PairAddr(q.SrcTokenAddr, q.DestTokenAddr, q.PoolAddr); !ok {
-		logger.Error().Msg("Invalid Uniswap V2 pair address")
-		ctx.JSON(400, gin.H{"error": "invalid Uniswap V2 pair address"})
+	var poolAddr common.Address
+	if q.PoolAddr != "" {
+		poolAddr, err = c.parseAddr(q.PoolAddr)
+		if errors.Is(err, ctrlutils.ErrInvalidAddrChecksum) {
+			logger.Error().Str("pool_address", q.PoolAddr).Msg("Invalid pool address checksum")
+			ctx.JSON(400, gin.H{"error": "invalid pool address checksum"})
+			return
+		}
+		if err != nil {
+			logger.Error().Msg("Invalid pool address format")
+			ctx.JSON(400, gin.H{"error": "invalid pool address format"})
+			return
+		}
+	}
+
+	var slippageBps uint64
+	if q.SlippageBpsStr != "" {
+		slippageBps, err = strconv.ParseUint(q.SlippageBpsStr, 10, 64)
+		if err != nil || slippageBps > 10000 {
+			logger.Error().Str("slippage_bps", q.SlippageBpsStr).Msg("Invalid slippage bps")
+			ctx.JSON(400, gin.H{"error": "invalid slippage bps"})
+			return
+		}
+	}
+
+	if q.Format != "" && q.Format != formatText && q.Format != formatJSON {
+		logger.Error().Str("format", q.Format).Msg("Invalid response format")
+		ctx.JSON(400, gin.H{"error": "invalid format"})
 		return
 	}
 
-	srcAmount := new(big.Int)
-	srcAmount, ok := srcAmount.SetString(q.SrcAmountStr, 10)
+	var blockNumber uint64
+	if q.BlockStr != "" {
+		blockNumber, err = strconv.ParseUint(q.BlockStr, 10, 64)
+		if err != nil {
+			logger.Error().Str("block", q.BlockStr).Msg("Invalid block number")
+			ctx.JSON(400, gin.H{"error": "invalid block number"})
+			return
+		}
+	}
+
+	poolType := strings.ToLower(q.PoolType)
+	if poolType == "" {
+		poolType = poolmodels.PoolTypeUniV2
+	}
+	model, ok := c.models.Get(poolType)
 	if !ok {
-		logger.Error().Msg("Invalid source amount")
-		ctx.JSON(400, gin.H{"error": "invalid source amount"})
+		logger.Error().Str("pool_type", q.PoolType).Msg("Invalid pool type")
+		ctx.JSON(400, gin.H{"error": "invalid pool type"})
 		return
 	}
 
+	_, ok = model.(poolmodel.HistoricalPoolModel)
+	if q.BlockStr != "" && !ok {
+		logger.Error().Str("pool_type", poolType).Msg("Historical quotes are not supported for the pool type")
+		ctx.JSON(400, gin.H{"error": "historical quotes are not supported for this pool type"})
+		return
+	}
+
+	if q.PoolAddr == "" {
+		if poolType != poolmodels.PoolTypeUniV2 {
+			logger.Error().Str("pool_type", poolType).Msg("Pool is required for the pool type")
+			ctx.JSON(400, gin.H{"error": "pool is required for this pool type"})
+			return
+		}
+		if q.BlockStr != "" {
+			logger.Error().Msg("Pool is required for historical quotes")
+			ctx.JSON(400, gin.H{"error": "pool is required for historical quotes"})
+			return
+		}
+		if srcTokenAddr == destTokenAddr {
+			logger.Error().Msg("Source and destination tokens are the same")
+			ctx.JSON(400, gin.H{"error": "source and destination tokens must differ"})
+			return
+		}
+	}
+
+	swap := poolmodel.Swap{
+		Pool: poolAddr,
+		Src:  srcTokenAddr,
+		Dst:  destTokenAddr,
+	}
+	dexName := model.Name()
+	if poolType == poolmodels.PoolTypeUniV2 && q.PoolAddr != "" {
+		dex, ok := c.cfg.Dexes.MatchPair(swap.Src, swap.Dst, swap.Pool)
+		if !ok {
+			logger.Error().Msg("Invalid Uniswap V2 pair address")
+			ctx.JSON(400, gin.H{"error": "invalid Uniswap V2 pair address"})
+			return
+		}
+		c.rememberPairTokens(swap.Pool, swap.Src, swap.Dst, dex.Name)
+		dexName = dex.Name
+		swap.FeeBps = c.resolveFeeBps(swap.Pool, dex.Name)
+	}
+
+	var (
+		amount     *big.Int
+		isExactOut bool
+	)
+	if q.SrcAmountDecimalStr != "" {
+		srcMetadata, err := c.erc20Client.Metadata(ctx.Request.Context(), swap.Src)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to get source token metadata")
+			ctx.JSON(500, gin.H{"error": "failed to get source token decimals"})
+			return
+		}
+		amount, err = ctrlutils.ParseDecimalAmount(q.SrcAmountDecimalStr, srcMetadata.Decimals)
+		if err != nil {
+			logger.Error().
+				Str("src_amount_decimal", q.SrcAmountDecimalStr).
+				Uint8("decimals", srcMetadata.Decimals).
+				Msg("Invalid source decimal amount")
+			ctx.JSON(400, gin.H{"error": "invalid source decimal amount"})
+			return
+		}
+	} else {
+		amount, isExactOut, ok = q.amount()
+		if !ok {
+			if isExactOut {
+				logger.Error().Msg("Invalid destination amount")
+				ctx.JSON(400, gin.H{"error": "invalid destination amount"})
+				return
+			}
+			logger.Error().Msg("Invalid source amount")
+			ctx.JSON(400, gin.H{"error": "invalid source amount"})
+			return
+		}
+	}
+
 	////////////////////////////////////////////////////////////////////////////
 
-	// Get the reserve pair from cache or fetch it
-	reservePair, err := c.getPair(ctx.Request.Context(), q.PoolAddr)
+	srcTax, srcTaxed := c.cfg.TokenTaxes.Find(swap.Src)
+	dstTax, dstTaxed := c.cfg.TokenTaxes.Find(swap.Dst)
+
+	var (
+		state  poolmodel.State
+		source string
+	)
+	switch {
+	case q.PoolAddr == "":
+		// Quote on the best of the pairs derived from the tokens
+		pool, err := c.bestDerivedPool(ctx.Request.Context(), model, swap, amount, isExactOut, srcTax.SellBps, dstTax.BuyBps)
+		if errors.Is(err, errNoDerivedPool) {
+			logger.Error().Msg("No pool found for the pair")
+			ctx.JSON(404, gin.H{"error": "no pool found for the pair"})
+			return
+		}
+		if errors.Is(err, errDerivedPoolUnavailable) {
+			logger.Error().Err(err).Msg("Failed to get derived pool state")
+			ctx.JSON(500, gin.H{"error": "failed to get reserve pair"})
+			return
+		}
+		if err != nil {
+			c.writeAmountError(ctx, err, amount, isExactOut)
+			return
+		}
+		swap, state, source, dexName = pool.swap, pool.state, pool.source, pool.dex
+	case q.BlockStr != "":
+		// Historical states bypass the live cache
+		state, err = c.getPoolStateAt(ctx.Request.Context(), swap.Pool, model, blockNumber)
+		if err != nil {
+			c.writeHistoryError(ctx, err, blockNumber)
+			return
+		}
+		source = sourceRPC
+	default:
+		// Get the pool state from cache or fetch it
+		state, source, err = c.getPoolState(ctx.Request.Context(), swap.Pool, model)
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Str("pool_address", swap.Pool.Hex()).
+				Str("pool_type", poolType).
+				Msg("Failed to get pool state")
+			if errors.Is(err, eth.ErrNotPair) {
+				ctx.JSON(400, gin.H{"error": "pool is not a Uniswap V2 pair"})
+				return
+			}
+			if poolType == poolmodels.PoolTypeUniV2 {
+				ctx.JSON(500, gin.H{"error": "failed to get reserve pair"})
+				return
+			}
+			ctx.JSON(500, gin.H{"error": "failed to get pool state"})
+			return
+		}
+	}
+	ctx.Writer.Header().Set(utils.DexNameHeader, dexName)
+
+	result, err := calTaxedAmount(model, state, swap, amount, isExactOut, srcTax.SellBps, dstTax.BuyBps)
 	if err != nil {
-		logger.Error().
-			Err(err).
-			Str("pool_address", q.PoolAddr).
-			Msg("Failed to get Uniswap V2 reserve pair")
-		ctx.JSON(500, gin.H{"error": "failed to get reserve pair"})
+		c.writeAmountError(ctx, err, amount, isExactOut)
 		return
 	}
 
-	estimatedAmount := ctrlutils.CalOutAmount(
-		q.SrcTokenAddr,
-		q.DestTokenAddr,
-		srcAmount,
-		reservePair.Reserve0,
-		reservePair.Reserve1,
-	)
-	if estimatedAmount == nil {
-		logger.Error().Msg("Failed to calculate output amount")
-		ctx.JSON(500, gin.H{"error": "failed to calculate output amount"})
+	amountIn, amountOut := amount, result
+	if isExactOut {
+		amountIn, amountOut = result, amount
+	}
+	metrics := c.tradeMetrics(ctx.Request.Context(), model, state, swap, amountIn, amountOut)
+	// slippageBps is already validated. The output of an exact-output quote
+	// is fixed, so its input is bounded instead.
+	var minOut, maxIn *big.Int
+	if q.SlippageBpsStr != "" && isExactOut {
+		maxIn, _ = ctrlutils.CalMaxInAmount(amountIn, slippageBps)
+	} else if q.SlippageBpsStr != "" {
+		minOut, _ = ctrlutils.CalMinOutAmount(amountOut, slippageBps)
+	}
+	setQuoteHeaders(ctx, metrics, minOut, maxIn)
+	if srcTaxed {
+		ctx.Writer.Header().Set(utils.SrcTaxedHeader, "true")
+	}
+	if dstTaxed {
+		ctx.Writer.Header().Set(utils.DstTaxedHeader, "true")
+	}
+
+	wantsJSON := q.wantsJSON(ctx)
+	var amountOutDecimal string
+	if wantsJSON || q.SrcAmountDecimalStr != "" {
+		amountOutDecimal = c.formatTokenAmount(ctx.Request.Context(), swap.Dst, amountOut)
+	}
+
+	if !wantsJSON {
+		if amountOutDecimal != "" {
+			ctx.Writer.Header().Set(utils.AmountOutDecimalHeader, amountOutDecimal)
+		}
+		// plain text response
+		ctx.Writer.Header().Set("Content-Type", "text/plain")
+		ctx.String(200, result.String())
+		return
+	}
+
+	reserves, err := model.Reserves(state, swap)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get pool reserves")
+		ctx.JSON(500, gin.H{"error": "failed to get pool reserves"})
 		return
 	}
 
-	// plain text response
-	ctx.Writer.Header().Set("Content-Type", "text/plain")
-	ctx.String(200, estimatedAmount.String())
+	resp := GetResponse{
+		AmountIn:         amountIn.String(),
+		AmountOut:        amountOut.String(),
+		AmountOutDecimal: amountOutDecimal,
+		Pool:             swap.Pool.Hex(),
+		PoolType:         poolType,
+		Dex:              dexName,
+		Token0:           reserves.Token0.Hex(),
+		Token1:           reserves.Token1.Hex(),
+		SrcTaxed:         srcTaxed,
+		DstTaxed:         dstTaxed,
+		Source:           source,
+	}
+	if minOut != nil {
+		resp.MinOut = minOut.String()
+	}
+	if maxIn != nil {
+		resp.MaxIn = maxIn.String()
+	}
+	if reserves.Reserve0 != nil && reserves.Reserve1 != nil {
+		resp.Reserve0 = reserves.Reserve0.String()
+		resp.Reserve1 = reserves.Reserve1.String()
+	}
+	if metrics != nil {
+		resp.SpotPrice = formatPrice(metrics.SpotPrice)
+		resp.ExecutionPrice = formatPrice(metrics.ExecutionPrice)
+		resp.PriceImpactBps = &metrics.PriceImpactBps
+		resp.LpFee = metrics.LpFee.String()
+	}
+	if reserves.BlockNumber != 0 {
+		resp.BlockNumber = reserves.BlockNumber
+		resp.BlockHash = reserves.BlockHash.Hex()
+	}
+	ctx.JSON(200, resp)
+}
+
+// wantsJSON tells whether the caller asked for a JSON response, with
+// format=json or an Accept header preferring application/json.
+func (q *GetQuery) wantsJSON(ctx *gin.Context) bool {
+	switch q.Format {
+	case formatJSON:
+		return true
+	case formatText:
+		return false
+	}
+	return ctx.NegotiateFormat(gin.MIMEPlain, gin.MIMEJSON) == gin.MIMEJSON
+}
+
+// calTaxedAmount quotes a swap with the transfer taxes of its tokens: the
+// pool receives the source amount net of the sell tax, and the destination
+// amount it sends out is reduced by the buy tax. It returns the amount out, or
+// the amount in when isExactOut is set.
+func calTaxedAmount(
+	model poolmodel.PoolModel,
+	state poolmodel.State,
+	swap poolmodel.Swap,
+	amount *big.Int,
+	isExactOut bool,
+	srcTaxBps, dstTaxBps uint64,
+) (*big.Int, error) {
+	if isExactOut {
+		poolAmountOut := ctrlutils.GrossUpTransferTax(amount, dstTaxBps)
+		poolAmountIn, err := model.AmountIn(state, swap, poolAmountOut)
+		if err != nil {
+			return nil, err
+		}
+		return ctrlutils.GrossUpTransferTax(poolAmountIn, srcTaxBps), nil
+	}
+
+	poolAmountOut, err := model.AmountOut(state, swap, ctrlutils.ApplyTransferTax(amount, srcTaxBps))
+	if err != nil {
+		return nil, err
+	}
+	return ctrlutils.ApplyTransferTax(poolAmountOut, dstTaxBps), nil
+}
+
+// writeAmountError maps an error of a pool model's amount computation to a
+// response.
+func (c *Controller) writeAmountError(ctx *gin.Context, err error, amount *big.Int, isExactOut bool) {
+	logger := log.Ctx(ctx.Request.Context())
+
+	switch {
+	case errors.Is(err, poolmodel.ErrUnknownToken):
+		logger.Error().Err(err).Msg("Tokens do not match the pool")
+		ctx.JSON(400, gin.H{"error": "invalid pool tokens"})
+	case errors.Is(err, poolmodels.ErrInvalidState):
+		// The pool was cached as another pool type
+		logger.Error().Err(err).Msg("Pool type does not match the pool")
+		ctx.JSON(400, gin.H{"error": "pool type does not match the pool"})
+	case errors.Is(err, ctrlutils.ErrInsufficientLiquidity):
+		logger.Error().
+			Err(err).
+			Str("amount", amount.String()).
+			Msg("Amount exceeds pool liquidity")
+		if isExactOut {
+			ctx.JSON(400, gin.H{"error": "destination amount exceeds pool liquidity"})
+			return
+		}
+		ctx.JSON(400, gin.H{"error": "insufficient pool liquidity"})
+	case errors.Is(err, ctrlutils.ErrZeroReserve):
+		logger.Error().Err(err).Msg("Pool has no liquidity")
+		ctx.JSON(400, gin.H{"error": "pool has no liquidity"})
+	case errors.Is(err, ctrlutils.ErrReserveOverflow):
+		logger.Error().Err(err).Msg("Pool reserves are out of range")
+		ctx.JSON(502, gin.H{"error": "invalid pool reserves"})
+	case errors.Is(err, ctrlutils.ErrInvariantViolated):
+		logger.Error().
+			Err(err).
+			Str("amount", amount.String()).
+			Msg("Quote violates the pool invariant")
+		ctx.JSON(500, gin.H{"error": "quote failed the pool invariant check"})
+	case errors.Is(err, ctrlutils.ErrInsufficientTickData):
+		logger.Error().
+			Err(err).
+			Str("amount", amount.String()).
+			Msg("Swap crosses ticks beyond the loaded range")
+		ctx.JSON(400, gin.H{"error": "amount exceeds the loaded tick range"})
+	case errors.Is(err, ctrlutils.ErrSwapRatioExceeded):
+		logger.Error().
+			Err(err).
+			Str("amount", amount.String()).
+			Msg("Amount exceeds the pool's max swap ratio")
+		ctx.JSON(400, gin.H{"error": "amount exceeds the pool's max swap ratio"})
+	case isExactOut:
+		logger.Error().Err(err).Msg("Failed to calculate input amount")
+		ctx.JSON(500, gin.H{"error": "failed to calculate input amount"})
+	default:
+		logger.Error().Err(err).Msg("Failed to calculate output amount")
+		ctx.JSON(500, gin.H{"error": "failed to calculate output amount"})
+	}
+}
+
+// writeHistoryError maps the errors of a historical state read to responses.
+func (c *Controller) writeHistoryError(ctx *gin.Context, err error, blockNumber uint64) {
+	logger := log.Ctx(ctx.Request.Context())
+
+	switch {
+	case errors.Is(err, eth.ErrBlockNotReached):
+		logger.Error().
+			Err(err).
+			Uint64("block", blockNumber).
+			Msg("Block is past the chain head")
+		ctx.JSON(400, gin.H{"error": "block not reached yet"})
+	case errors.Is(err, eth.ErrNotPair):
+		logger.Error().
+			Err(err).
+			Uint64("block", blockNumber).
+			Msg("Pool is not a Uniswap V2 pair")
+		ctx.JSON(400, gin.H{"error": "pool is not a Uniswap V2 pair"})
+	case errors.Is(err, eth.ErrHistoryUnavailable):
+		logger.Error().
+			Err(err).
+			Uint64("block", blockNumber).
+			Msg("Node cannot serve the block history")
+		ctx.JSON(503, gin.H{"error": "node cannot serve the history of the requested block"})
+	default:
+		logger.Error().
+			Err(err).
+			Uint64("block", blockNumber).
+			Msg("Failed to get historical pool state")
+		ctx.JSON(500, gin.H{"error": "failed to get historical pool state"})
+	}
+}
+
+// tradeMetrics derives the spot and execution prices, the price impact and
+// the LP fee of a quote. They are best effort: nil is returned for a pool
+// whose spot price cannot be derived, which is still quoted without them.
+func (c *Controller) tradeMetrics(
+	ctx context.Context,
+	model poolmodel.PoolModel,
+	state poolmodel.State,
+	swap poolmodel.Swap,
+	amountIn, amountOut *big.Int,
+) *ctrlutils.TradeMetrics {
+	logger := log.Ctx(ctx)
+
+	spot, err := model.SpotPrice(state, swap)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to get spot price")
+		return nil
+	}
+	metrics, err := ctrlutils.CalTradeMetrics(spot.Price, spot.FeeRate, amountIn, amountOut)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to calculate trade metrics")
+		return nil
+	}
+	return metrics
+}
+
+func setQuoteHeaders(ctx *gin.Context, metrics *ctrlutils.TradeMetrics, minOut, maxIn *big.Int) {
+	header := ctx.Writer.Header()
+	if metrics != nil {
+		header.Set(utils.SpotPriceHeader, formatPrice(metrics.SpotPrice))
+		header.Set(utils.ExecutionPriceHeader, formatPrice(metrics.ExecutionPrice))
+		header.Set(utils.PriceImpactBpsHeader, strconv.FormatInt(metrics.PriceImpactBps, 10))
+		header.Set(utils.LpFeeHeader, metrics.LpFee.String())
+	}
+	if minOut != nil {
+		header.Set(utils.MinOutHeader, minOut.String())
+	}
+	if maxIn != nil {
+		header.Set(utils.MaxInHeader, maxIn.String())
+	}
+}
+
+// formatTokenAmount renders an amount of a token in token units. It is best
+// effort: an empty string is returned when the token decimals cannot be read.
+func (c *Controller) formatTokenAmount(ctx context.Context, tokenAddr common.Address, amount *big.Int) string {
+	metadata, err := c.erc20Client.Metadata(ctx, tokenAddr)
+	if err != nil {
+		log.Ctx(ctx).Warn().
+			Err(err).
+			Str("token_address", tokenAddr.Hex()).
+			Msg("Failed to get token metadata")
+		return ""
+	}
+	return ctrlutils.FormatDecimalAmount(amount, metadata.Decimals)
+}
+
+// formatPrice renders a price in raw token units with 18 significant digits.
+func formatPrice(price *big.Rat) string {
+	return new(big.Float).SetPrec(256).SetRat(price).Text('g', 18)
 }
 
 ////////////////////////////////////////////////////////////////////////////////
 
-func (c *Controller) getPair(ctx context.Context, poolAddr string) (*eth.ReservePair, error) {
+// getPoolState returns the cached state of a pool, or fetches it through its
+// model and registers the pool with ethwss for log driven updates. The source
+// tells which of the two happened.
+func (c *Controller) getPoolState(ctx context.Context, poolAddr common.Address, model poolmodel.PoolModel) (poolmodel.State, string, error) {
 	logger := log.Ctx(ctx)
 	logger.Debug().
-		Str("pool_address", poolAddr).
-		Msg("Getting Uniswap V2 pair for reserve updates")
+		Str("pool_address", poolAddr.Hex()).
+		Str("model", model.Name()).
+		Msg("Getting pool state")
 
-	pair := c.ethWssClient.GetPair(ctx, poolAddr)
-	if pair != nil {
+	state := c.ethWssClient.GetPool(ctx, poolAddr)
+	if state != nil {
 		logger.Debug().
-			Str("pool_address", poolAddr).
-			Msg("Pair found in cache")
-		return (*eth.ReservePair)(pair), nil
+			Str("pool_address", poolAddr.Hex()).
+			Msg("Pool found in cache")
+		return state, sourceCache, nil
 	}
 
-	// Use singleflight to prevent duplicate requests for the same estimation
-	singleflightKey := "estimate_" + poolAddr
+	state, err := c.fetchPoolState(ctx, poolAddr, model)
+	if err != nil {
+		return nil, "", err
+	}
+	return state, sourceRPC, nil
+}
+
+// fetchPoolState fetches the state of a pool through its model, sharing the
+// call with concurrent requests for the same pool, and registers the pool
+// with ethwss.
+func (c *Controller) fetchPoolState(ctx context.Context, poolAddr common.Address, model poolmodel.PoolModel) (poolmodel.State, error) {
+	logger := log.Ctx(ctx)
+
+	// Use singleflight to prevent duplicate requests for the same estimation;
+	// the model is part of the key, since requests may read one address as
+	// different pool types
+	singleflightKey := "estimate_" + model.Name() + "_" + poolAddr.Hex()
 	res, err, _ := c.g4GetEstimate.Do(singleflightKey, func() (any, error) {
-		return c.ethClient.UniV2ReservePair(ctx, poolAddr)
+		return model.FetchState(ctx, poolAddr)
 	})
 	if err != nil {
 		logger.Error().
 			Err(err).
-			Str("pool_address", poolAddr).
-			Msg("Failed to get Uniswap V2 reserve pair")
+			Str("pool_address", poolAddr.Hex()).
+			Msg("Failed to fetch pool state")
 		return nil, err
 	}
 	if res == nil {
 		logger.Error().
-			Str("pool_address", poolAddr).
-			Msg("Uniswap V2 reserve pair not found")
-		return nil, err
+			Str("pool_address", poolAddr.Hex()).
+			Msg("Pool state not found")
+		return nil, fmt.Errorf("pool state not found for %s", poolAddr.Hex())
 	}
 	logger.Debug().
-		Str("pool_address", poolAddr).
-		Msg("Uniswap V2 reserve pair found")
+		Str("pool_address", poolAddr.Hex()).
+		Msg("Pool state fetched")
+
+	c.ethWssClient.RegPool(context.Background(), poolAddr, model, res)
+	return res, nil
+}
+
+// getPoolStateAt fetches the state of a pool as of a past block, through a
+// model implementing HistoricalPoolModel. It is shared with concurrent
+// requests for the same model and block but never cached.
+func (c *Controller) getPoolStateAt(
+	ctx context.Context,
+	poolAddr common.Address,
+	model poolmodel.PoolModel,
+	blockNumber uint64,
+) (poolmodel.State, error) {
+	logger := log.Ctx(ctx)
+	logger.Debug().
+		Str("pool_address", poolAddr.Hex()).
+		Uint64("block", blockNumber).
+		Msg("Getting historical pool state")
 
-	currPair, ok := res.(*eth.ReservePair)
+	historicalModel, ok := model.(poolmodel.HistoricalPoolModel)
 	if !ok {
-		logger.Error().
-			Str("pool_address", poolAddr).
-			Msg("Unexpected response type from UniV2ReservePair")
-		return nil, fmt.Errorf("unexpected response type from UniV2ReservePair: %T", res)
+		return nil, fmt.Errorf("historical states are not supported by %s", model.Name())
 	}
 
-	c.ethWssClient.RegPair(context.Background(), poolAddr, (*ethwss.ReservePair)(currPair))
-	return currPair, nil
+	singleflightKey := fmt.Sprintf("estimate_%s_%s@%d", model.Name(), poolAddr.Hex(), blockNumber)
+	res, err, _ := c.g4GetEstimate.Do(singleflightKey, func() (any, error) {
+		return historicalModel.FetchStateAt(ctx, poolAddr, blockNumber)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
 }
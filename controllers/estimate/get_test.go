@@ -2,14 +2,26 @@ package estimate
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"math/big"
 	"net/http"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/WangWilly/swap-estimation/controllers/estimate/ctrlutils"
+	"github.com/WangWilly/swap-estimation/controllers/estimate/poolmodels"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/erc20"
 	"github.com/WangWilly/swap-estimation/pkgs/clients/eth"
-	"github.com/WangWilly/swap-estimation/pkgs/clients/ethwss"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/univ3"
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/WangWilly/swap-estimation/pkgs/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	. "github.com/smartystreets/goconvey/convey"
 	"go.uber.org/mock/gomock"
 )
@@ -29,170 +41,1106 @@ func TestGet(t *testing.T) {
 			}
 			mockReservePair.Reserve1.SetString("100000000000000000000", 10) // 100 ETH in wei
 
-			mockEthWssReservePair := (*ethwss.ReservePair)(mockReservePair)
+			expectedOutput := "1974316068"
+			usdcMetadata := &erc20.Metadata{Decimals: 6, Symbol: "USDC", Name: "USD Coin"}
+			pairMetadata := &eth.PairMetadata{
+				Token0: common.HexToAddress(validDstAddr),
+				Token1: common.HexToAddress(validSrcAddr),
+			}
+
+			Convey("When making a valid estimation request", func() {
+				// Set up expectations for the cache miss and eth client call
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(nil) // Cache miss
+
+				s.ethClient.EXPECT().
+					UniV2ReservePair(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(mockReservePair, nil)
+				s.ethClient.EXPECT().
+					UniV2PairMetadata(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(pairMetadata, nil)
+
+				s.ethWssClient.EXPECT().
+					RegPool(gomock.Any(), common.HexToAddress(validPoolAddr), gomock.Any(), gomock.Any()).
+					Return(nil)
+
+				// Make the request and verify response
+				var actualOutput string
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount,
+					nil,
+					&actualOutput,
+				)
+
+				Convey("Then the response should be successful with the estimated amount", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(actualOutput, ShouldEqual, expectedOutput)
+				})
+			})
+
+			Convey("When making a request with cached pool data", func() {
+				// Set up expectation for cache hit
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(mockReservePair) // Cache hit
+
+				// No call to ethClient.UniV2ReservePair expected
+				// No call to ethWssClient.RegPool expected
+
+				// Make the request and verify response
+				var actualOutput string
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount,
+					nil,
+					&actualOutput,
+				)
+
+				Convey("Then the response should be successful with the estimated amount from cache", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(actualOutput, ShouldEqual, expectedOutput)
+				})
+			})
+
+			Convey("When the pool has a configured fee override", func() {
+				s.controller.cfg.PoolFees = ctrlutils.PoolFeeMap{common.HexToAddress(validPoolAddr): 25}
+				defer func() { s.controller.cfg.PoolFees = nil }()
+
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(mockReservePair) // Cache hit
+
+				var actualOutput string
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount,
+					nil,
+					&actualOutput,
+				)
+
+				Convey("Then the estimate should use the pool fee", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(actualOutput, ShouldEqual, "1975296418")
+				})
+			})
+
+			Convey("When making a request with addresses in mixed case", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(mockReservePair) // Cache hit
+
+				var actualOutput string
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+strings.ToLower(validPoolAddr)+
+						"&src=0x"+strings.ToUpper(validSrcAddr[2:])+
+						"&dst="+strings.ToLower(validDstAddr)+
+						"&src_amount="+validAmount,
+					nil,
+					&actualOutput,
+				)
+
+				Convey("Then the estimate should not depend on the case", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(actualOutput, ShouldEqual, expectedOutput)
+				})
+			})
+
+			Convey("When strict checksums are required", func() {
+				s.controller.cfg.StrictChecksum = true
+				defer func() { s.controller.cfg.StrictChecksum = false }()
+
+				Convey("And the addresses are checksummed", func() {
+					s.ethWssClient.EXPECT().
+						GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+						Return(mockReservePair) // Cache hit
+
+					var actualOutput string
+					resCode := s.testServer.MustDo(
+						t,
+						http.MethodGet,
+						"/estimate?pool="+validPoolAddr+
+							"&src="+validSrcAddr+
+							"&dst="+validDstAddr+
+							"&src_amount="+validAmount,
+						nil,
+						&actualOutput,
+					)
+
+					Convey("Then the estimate should be returned", func() {
+						So(resCode, ShouldEqual, http.StatusOK)
+						So(actualOutput, ShouldEqual, expectedOutput)
+					})
+				})
+
+				Convey("And the source token is not checksummed", func() {
+					var errorResponse map[string]string
+					s.testServer.MustDoAndMatchCode(
+						t,
+						http.MethodGet,
+						"/estimate?pool="+validPoolAddr+
+							"&src="+strings.ToLower(validSrcAddr)+
+							"&dst="+validDstAddr+
+							"&src_amount="+validAmount,
+						nil,
+						&errorResponse,
+						http.StatusBadRequest,
+					)
+
+					Convey("Then the response should indicate an invalid checksum", func() {
+						So(errorResponse["error"], ShouldEqual, "invalid source token address checksum")
+					})
+				})
+
+				Convey("And the pool is not checksummed", func() {
+					var errorResponse map[string]string
+					s.testServer.MustDoAndMatchCode(
+						t,
+						http.MethodGet,
+						"/estimate?pool="+strings.ToLower(validPoolAddr)+
+							"&src="+validSrcAddr+
+							"&dst="+validDstAddr+
+							"&src_amount="+validAmount,
+						nil,
+						&errorResponse,
+						http.StatusBadRequest,
+					)
+
+					Convey("Then the response should indicate an invalid checksum", func() {
+						So(errorResponse["error"], ShouldEqual, "invalid pool address checksum")
+					})
+				})
+
+				Convey("And the tokens are given by symbol", func() {
+					s.ethWssClient.EXPECT().
+						GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+						Return(mockReservePair) // Cache hit
+
+					var actualOutput string
+					resCode := s.testServer.MustDo(
+						t,
+						http.MethodGet,
+						"/estimate?pool="+validPoolAddr+
+							"&src=weth&dst=usdc&src_amount="+validAmount,
+						nil,
+						&actualOutput,
+					)
+
+					Convey("Then the resolved addresses should be accepted", func() {
+						So(resCode, ShouldEqual, http.StatusOK)
+						So(actualOutput, ShouldEqual, expectedOutput)
+					})
+				})
+			})
+
+			Convey("When both tokens are taxed", func() {
+				s.controller.cfg.TokenTaxes = ctrlutils.TokenTaxList{
+					{Token: common.HexToAddress(validSrcAddr), SellBps: 500, BuyBps: 500},
+					{Token: common.HexToAddress(validDstAddr), SellBps: 300, BuyBps: 300},
+				}
+				defer func() { s.controller.cfg.TokenTaxes = nil }()
+
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(mockReservePair) // Cache hit
+				s.erc20Client.EXPECT().
+					Metadata(gomock.Any(), common.HexToAddress(validDstAddr)).
+					Return(usdcMetadata, nil)
+
+				var actualOutput GetResponse
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount+
+						"&format=json",
+					nil,
+					&actualOutput,
+				)
+
+				Convey("Then the estimate should apply the input and output haircuts", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					// 1 ETH taxed 5% in, 1876526479 out of the pool taxed 3%
+					So(actualOutput.AmountOut, ShouldEqual, "1820230685")
+					So(actualOutput.SrcTaxed, ShouldBeTrue)
+					So(actualOutput.DstTaxed, ShouldBeTrue)
+				})
+			})
+
+			Convey("When the destination token is taxed on an exact-output request", func() {
+				s.controller.cfg.TokenTaxes = ctrlutils.TokenTaxList{
+					{Token: common.HexToAddress(validDstAddr), SellBps: 300, BuyBps: 300},
+				}
+				defer func() { s.controller.cfg.TokenTaxes = nil }()
+
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(mockReservePair) // Cache hit
+
+				resp, err := http.Get(s.testServer.GetURL(
+					t,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&dst_amount=1820230685",
+				))
+				So(err, ShouldBeNil)
+				defer resp.Body.Close()
+				body, err := io.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+
+				Convey("Then the source amount should cover the pool output grossed up by the tax", func() {
+					So(resp.StatusCode, ShouldEqual, http.StatusOK)
+					// The pool has to send 1876526479 for 1820230685 to arrive
+					So(string(body), ShouldEqual, "949999999770083754")
+					So(resp.Header.Get(utils.SrcTaxedHeader), ShouldBeEmpty)
+					So(resp.Header.Get(utils.DstTaxedHeader), ShouldEqual, "true")
+				})
+			})
+
+			Convey("When making a request against a pool of a registered fork", func() {
+				forkDexes := ctrlutils.DexList{}
+				So(forkDexes.EnvDecode(
+					"uniswapv2:0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f:0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f:30,"+
+						"testfork:0x1000000000000000000000000000000000000001:0x1111111111111111111111111111111111111111111111111111111111111111:30",
+				), ShouldBeNil)
+				defaultDexes := s.controller.cfg.Dexes
+				s.controller.cfg.Dexes = forkDexes
+				defer func() { s.controller.cfg.Dexes = defaultDexes }()
+
+				forkPoolAddr := forkDexes[1].PairAddr(common.HexToAddress(validSrcAddr), common.HexToAddress(validDstAddr)).Hex()
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(forkPoolAddr)).
+					Return(mockReservePair) // Cache hit
+
+				var actualOutput string
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+forkPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount,
+					nil,
+					&actualOutput,
+				)
+
+				Convey("Then the fork pool should be accepted and quoted", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(actualOutput, ShouldEqual, expectedOutput)
+				})
+			})
+
+			Convey("When making a request without a pool address", func() {
+				forkDexes := ctrlutils.DexList{}
+				So(forkDexes.EnvDecode(
+					"uniswapv2:0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f:0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f:30,"+
+						"testfork:0x1000000000000000000000000000000000000001:0x1111111111111111111111111111111111111111111111111111111111111111:30",
+				), ShouldBeNil)
+				defaultDexes := s.controller.cfg.Dexes
+				s.controller.cfg.Dexes = forkDexes
+				defer func() { s.controller.cfg.Dexes = defaultDexes }()
+
+				// The fork pair holds more USDC for the same WETH
+				forkPoolAddr := forkDexes[1].PairAddr(common.HexToAddress(validSrcAddr), common.HexToAddress(validDstAddr)).Hex()
+				forkReservePair := &eth.ReservePair{
+					Reserve0: big.NewInt(250000000000),
+					Reserve1: mockReservePair.Reserve1,
+				}
+
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(mockReservePair) // Cache hit
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(forkPoolAddr)).
+					Return(nil) // Cache miss
+				s.ethClient.EXPECT().
+					ContractExists(gomock.Any(), common.HexToAddress(forkPoolAddr)).
+					Return(true, nil)
+				s.ethClient.EXPECT().
+					UniV2ReservePair(gomock.Any(), common.HexToAddress(forkPoolAddr)).
+					Return(forkReservePair, nil)
+				s.ethClient.EXPECT().
+					UniV2PairMetadata(gomock.Any(), common.HexToAddress(forkPoolAddr)).
+					Return(pairMetadata, nil)
+				s.ethWssClient.EXPECT().
+					RegPool(gomock.Any(), common.HexToAddress(forkPoolAddr), gomock.Any(), gomock.Any()).
+					Return(nil)
+
+				resp, err := http.Get(s.testServer.GetURL(
+					t,
+					"/estimate?src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount,
+				))
+				So(err, ShouldBeNil)
+				defer resp.Body.Close()
+				body, err := io.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+
+				Convey("Then the best of the derived pools should be quoted", func() {
+					So(resp.StatusCode, ShouldEqual, http.StatusOK)
+					So(string(body), ShouldEqual, "2467895085")
+					So(resp.Header.Get(utils.DexNameHeader), ShouldEqual, "testfork")
+				})
+			})
+
+			Convey("When making a request without a pool address and no pair is deployed", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(nil) // Cache miss
+				s.ethClient.EXPECT().
+					ContractExists(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(false, nil)
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusNotFound,
+				)
+
+				Convey("Then the response should indicate no pool was found", func() {
+					So(errorResponse["error"], ShouldEqual, "no pool found for the pair")
+				})
+			})
+
+			Convey("When making a request without a pool address and the node fails", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(nil) // Cache miss
+				s.ethClient.EXPECT().
+					ContractExists(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(false, errors.New("connection refused"))
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusInternalServerError,
+				)
+
+				Convey("Then the response should not report a missing pool", func() {
+					So(errorResponse["error"], ShouldEqual, "failed to get reserve pair")
+				})
+			})
+
+			Convey("When making a request without a pool address for another pool type", func() {
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool_type=v3"+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate pool address is required", func() {
+					So(errorResponse["error"], ShouldEqual, "pool is required for this pool type")
+				})
+			})
+
+			Convey("When making a request with invalid pool address format", func() {
+				invalidPoolAddr := "0xinvalid"
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+invalidPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate invalid pool address format", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid pool address format")
+				})
+			})
+
+			Convey("When making a request with missing source token address", func() {
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate source token address is required", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid query parameters")
+				})
+			})
+
+			Convey("When making a request with invalid source token address", func() {
+				invalidSrcAddr := "0xinvalid"
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+invalidSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate invalid source token address format", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid source token address format")
+				})
+			})
+
+			Convey("When making a request with missing destination token address", func() {
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
+						"&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate destination token address is required", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid query parameters")
+				})
+			})
+
+			Convey("When making a request with invalid destination token address", func() {
+				invalidDstAddr := "0xinvalid"
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+invalidDstAddr+
+						"&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate invalid destination token address format", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid destination token address format")
+				})
+			})
+
+			Convey("When making a request with invalid pair address", func() {
+				// Using valid addresses but they don't form a valid pair
+				invalidPairPoolAddr := "0x1000000000000000000000000000000000000000"
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+invalidPairPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate invalid Uniswap V2 pair address", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid Uniswap V2 pair address")
+				})
+			})
+
+			Convey("When making a request with missing source amount", func() {
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr,
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate source amount is required", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid query parameters")
+				})
+			})
+
+			Convey("When making a request with invalid source amount", func() {
+				invalidAmount := "not-a-number"
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+invalidAmount,
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate invalid source amount", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid source amount")
+				})
+			})
+
+			Convey("When making a valid exact-output estimation request", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(mockReservePair) // Cache hit
+
+				var actualOutput string
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&dst_amount="+expectedOutput,
+					nil,
+					&actualOutput,
+				)
+
+				Convey("Then the response should be the source amount required, rounded up", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(actualOutput, ShouldEqual, "999999999593763120")
+				})
+			})
+
+			Convey("When making an exact-output JSON request with a slippage tolerance", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(mockReservePair) // Cache hit
+				s.erc20Client.EXPECT().
+					Metadata(gomock.Any(), common.HexToAddress(validDstAddr)).
+					Return(usdcMetadata, nil)
+
+				resp, err := http.Get(s.testServer.GetURL(
+					t,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&dst_amount="+expectedOutput+
+						"&slippage_bps=50"+
+						"&format=json",
+				))
+				So(err, ShouldBeNil)
+				defer resp.Body.Close()
+				var actualOutput GetResponse
+				So(json.NewDecoder(resp.Body).Decode(&actualOutput), ShouldBeNil)
+
+				Convey("Then the input should be bounded instead of the fixed output", func() {
+					So(resp.StatusCode, ShouldEqual, http.StatusOK)
+					So(actualOutput.AmountIn, ShouldEqual, "999999999593763120")
+					So(actualOutput.AmountOut, ShouldEqual, expectedOutput)
+					So(actualOutput.MaxIn, ShouldEqual, "1004999999591731936")
+					So(actualOutput.MinOut, ShouldBeEmpty)
+					So(resp.Header.Get(utils.MaxInHeader), ShouldEqual, "1004999999591731936")
+					So(resp.Header.Get(utils.MinOutHeader), ShouldBeEmpty)
+				})
+			})
+
+			Convey("When making a request with a decimal source amount", func() {
+				s.erc20Client.EXPECT().
+					Metadata(gomock.Any(), common.HexToAddress(validSrcAddr)).
+					Return(&erc20.Metadata{Decimals: 18, Symbol: "WETH", Name: "Wrapped Ether"}, nil)
+				s.erc20Client.EXPECT().
+					Metadata(gomock.Any(), common.HexToAddress(validDstAddr)).
+					Return(usdcMetadata, nil)
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(mockReservePair) // Cache hit
+
+				resp, err := http.Get(s.testServer.GetURL(
+					t,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount_decimal=1.0",
+				))
+				So(err, ShouldBeNil)
+				defer resp.Body.Close()
+				body, err := io.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+
+				Convey("Then the amount should be scaled by the token decimals", func() {
+					So(resp.StatusCode, ShouldEqual, http.StatusOK)
+					So(string(body), ShouldEqual, expectedOutput)
+					So(resp.Header.Get(utils.AmountOutDecimalHeader), ShouldEqual, "1974.316068")
+				})
+			})
+
+			Convey("When making a request with token symbols", func() {
+				s.erc20Client.EXPECT().
+					Metadata(gomock.Any(), common.HexToAddress(validSrcAddr)).
+					Return(&erc20.Metadata{Decimals: 18, Symbol: "WETH", Name: "Wrapped Ether"}, nil)
+				s.erc20Client.EXPECT().
+					Metadata(gomock.Any(), common.HexToAddress(validDstAddr)).
+					Return(usdcMetadata, nil)
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(mockReservePair) // Cache hit
+
+				var actualOutput string
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&src=WETH&dst=usdc&src_amount_decimal=1",
+					nil,
+					&actualOutput,
+				)
+
+				Convey("Then the symbols should be resolved from the token list", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(actualOutput, ShouldEqual, expectedOutput)
+				})
+			})
+
+			Convey("When making a request with an ambiguous token symbol", func() {
+				var errorResponse struct {
+					Error      string `json:"error"`
+					Candidates []struct {
+						Address string `json:"address"`
+						Name    string `json:"name"`
+					} `json:"candidates"`
+				}
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&src=WETH&dst=AMPL&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should list the candidates", func() {
+					So(errorResponse.Error, ShouldEqual, "ambiguous destination token symbol")
+					So(errorResponse.Candidates, ShouldHaveLength, 2)
+					So(errorResponse.Candidates[0].Name, ShouldEqual, "Ampleforth")
+					So(errorResponse.Candidates[1].Name, ShouldEqual, "Amp")
+				})
+			})
+
+			Convey("When making a request with an unknown token symbol", func() {
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&src=NOPE&dst="+validDstAddr+
+						"&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate invalid source address", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid source token address format")
+				})
+			})
 
-			expectedOutput := "1974316068"
+			Convey("When making a request with a decimal amount finer than the token decimals", func() {
+				s.erc20Client.EXPECT().
+					Metadata(gomock.Any(), common.HexToAddress(validDstAddr)).
+					Return(usdcMetadata, nil)
 
-			Convey("When making a valid estimation request", func() {
-				// Set up expectations for the cache miss and eth client call
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validDstAddr+
+						"&dst="+validSrcAddr+
+						"&src_amount_decimal=1.0000001",
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate invalid decimal amount", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid source decimal amount")
+				})
+			})
+
+			Convey("When making a request with both a raw and a decimal source amount", func() {
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount+
+						"&src_amount_decimal=1",
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate invalid query parameters", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid query parameters")
+				})
+			})
+
+			Convey("When making a request with a slippage tolerance", func() {
 				s.ethWssClient.EXPECT().
-					GetPair(gomock.Any(), validPoolAddr).
-					Return(nil) // Cache miss
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(mockReservePair) // Cache hit
 
-				s.ethClient.EXPECT().
-					UniV2ReservePair(gomock.Any(), validPoolAddr).
-					Return(mockReservePair, nil)
+				resp, err := http.Get(s.testServer.GetURL(
+					t,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount+
+						"&slippage_bps=50",
+				))
+				So(err, ShouldBeNil)
+				defer resp.Body.Close()
+				body, err := io.ReadAll(resp.Body)
+				So(err, ShouldBeNil)
+
+				Convey("Then the quote details should be reported in the headers", func() {
+					So(resp.StatusCode, ShouldEqual, http.StatusOK)
+					So(string(body), ShouldEqual, expectedOutput)
+					So(resp.Header.Get(utils.SpotPriceHeader), ShouldEqual, "2e-09")
+					So(resp.Header.Get(utils.ExecutionPriceHeader), ShouldEqual, "1.974316068e-09")
+					So(resp.Header.Get(utils.PriceImpactBpsHeader), ShouldEqual, "98")
+					So(resp.Header.Get(utils.LpFeeHeader), ShouldEqual, "3000000000000000")
+					So(resp.Header.Get(utils.MinOutHeader), ShouldEqual, "1964444487")
+				})
+			})
+
+			Convey("When making a request with an invalid slippage tolerance", func() {
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount+
+						"&slippage_bps=10001",
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
 
+				Convey("Then the response should indicate invalid slippage", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid slippage bps")
+				})
+			})
+
+			Convey("When making a JSON request with cached pool data", func() {
+				cachedPair := *mockReservePair
+				cachedPair.BlockNumber = 15000000
+				cachedPair.BlockHash = common.HexToHash("0xabc")
 				s.ethWssClient.EXPECT().
-					RegPair(gomock.Any(), validPoolAddr, gomock.Any()).
-					Return(nil)
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(&cachedPair) // Cache hit
+				s.erc20Client.EXPECT().
+					Metadata(gomock.Any(), common.HexToAddress(validDstAddr)).
+					Return(usdcMetadata, nil)
 
-				// Make the request and verify response
-				var actualOutput string
+				var actualOutput GetResponse
 				resCode := s.testServer.MustDo(
 					t,
 					http.MethodGet,
 					"/estimate?pool="+validPoolAddr+
 						"&src="+validSrcAddr+
 						"&dst="+validDstAddr+
-						"&src_amount="+validAmount,
+						"&src_amount="+validAmount+
+						"&slippage_bps=50"+
+						"&format=json",
 					nil,
 					&actualOutput,
 				)
 
-				Convey("Then the response should be successful with the estimated amount", func() {
+				Convey("Then the response should carry the quote with its pool and block", func() {
 					So(resCode, ShouldEqual, http.StatusOK)
-					So(actualOutput, ShouldEqual, expectedOutput)
+					So(actualOutput.AmountIn, ShouldEqual, validAmount)
+					So(actualOutput.AmountOut, ShouldEqual, expectedOutput)
+					So(actualOutput.MinOut, ShouldEqual, "1964444487")
+					So(actualOutput.AmountOutDecimal, ShouldEqual, "1974.316068")
+					So(actualOutput.Pool, ShouldEqual, validPoolAddr)
+					So(actualOutput.PoolType, ShouldEqual, "v2")
+					So(actualOutput.Dex, ShouldEqual, "uniswapv2")
+					So(actualOutput.Token0, ShouldEqual, validDstAddr)
+					So(actualOutput.Token1, ShouldEqual, validSrcAddr)
+					So(actualOutput.Reserve0, ShouldEqual, "200000000000")
+					So(actualOutput.Reserve1, ShouldEqual, "100000000000000000000")
+					So(actualOutput.SpotPrice, ShouldEqual, "2e-09")
+					So(*actualOutput.PriceImpactBps, ShouldEqual, 98)
+					So(actualOutput.BlockNumber, ShouldEqual, 15000000)
+					So(actualOutput.BlockHash, ShouldEqual, common.HexToHash("0xabc").Hex())
+					So(actualOutput.Source, ShouldEqual, "cache")
 				})
 			})
 
-			Convey("When making a request with cached pool data", func() {
-				// Set up expectation for cache hit
+			Convey("When making a JSON request through the Accept header", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(nil) // Cache miss
+				s.ethClient.EXPECT().
+					UniV2ReservePair(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(mockReservePair, nil)
+				s.ethClient.EXPECT().
+					UniV2PairMetadata(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(pairMetadata, nil)
 				s.ethWssClient.EXPECT().
-					GetPair(gomock.Any(), validPoolAddr).
-					Return(mockEthWssReservePair) // Cache hit
+					RegPool(gomock.Any(), common.HexToAddress(validPoolAddr), gomock.Any(), gomock.Any()).
+					Return(nil)
+				s.erc20Client.EXPECT().
+					Metadata(gomock.Any(), common.HexToAddress(validDstAddr)).
+					Return(usdcMetadata, nil)
 
-				// No call to ethClient.UniV2ReservePair expected
-				// No call to ethWssClient.RegPair expected
+				req, err := http.NewRequest(http.MethodGet, s.testServer.GetURL(
+					t,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount,
+				), nil)
+				So(err, ShouldBeNil)
+				req.Header.Set("Accept", "application/json")
+				resp, err := http.DefaultClient.Do(req)
+				So(err, ShouldBeNil)
+				defer resp.Body.Close()
+				var actualOutput GetResponse
+				So(json.NewDecoder(resp.Body).Decode(&actualOutput), ShouldBeNil)
 
-				// Make the request and verify response
-				var actualOutput string
+				Convey("Then the response should report a cold fetch", func() {
+					So(resp.StatusCode, ShouldEqual, http.StatusOK)
+					So(resp.Header.Get("Content-Type"), ShouldStartWith, "application/json")
+					So(actualOutput.AmountOut, ShouldEqual, expectedOutput)
+					So(actualOutput.MinOut, ShouldBeEmpty)
+					So(actualOutput.Source, ShouldEqual, "rpc")
+				})
+			})
+
+			Convey("When making a request with an invalid format", func() {
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount+
+						"&format=xml",
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate invalid format", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid format")
+				})
+			})
+
+			Convey("When making a historical request", func() {
+				historicalPair := *mockReservePair
+				historicalPair.BlockNumber = 13999980
+				historicalPair.BlockHash = common.HexToHash("0xdef")
+
+				// The live cache is bypassed: no GetPool or RegPool call expected
+				s.ethClient.EXPECT().
+					UniV2ReservePairAt(gomock.Any(), common.HexToAddress(validPoolAddr), uint64(14000000)).
+					Return(&historicalPair, nil)
+				s.ethClient.EXPECT().
+					UniV2PairMetadata(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(pairMetadata, nil)
+				s.erc20Client.EXPECT().
+					Metadata(gomock.Any(), common.HexToAddress(validDstAddr)).
+					Return(usdcMetadata, nil)
+
+				var actualOutput GetResponse
 				resCode := s.testServer.MustDo(
 					t,
 					http.MethodGet,
 					"/estimate?pool="+validPoolAddr+
 						"&src="+validSrcAddr+
 						"&dst="+validDstAddr+
-						"&src_amount="+validAmount,
+						"&src_amount="+validAmount+
+						"&block=14000000"+
+						"&format=json",
 					nil,
 					&actualOutput,
 				)
 
-				Convey("Then the response should be successful with the estimated amount from cache", func() {
+				Convey("Then the quote should be computed from the reserves at that block", func() {
 					So(resCode, ShouldEqual, http.StatusOK)
-					So(actualOutput, ShouldEqual, expectedOutput)
+					So(actualOutput.AmountOut, ShouldEqual, expectedOutput)
+					So(actualOutput.BlockNumber, ShouldEqual, 13999980)
+					So(actualOutput.BlockHash, ShouldEqual, common.HexToHash("0xdef").Hex())
+					So(actualOutput.Source, ShouldEqual, "rpc")
 				})
 			})
 
-			Convey("When making a request with missing pool address", func() {
-				// Make the request without pool parameter
+			Convey("When making a historical request past the chain head", func() {
+				s.ethClient.EXPECT().
+					UniV2ReservePairAt(gomock.Any(), common.HexToAddress(validPoolAddr), uint64(99000000)).
+					Return(nil, fmt.Errorf("%w: block 99000000, head 15000000", eth.ErrBlockNotReached))
+
 				var errorResponse map[string]string
 				s.testServer.MustDoAndMatchCode(
 					t,
 					http.MethodGet,
-					"/estimate?src="+validSrcAddr+
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
 						"&dst="+validDstAddr+
-						"&src_amount="+validAmount,
+						"&src_amount="+validAmount+
+						"&block=99000000",
 					nil,
 					&errorResponse,
 					http.StatusBadRequest,
 				)
 
-				Convey("Then the response should indicate pool address is required", func() {
-					So(errorResponse["error"], ShouldEqual, "invalid query parameters")
+				Convey("Then the response should indicate the block is not reached yet", func() {
+					So(errorResponse["error"], ShouldEqual, "block not reached yet")
 				})
 			})
 
-			Convey("When making a request with invalid pool address format", func() {
-				invalidPoolAddr := "0xinvalid"
+			Convey("When the node cannot serve the requested history", func() {
+				s.ethClient.EXPECT().
+					UniV2ReservePairAt(gomock.Any(), common.HexToAddress(validPoolAddr), uint64(10000000)).
+					Return(nil, fmt.Errorf("%w: missing trie node", eth.ErrHistoryUnavailable))
 
 				var errorResponse map[string]string
 				s.testServer.MustDoAndMatchCode(
 					t,
 					http.MethodGet,
-					"/estimate?pool="+invalidPoolAddr+
+					"/estimate?pool="+validPoolAddr+
 						"&src="+validSrcAddr+
 						"&dst="+validDstAddr+
-						"&src_amount="+validAmount,
+						"&src_amount="+validAmount+
+						"&block=10000000",
 					nil,
 					&errorResponse,
-					http.StatusBadRequest,
+					http.StatusServiceUnavailable,
 				)
 
-				Convey("Then the response should indicate invalid pool address format", func() {
-					So(errorResponse["error"], ShouldEqual, "invalid pool address format")
+				Convey("Then the response should indicate the history is unavailable", func() {
+					So(errorResponse["error"], ShouldEqual, "node cannot serve the history of the requested block")
 				})
 			})
 
-			Convey("When making a request with missing source token address", func() {
+			Convey("When making a request with an invalid block number", func() {
 				var errorResponse map[string]string
 				s.testServer.MustDoAndMatchCode(
 					t,
 					http.MethodGet,
 					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
 						"&dst="+validDstAddr+
-						"&src_amount="+validAmount,
+						"&src_amount="+validAmount+
+						"&block=latest",
 					nil,
 					&errorResponse,
 					http.StatusBadRequest,
 				)
 
-				Convey("Then the response should indicate source token address is required", func() {
-					So(errorResponse["error"], ShouldEqual, "invalid query parameters")
+				Convey("Then the response should indicate invalid block number", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid block number")
 				})
 			})
 
-			Convey("When making a request with invalid source token address", func() {
-				invalidSrcAddr := "0xinvalid"
-
+			Convey("When making a historical request for a pool type without history", func() {
 				var errorResponse map[string]string
 				s.testServer.MustDoAndMatchCode(
 					t,
 					http.MethodGet,
-					"/estimate?pool="+validPoolAddr+
-						"&src="+invalidSrcAddr+
+					"/estimate?pool_type=v3&pool="+validPoolAddr+
+						"&src="+validSrcAddr+
 						"&dst="+validDstAddr+
-						"&src_amount="+validAmount,
+						"&src_amount="+validAmount+
+						"&block=14000000",
 					nil,
 					&errorResponse,
 					http.StatusBadRequest,
 				)
 
-				Convey("Then the response should indicate invalid source token address format", func() {
-					So(errorResponse["error"], ShouldEqual, "invalid source token address format")
+				Convey("Then the response should indicate historical quotes are not supported", func() {
+					So(errorResponse["error"], ShouldEqual, "historical quotes are not supported for this pool type")
 				})
 			})
 
-			Convey("When making a request with missing destination token address", func() {
+			Convey("When making an exact-output request beyond the pool liquidity", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(mockReservePair) // Cache hit
+
 				var errorResponse map[string]string
 				s.testServer.MustDoAndMatchCode(
 					t,
 					http.MethodGet,
 					"/estimate?pool="+validPoolAddr+
 						"&src="+validSrcAddr+
-						"&src_amount="+validAmount,
+						"&dst="+validDstAddr+
+						"&dst_amount=200000000000",
 					nil,
 					&errorResponse,
 					http.StatusBadRequest,
 				)
 
-				Convey("Then the response should indicate destination token address is required", func() {
-					So(errorResponse["error"], ShouldEqual, "invalid query parameters")
+				Convey("Then the response should indicate insufficient pool liquidity", func() {
+					So(errorResponse["error"], ShouldEqual, "destination amount exceeds pool liquidity")
 				})
 			})
 
-			Convey("When making a request with invalid destination token address", func() {
-				invalidDstAddr := "0xinvalid"
+			Convey("When the cached pool has a zero reserve", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(&eth.ReservePair{Reserve0: big.NewInt(200000000000), Reserve1: new(big.Int)}) // Cache hit
 
 				var errorResponse map[string]string
 				s.testServer.MustDoAndMatchCode(
@@ -200,61 +1148,65 @@ func TestGet(t *testing.T) {
 					http.MethodGet,
 					"/estimate?pool="+validPoolAddr+
 						"&src="+validSrcAddr+
-						"&dst="+invalidDstAddr+
+						"&dst="+validDstAddr+
 						"&src_amount="+validAmount,
 					nil,
 					&errorResponse,
 					http.StatusBadRequest,
 				)
 
-				Convey("Then the response should indicate invalid destination token address format", func() {
-					So(errorResponse["error"], ShouldEqual, "invalid destination token address format")
+				Convey("Then the response should indicate the pool has no liquidity", func() {
+					So(errorResponse["error"], ShouldEqual, "pool has no liquidity")
 				})
 			})
 
-			Convey("When making a request with invalid pair address", func() {
-				// Using valid addresses but they don't form a valid pair
-				invalidPairPoolAddr := "0x1000000000000000000000000000000000000000"
+			Convey("When the cached pool has a reserve beyond uint112", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(&eth.ReservePair{
+						Reserve0: big.NewInt(200000000000),
+						Reserve1: new(big.Int).Lsh(big.NewInt(1), 112),
+					}) // Cache hit
 
 				var errorResponse map[string]string
 				s.testServer.MustDoAndMatchCode(
 					t,
 					http.MethodGet,
-					"/estimate?pool="+invalidPairPoolAddr+
+					"/estimate?pool="+validPoolAddr+
 						"&src="+validSrcAddr+
 						"&dst="+validDstAddr+
 						"&src_amount="+validAmount,
 					nil,
 					&errorResponse,
-					http.StatusBadRequest,
+					http.StatusBadGateway,
 				)
 
-				Convey("Then the response should indicate invalid Uniswap V2 pair address", func() {
-					So(errorResponse["error"], ShouldEqual, "invalid Uniswap V2 pair address")
+				Convey("Then the response should indicate invalid pool reserves", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid pool reserves")
 				})
 			})
 
-			Convey("When making a request with missing source amount", func() {
+			Convey("When making a request with both source and destination amounts", func() {
 				var errorResponse map[string]string
 				s.testServer.MustDoAndMatchCode(
 					t,
 					http.MethodGet,
 					"/estimate?pool="+validPoolAddr+
 						"&src="+validSrcAddr+
-						"&dst="+validDstAddr,
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount+
+						"&dst_amount="+expectedOutput,
 					nil,
 					&errorResponse,
 					http.StatusBadRequest,
 				)
 
-				Convey("Then the response should indicate source amount is required", func() {
+				Convey("Then the response should indicate invalid query parameters", func() {
 					So(errorResponse["error"], ShouldEqual, "invalid query parameters")
 				})
 			})
 
-			Convey("When making a request with invalid source amount", func() {
-				invalidAmount := "not-a-number"
-
+			Convey("When making a request with invalid destination amount", func() {
 				var errorResponse map[string]string
 				s.testServer.MustDoAndMatchCode(
 					t,
@@ -262,26 +1214,26 @@ func TestGet(t *testing.T) {
 					"/estimate?pool="+validPoolAddr+
 						"&src="+validSrcAddr+
 						"&dst="+validDstAddr+
-						"&src_amount="+invalidAmount,
+						"&dst_amount=not-a-number",
 					nil,
 					&errorResponse,
 					http.StatusBadRequest,
 				)
 
-				Convey("Then the response should indicate invalid source amount", func() {
-					So(errorResponse["error"], ShouldEqual, "invalid source amount")
+				Convey("Then the response should indicate invalid destination amount", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid destination amount")
 				})
 			})
 
 			Convey("When the eth client fails to retrieve reserves", func() {
 				// Set up expectations for the cache miss and eth client failure
 				s.ethWssClient.EXPECT().
-					GetPair(gomock.Any(), validPoolAddr).
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
 					Return(nil) // Cache miss
 
 				// Set up expectation for eth client failure
 				s.ethClient.EXPECT().
-					UniV2ReservePair(gomock.Any(), validPoolAddr).
+					UniV2ReservePair(gomock.Any(), common.HexToAddress(validPoolAddr)).
 					Return(nil, context.DeadlineExceeded)
 
 				var errorResponse map[string]string
@@ -302,25 +1254,90 @@ func TestGet(t *testing.T) {
 				})
 			})
 
+			Convey("When the pool address holds no pair", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(nil) // Cache miss
+				s.ethClient.EXPECT().
+					UniV2ReservePair(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(nil, fmt.Errorf("%w: getReserves returned no data", eth.ErrNotPair))
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate the pool is not a pair", func() {
+					So(errorResponse["error"], ShouldEqual, "pool is not a Uniswap V2 pair")
+				})
+			})
+
+			Convey("When the pool holds other tokens on chain", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(nil) // Cache miss
+				s.ethClient.EXPECT().
+					UniV2ReservePair(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(mockReservePair, nil)
+				s.ethClient.EXPECT().
+					UniV2PairMetadata(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(&eth.PairMetadata{
+						Token0: common.HexToAddress(validDstAddr),
+						Token1: common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"), // USDT
+					}, nil)
+				s.ethWssClient.EXPECT().
+					RegPool(gomock.Any(), common.HexToAddress(validPoolAddr), gomock.Any(), gomock.Any()).
+					Return(nil)
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate the tokens do not match the pool", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid pool tokens")
+				})
+			})
+
 			Convey("When multiple concurrent requests are made for the same pool", func() {
 				// First request will be a cache miss
 				s.ethWssClient.EXPECT().
-					GetPair(gomock.Any(), validPoolAddr).
+					GetPool(gomock.Any(), common.HexToAddress(validPoolAddr)).
 					Return(nil).
 					Times(5) // First request is a cache miss
 
 				// Set up expectation for the eth client call - should only be called ONCE
 				s.ethClient.EXPECT().
-					UniV2ReservePair(gomock.Any(), validPoolAddr).
-					DoAndReturn(func(ctx context.Context, poolAddr string) (*eth.ReservePair, error) {
+					UniV2ReservePair(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					DoAndReturn(func(ctx context.Context, poolAddr common.Address) (*eth.ReservePair, error) {
 						// Simulate some processing time
 						time.Sleep(100 * time.Millisecond)
 						return mockReservePair, nil
 					}).Times(1) // This is key - we expect only one call
+				s.ethClient.EXPECT().
+					UniV2PairMetadata(gomock.Any(), common.HexToAddress(validPoolAddr)).
+					Return(pairMetadata, nil).
+					Times(1)
 
 				// Register the pair in cache
 				s.ethWssClient.EXPECT().
-					RegPair(gomock.Any(), validPoolAddr, gomock.Any()).
+					RegPool(gomock.Any(), common.HexToAddress(validPoolAddr), gomock.Any(), gomock.Any()).
 					Return(nil).
 					Times(5) // Should only be called once after the first request
 
@@ -389,3 +1406,113 @@ func TestGet(t *testing.T) {
 		})
 	})
 }
+
+// nodeEthClient reads historical reserves through an eth client over a mocked
+// node, so that the errors of the node reach the controller as in production,
+// and leaves the other reads to the mocked eth client.
+type nodeEthClient struct {
+	*poolmodels.MockEthClient
+	node poolmodels.EthClient
+}
+
+func (c nodeEthClient) UniV2ReservePairAt(ctx context.Context, pairAddr common.Address, blockNumber uint64) (*eth.ReservePair, error) {
+	return c.node.UniV2ReservePairAt(ctx, pairAddr, blockNumber)
+}
+
+func TestGetHistoryFromNode(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given an estimate swap endpoint over a pruned node without the Sync log fallback", t, func() {
+			validPoolAddr := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"
+			validSrcAddr := "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2" // WETH
+			validDstAddr := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48" // USDC
+			validAmount := "1000000000000000000"                         // 1 ETH
+
+			gethClient := eth.NewMockGethClient(gomock.NewController(t))
+			ethClient := eth.New(eth.Config{BlockRangeSize: 100}, gethClient)
+			s.controller.models.Register(poolmodels.PoolTypeUniV2, poolmodels.NewUniV2(nodeEthClient{
+				MockEthClient: s.ethClient,
+				node:          ethClient,
+			}))
+
+			Convey("When the node misses the state of the requested block", func() {
+				gethClient.EXPECT().
+					BlockNumber(gomock.Any()).
+					Return(uint64(15000000), nil)
+				gethClient.EXPECT().
+					HeaderByNumber(gomock.Any(), big.NewInt(10000000)).
+					Return(&types.Header{Number: big.NewInt(10000000)}, nil)
+				gethClient.EXPECT().
+					CallContractAtHash(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("missing trie node 5a0f3e (path ) state 0x1d4a is not available"))
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool="+validPoolAddr+
+						"&src="+validSrcAddr+
+						"&dst="+validDstAddr+
+						"&src_amount="+validAmount+
+						"&block=10000000",
+					nil,
+					&errorResponse,
+					http.StatusServiceUnavailable,
+				)
+
+				Convey("Then the response should indicate the history is unavailable", func() {
+					So(errorResponse["error"], ShouldEqual, "node cannot serve the history of the requested block")
+				})
+			})
+		})
+	})
+}
+
+func TestFetchPoolState(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given one address read as two pool types", t, func() {
+			ctx := context.Background()
+			poolAddr := common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc")
+			ctrl := gomock.NewController(t)
+			v2Model := poolmodel.NewMockPoolModel(ctrl)
+			v3Model := poolmodel.NewMockPoolModel(ctrl)
+			v2Model.EXPECT().Name().Return("uniswapv2").AnyTimes()
+			v3Model.EXPECT().Name().Return("uniswapv3").AnyTimes()
+			v2State, v3State := &eth.ReservePair{}, &univ3.PoolState{}
+
+			Convey("When both are fetched at the same time", func() {
+				started, release := make(chan struct{}), make(chan struct{})
+				v2Model.EXPECT().
+					FetchState(gomock.Any(), poolAddr).
+					DoAndReturn(func(context.Context, common.Address) (poolmodel.State, error) {
+						close(started)
+						<-release
+						return v2State, nil
+					})
+				v3Model.EXPECT().
+					FetchState(gomock.Any(), poolAddr).
+					Return(v3State, nil)
+				s.ethWssClient.EXPECT().
+					RegPool(gomock.Any(), poolAddr, gomock.Any(), gomock.Any()).
+					Return(nil).
+					Times(2)
+
+				var v2Result poolmodel.State
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+					v2Result, _ = s.controller.fetchPoolState(ctx, poolAddr, v2Model)
+				}()
+				<-started
+				v3Result, err := s.controller.fetchPoolState(ctx, poolAddr, v3Model)
+				close(release)
+				<-done
+
+				Convey("Then each should be fetched by its own model", func() {
+					So(err, ShouldBeNil)
+					So(v3Result, ShouldEqual, v3State)
+					So(v2Result, ShouldEqual, v2State)
+				})
+			})
+		})
+	})
+}
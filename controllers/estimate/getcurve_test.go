@@ -0,0 +1,153 @@
+package estimate
+
+import (
+	"errors"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/WangWilly/swap-estimation/pkgs/clients/curve"
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetCurve(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given an estimate swap endpoint with a Curve pool", t, func() {
+			poolAddr := "0xbEbc44782C7dB0a1A60Cb6fe97d0b483032FF1C7"
+			daiAddr := "0x6B175474E89094C44Da98b954EedeAC495271d0F"
+			usdcAddr := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+			usdtAddr := "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+
+			poolState := &curve.PoolState{
+				A:   big.NewInt(2000),
+				Fee: big.NewInt(4000000),
+				Coins: []common.Address{
+					common.HexToAddress(daiAddr),
+					common.HexToAddress(usdcAddr),
+					common.HexToAddress(usdtAddr),
+				},
+				Balances: []*big.Int{
+					new(big.Int).Exp(big.NewInt(10), big.NewInt(24), nil),
+					big.NewInt(1000000000000),
+					big.NewInt(1000000000000),
+				},
+				Decimals: []uint8{18, 6, 6},
+			}
+
+			Convey("When making a valid exact input request", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(nil) // Cache miss
+				s.curveClient.EXPECT().
+					PoolState(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(poolState, nil)
+				s.ethWssClient.EXPECT().
+					RegPool(gomock.Any(), common.HexToAddress(poolAddr), gomock.Any(), poolState).
+					Return(nil)
+
+				var actualOutput string
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodGet,
+					"/estimate?pool_type=curve&pool="+poolAddr+
+						"&src="+usdcAddr+
+						"&dst="+daiAddr+
+						"&src_amount=1000000000",
+					nil,
+					&actualOutput,
+				)
+
+				Convey("Then the response should be the StableSwap output amount", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(actualOutput, ShouldEqual, "999599500449525711807")
+				})
+			})
+
+			Convey("When making a valid exact output request", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(nil) // Cache miss
+				s.curveClient.EXPECT().
+					PoolState(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(poolState, nil)
+				s.ethWssClient.EXPECT().
+					RegPool(gomock.Any(), common.HexToAddress(poolAddr), gomock.Any(), poolState).
+					Return(nil)
+
+				var actualOutput string
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodGet,
+					"/estimate?pool_type=curve&pool="+poolAddr+
+						"&src="+usdcAddr+
+						"&dst="+daiAddr+
+						"&dst_amount=1000000000000000000000",
+					nil,
+					&actualOutput,
+				)
+
+				Convey("Then the response should be the required input amount", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(actualOutput, ShouldEqual, "1000400661")
+				})
+			})
+
+			Convey("When the tokens do not belong to the pool", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(nil) // Cache miss
+				s.curveClient.EXPECT().
+					PoolState(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(poolState, nil)
+				s.ethWssClient.EXPECT().
+					RegPool(gomock.Any(), common.HexToAddress(poolAddr), gomock.Any(), poolState).
+					Return(nil)
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool_type=curve&pool="+poolAddr+
+						"&src="+usdcAddr+
+						"&dst=0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"+
+						"&src_amount=1000",
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate the tokens are invalid", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid pool tokens")
+				})
+			})
+
+			Convey("When the pool state cannot be read", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(nil) // Cache miss
+				s.curveClient.EXPECT().
+					PoolState(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(nil, errors.New("execution reverted"))
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool_type=curve&pool="+poolAddr+
+						"&src="+usdcAddr+
+						"&dst="+daiAddr+
+						"&src_amount=1000",
+					nil,
+					&errorResponse,
+					http.StatusInternalServerError,
+				)
+
+				Convey("Then the response should indicate a server error", func() {
+					So(errorResponse["error"], ShouldEqual, "failed to get pool state")
+				})
+			})
+		})
+	})
+}
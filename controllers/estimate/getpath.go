@@ -0,0 +1,255 @@
+package estimate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/WangWilly/swap-estimation/controllers/estimate/ctrlutils"
+	"github.com/WangWilly/swap-estimation/controllers/estimate/poolmodels"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/eth"
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+type GetPathQuery struct {
+	PathStr      string `form:"path" binding:"required"`
+	SrcAmountStr string `form:"src_amount" binding:"required"`
+	DexName      string `form:"dex"`
+}
+
+type HopQuote struct {
+	PoolAddr      string `json:"pool"`
+	Dex           string `json:"dex"`
+	FeeBps        uint64 `json:"fee_bps"`
+	SrcTokenAddr  string `json:"src"`
+	DestTokenAddr string `json:"dst"`
+	AmountIn      string `json:"amount_in"`
+	AmountOut     string `json:"amount_out"`
+}
+
+type PathQuote struct {
+	Path      []string   `json:"path"`
+	Hops      []HopQuote `json:"hops"`
+	AmountOut string     `json:"amount_out"`
+
+	amountOut *big.Int
+}
+
+var (
+	errGetHopReservePair = errors.New("failed to get reserve pair")
+	errCalHopOutAmount   = errors.New("failed to calculate output amount")
+)
+
+// hopError is the error of the hop of a path, by index, whose pool could not
+// be read.
+type hopError struct {
+	Hop int
+	Err error
+}
+
+func (e *hopError) Error() string {
+	return fmt.Sprintf("hop %d: %v", e.Hop, e.Err)
+}
+
+func (e *hopError) Unwrap() error {
+	return e.Err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (c *Controller) GetPath(ctx *gin.Context) {
+	logger := log.Ctx(ctx.Request.Context())
+	logger.Debug().Msg("Received path estimate request")
+
+	q := &GetPathQuery{}
+	if err := ctx.ShouldBindQuery(q); err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to bind query parameters")
+		ctx.JSON(400, gin.H{"error": "invalid query parameters"})
+		return
+	}
+
+	path := strings.Split(q.PathStr, ",")
+	if len(path) < 2 {
+		logger.Error().Msg("Path must contain at least two tokens")
+		ctx.JSON(400, gin.H{"error": "path must contain at least two tokens"})
+		return
+	}
+	tokens := make([]common.Address, len(path))
+	for i, token := range path {
+		tokenAddr, candidates, err := c.parseToken(token)
+		if errors.Is(err, errAmbiguousSymbol) {
+			logger.Error().Str("symbol", token).Msg("Ambiguous token symbol in path")
+			ctx.JSON(400, gin.H{"error": "ambiguous token symbol in path", "candidates": candidates})
+			return
+		}
+		if errors.Is(err, ctrlutils.ErrInvalidAddrChecksum) {
+			logger.Error().
+				Str("token_address", token).
+				Msg("Invalid token address checksum in path")
+			ctx.JSON(400, gin.H{"error": "invalid token address checksum in path"})
+			return
+		}
+		if err != nil {
+			logger.Error().
+				Str("token_address", token).
+				Msg("Invalid token address format in path")
+			ctx.JSON(400, gin.H{"error": "invalid token address format in path"})
+			return
+		}
+		if i > 0 && tokens[i-1] == tokenAddr {
+			logger.Error().
+				Str("token_address", tokenAddr.Hex()).
+				Msg("Consecutive identical tokens in path")
+			ctx.JSON(400, gin.H{"error": "path contains consecutive identical tokens"})
+			return
+		}
+		tokens[i] = tokenAddr
+	}
+
+	srcAmount, ok := new(big.Int).SetString(q.SrcAmountStr, 10)
+	if !ok {
+		logger.Error().Msg("Invalid source amount")
+		ctx.JSON(400, gin.H{"error": "invalid source amount"})
+		return
+	}
+
+	// Pools are derived from the first registered DEX unless one is named
+	dex := c.cfg.Dexes[0]
+	if q.DexName != "" {
+		if dex, ok = c.cfg.Dexes.Find(q.DexName); !ok {
+			logger.Error().Str("dex", q.DexName).Msg("Unknown DEX")
+			ctx.JSON(400, gin.H{"error": "unknown dex"})
+			return
+		}
+	}
+
+	////////////////////////////////////////////////////////////////////////////
+
+	pools := make([]common.Address, 0, len(tokens)-1)
+	for i := 0; i < len(tokens)-1; i++ {
+		poolAddr := dex.PairAddr(tokens[i], tokens[i+1])
+		c.rememberPairTokens(poolAddr, tokens[i], tokens[i+1], dex.Name)
+		pools = append(pools, poolAddr)
+	}
+
+	quote, err := c.quotePath(ctx.Request.Context(), tokens, pools, srcAmount)
+	var hopErr *hopError
+	if errors.As(err, &hopErr) && errors.Is(err, errPoolNotDeployed) {
+		ctx.JSON(404, gin.H{"error": "pool of hop is not deployed", "hop": hopErr.Hop})
+		return
+	}
+	if errors.As(err, &hopErr) && errors.Is(err, eth.ErrNotPair) {
+		ctx.JSON(400, gin.H{"error": "pool of hop is not a Uniswap V2 pair", "hop": hopErr.Hop})
+		return
+	}
+	if errors.Is(err, errGetHopReservePair) {
+		ctx.JSON(500, gin.H{"error": "failed to get reserve pair"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(500, gin.H{"error": "failed to calculate output amount"})
+		return
+	}
+
+	ctx.JSON(200, quote)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// quotePath chains the Uniswap V2 model over the pools of a path, fetching the
+// reserves of each hop through derivedPoolState, so that a pair that is not
+// deployed is not read. A hop whose reserves cannot be read fails with a
+// hopError.
+func (c *Controller) quotePath(
+	ctx context.Context,
+	path []common.Address,
+	pools []common.Address,
+	srcAmount *big.Int,
+) (*PathQuote, error) {
+	logger := log.Ctx(ctx)
+
+	model, ok := c.models.Get(poolmodels.PoolTypeUniV2)
+	if !ok {
+		return nil, fmt.Errorf("%w: no %s pool model", errGetHopReservePair, poolmodels.PoolTypeUniV2)
+	}
+
+	reservePairs := make(map[common.Address]poolmodel.State, len(pools))
+	for i, poolAddr := range pools {
+		// Reserves go through the same cache and singleflight group as Get
+		reservePair, _, err := c.derivedPoolState(ctx, poolAddr, model)
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Int("hop", i).
+				Str("pool_address", poolAddr.Hex()).
+				Msg("Failed to get Uniswap V2 reserve pair")
+			return nil, &hopError{Hop: i, Err: fmt.Errorf("%w: %w", errGetHopReservePair, err)}
+		}
+		reservePairs[poolAddr] = reservePair
+	}
+
+	return c.quotePathWithReserves(model, path, pools, reservePairs, srcAmount)
+}
+
+func (c *Controller) quotePathWithReserves(
+	model poolmodel.PoolModel,
+	path []common.Address,
+	pools []common.Address,
+	reservePairs map[common.Address]poolmodel.State,
+	srcAmount *big.Int,
+) (*PathQuote, error) {
+	hops := make([]HopQuote, 0, len(pools))
+	amountIn := srcAmount
+	for i, poolAddr := range pools {
+		srcTokenAddr, dstTokenAddr := path[i], path[i+1]
+
+		reservePair, ok := reservePairs[poolAddr]
+		if !ok || reservePair == nil {
+			return nil, errGetHopReservePair
+		}
+
+		pairEdge, _ := c.lookupPairTokens(poolAddr)
+		feeBps := c.resolveFeeBps(poolAddr, pairEdge.Dex)
+
+		amountOut, err := model.AmountOut(reservePair, poolmodel.Swap{
+			Pool:   poolAddr,
+			Src:    srcTokenAddr,
+			Dst:    dstTokenAddr,
+			FeeBps: feeBps,
+		}, amountIn)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errCalHopOutAmount, err)
+		}
+
+		hops = append(hops, HopQuote{
+			PoolAddr:      poolAddr.Hex(),
+			Dex:           pairEdge.Dex,
+			FeeBps:        feeBps,
+			SrcTokenAddr:  srcTokenAddr.Hex(),
+			DestTokenAddr: dstTokenAddr.Hex(),
+			AmountIn:      amountIn.String(),
+			AmountOut:     amountOut.String(),
+		})
+		amountIn = amountOut
+	}
+
+	pathStrs := make([]string, len(path))
+	for i, tokenAddr := range path {
+		pathStrs[i] = tokenAddr.Hex()
+	}
+	return &PathQuote{
+		Path:      pathStrs,
+		Hops:      hops,
+		AmountOut: amountIn.String(),
+		amountOut: amountIn,
+	}, nil
+}
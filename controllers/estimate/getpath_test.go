@@ -0,0 +1,272 @@
+package estimate
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/WangWilly/swap-estimation/controllers/estimate/ctrlutils"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/eth"
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetPath(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given a path estimate endpoint", t, func() {
+			// Setup test data
+			wethAddr := "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+			usdcAddr := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+			usdtAddr := "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+			validAmount := "1000000000000000000" // 1 ETH
+
+			wethUsdcPoolAddr := ctrlutils.ComputeUniV2PairAddr(common.HexToAddress(wethAddr), common.HexToAddress(usdcAddr))
+			usdcUsdtPoolAddr := ctrlutils.ComputeUniV2PairAddr(common.HexToAddress(usdcAddr), common.HexToAddress(usdtAddr))
+
+			wethUsdcPair := &eth.ReservePair{
+				Reserve0: big.NewInt(200000000000), // 200,000 USDC (6 decimals)
+				Reserve1: new(big.Int),             // 100 ETH (18 decimals)
+			}
+			wethUsdcPair.Reserve1.SetString("100000000000000000000", 10)
+
+			usdcUsdtPair := &eth.ReservePair{
+				Reserve0: big.NewInt(1000000000000), // 1,000,000 USDC
+				Reserve1: big.NewInt(1000000000000), // 1,000,000 USDT
+			}
+
+			Convey("When making a valid two-hop path request", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), wethUsdcPoolAddr).
+					Return(wethUsdcPair)
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), usdcUsdtPoolAddr).
+					Return(usdcUsdtPair)
+
+				var resp PathQuote
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodGet,
+					"/estimate/path?path="+wethAddr+","+usdcAddr+","+usdtAddr+
+						"&src_amount="+validAmount,
+					nil,
+					&resp,
+				)
+
+				Convey("Then the response should chain the per-hop amounts", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(resp.Path, ShouldResemble, []string{wethAddr, usdcAddr, usdtAddr})
+					So(resp.Hops, ShouldHaveLength, 2)
+
+					So(resp.Hops[0].PoolAddr, ShouldEqual, wethUsdcPoolAddr.Hex())
+					So(resp.Hops[0].Dex, ShouldEqual, "uniswapv2")
+					So(resp.Hops[0].FeeBps, ShouldEqual, 30)
+					So(resp.Hops[0].AmountIn, ShouldEqual, validAmount)
+					So(resp.Hops[0].AmountOut, ShouldEqual, "1974316068")
+
+					So(resp.Hops[1].PoolAddr, ShouldEqual, usdcUsdtPoolAddr.Hex())
+					So(resp.Hops[1].AmountIn, ShouldEqual, "1974316068")
+					So(resp.Hops[1].AmountOut, ShouldEqual, "1964526160")
+
+					So(resp.AmountOut, ShouldEqual, "1964526160")
+				})
+			})
+
+			Convey("When making a path request with token symbols", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), wethUsdcPoolAddr).
+					Return(wethUsdcPair)
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), usdcUsdtPoolAddr).
+					Return(usdcUsdtPair)
+
+				var resp PathQuote
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodGet,
+					"/estimate/path?path=WETH,USDC,USDT&src_amount="+validAmount,
+					nil,
+					&resp,
+				)
+
+				Convey("Then the path should be resolved to addresses", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(resp.Path, ShouldResemble, []string{wethAddr, usdcAddr, usdtAddr})
+					So(resp.AmountOut, ShouldEqual, "1964526160")
+				})
+			})
+
+			Convey("When making a path request with an ambiguous token symbol", func() {
+				var errorResponse map[string]any
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate/path?path=WETH,AMPL&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should list the candidates", func() {
+					So(errorResponse["error"], ShouldEqual, "ambiguous token symbol in path")
+					So(errorResponse["candidates"], ShouldHaveLength, 2)
+				})
+			})
+
+			Convey("When making a request with a single-token path", func() {
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate/path?path="+wethAddr+"&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate the path is too short", func() {
+					So(errorResponse["error"], ShouldEqual, "path must contain at least two tokens")
+				})
+			})
+
+			Convey("When making a request with an invalid token in the path", func() {
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate/path?path="+wethAddr+",0xinvalid&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate an invalid token address", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid token address format in path")
+				})
+			})
+
+			Convey("When making a request with consecutive identical tokens", func() {
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate/path?path="+wethAddr+","+wethAddr+"&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should reject the path", func() {
+					So(errorResponse["error"], ShouldEqual, "path contains consecutive identical tokens")
+				})
+			})
+
+			Convey("When making a request for an unknown DEX", func() {
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate/path?path="+wethAddr+","+usdcAddr+"&src_amount="+validAmount+"&dex=unknown",
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate the DEX is unknown", func() {
+					So(errorResponse["error"], ShouldEqual, "unknown dex")
+				})
+			})
+
+			Convey("When a hop's reserves cannot be retrieved", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), wethUsdcPoolAddr).
+					Return(wethUsdcPair)
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), usdcUsdtPoolAddr).
+					Return(nil) // Cache miss
+				s.ethClient.EXPECT().
+					ContractExists(gomock.Any(), usdcUsdtPoolAddr).
+					Return(true, nil)
+				s.ethClient.EXPECT().
+					UniV2ReservePair(gomock.Any(), usdcUsdtPoolAddr).
+					Return(nil, context.DeadlineExceeded)
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate/path?path="+wethAddr+","+usdcAddr+","+usdtAddr+
+						"&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusInternalServerError,
+				)
+
+				Convey("Then the response should indicate failure to get reserves", func() {
+					So(errorResponse["error"], ShouldEqual, "failed to get reserve pair")
+				})
+			})
+
+			Convey("When a hop's pair is not deployed", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), wethUsdcPoolAddr).
+					Return(wethUsdcPair)
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), usdcUsdtPoolAddr).
+					Return(nil) // Cache miss
+				s.ethClient.EXPECT().
+					ContractExists(gomock.Any(), usdcUsdtPoolAddr).
+					Return(false, nil)
+
+				// No call to ethClient.UniV2ReservePair expected
+
+				var errorResponse map[string]any
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate/path?path="+wethAddr+","+usdcAddr+","+usdtAddr+
+						"&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusNotFound,
+				)
+
+				Convey("Then the response should name the hop", func() {
+					So(errorResponse["error"], ShouldEqual, "pool of hop is not deployed")
+					So(errorResponse["hop"], ShouldEqual, 1)
+				})
+			})
+
+			Convey("When a hop's address is not a pair", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), wethUsdcPoolAddr).
+					Return(nil) // Cache miss
+				s.ethClient.EXPECT().
+					ContractExists(gomock.Any(), wethUsdcPoolAddr).
+					Return(true, nil)
+				s.ethClient.EXPECT().
+					UniV2ReservePair(gomock.Any(), wethUsdcPoolAddr).
+					Return(nil, eth.ErrNotPair)
+
+				// The second hop is not read
+
+				var errorResponse map[string]any
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate/path?path="+wethAddr+","+usdcAddr+","+usdtAddr+
+						"&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should name the hop", func() {
+					So(errorResponse["error"], ShouldEqual, "pool of hop is not a Uniswap V2 pair")
+					So(errorResponse["hop"], ShouldEqual, 0)
+				})
+			})
+		})
+	})
+}
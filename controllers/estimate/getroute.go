@@ -0,0 +1,184 @@
+package estimate
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/WangWilly/swap-estimation/controllers/estimate/ctrlutils"
+	"github.com/WangWilly/swap-estimation/controllers/estimate/poolmodels"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+type GetRouteQuery struct {
+	SrcTokenAddr  string `form:"src" binding:"required"`
+	DestTokenAddr string `form:"dst" binding:"required"`
+	SrcAmountStr  string `form:"src_amount" binding:"required"`
+	MaxHops       int    `form:"max_hops"`
+}
+
+type GetRouteResponse struct {
+	Best         PathQuote   `json:"best"`
+	Alternatives []PathQuote `json:"alternatives"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (c *Controller) GetRoute(ctx *gin.Context) {
+	logger := log.Ctx(ctx.Request.Context())
+	logger.Debug().Msg("Received route request")
+
+	q := &GetRouteQuery{}
+	if err := ctx.ShouldBindQuery(q); err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to bind query parameters")
+		ctx.JSON(400, gin.H{"error": "invalid query parameters"})
+		return
+	}
+
+	srcTokenAddr, candidates, err := c.parseToken(q.SrcTokenAddr)
+	if err != nil {
+		writeTokenError(ctx, err, "source", q.SrcTokenAddr, candidates)
+		return
+	}
+
+	destTokenAddr, candidates, err := c.parseToken(q.DestTokenAddr)
+	if err != nil {
+		writeTokenError(ctx, err, "destination", q.DestTokenAddr, candidates)
+		return
+	}
+
+	if srcTokenAddr == destTokenAddr {
+		logger.Error().Msg("Source and destination tokens are the same")
+		ctx.JSON(400, gin.H{"error": "source and destination tokens must differ"})
+		return
+	}
+
+	srcAmount, ok := new(big.Int).SetString(q.SrcAmountStr, 10)
+	if !ok || srcAmount.Sign() < 0 {
+		logger.Error().Msg("Invalid source amount")
+		ctx.JSON(400, gin.H{"error": "invalid source amount"})
+		return
+	}
+
+	maxHops := c.cfg.RouteMaxHops
+	if q.MaxHops < 0 {
+		logger.Error().Int("max_hops", q.MaxHops).Msg("Invalid max hops")
+		ctx.JSON(400, gin.H{"error": "invalid max hops"})
+		return
+	}
+	if q.MaxHops > 0 && q.MaxHops < maxHops {
+		maxHops = q.MaxHops
+	}
+
+	////////////////////////////////////////////////////////////////////////////
+
+	reqCtx := ctx.Request.Context()
+	edges := c.routeEdges(reqCtx, srcTokenAddr, destTokenAddr)
+	routes := ctrlutils.FindRoutes(edges, srcTokenAddr, destTokenAddr, maxHops)
+	logger.Debug().
+		Int("edge_count", len(edges)).
+		Int("route_count", len(routes)).
+		Msg("Candidate routes found")
+
+	// Fetch the reserves of every candidate pool once, the uncached ones at
+	// one block; derived pairs that are not deployed are left out before any
+	// read, and every route through them is skipped
+	model, ok := c.models.Get(poolmodels.PoolTypeUniV2)
+	if !ok {
+		logger.Error().Msg("No Uniswap V2 pool model registered")
+		ctx.JSON(500, gin.H{"error": "failed to get reserve pair"})
+		return
+	}
+	pools := make([]common.Address, 0)
+	seenPools := make(map[common.Address]bool)
+	for _, route := range routes {
+		for _, poolAddr := range route.Pools {
+			if !seenPools[poolAddr] {
+				seenPools[poolAddr] = true
+				pools = append(pools, poolAddr)
+			}
+		}
+	}
+	reservePairs := c.batchPoolStates(reqCtx, pools, model, true)
+
+	quotes := make([]PathQuote, 0, len(routes))
+	for _, route := range routes {
+		quote, err := c.quotePathWithReserves(model, route.Tokens, route.Pools, reservePairs, srcAmount)
+		if err != nil {
+			continue
+		}
+		quotes = append(quotes, *quote)
+	}
+
+	if len(quotes) == 0 {
+		logger.Error().Msg("No route found")
+		ctx.JSON(404, gin.H{"error": "no route found"})
+		return
+	}
+
+	// Highest output first, then the shortest route
+	sort.SliceStable(quotes, func(i, j int) bool {
+		if cmp := quotes[i].amountOut.Cmp(quotes[j].amountOut); cmp != 0 {
+			return cmp > 0
+		}
+		return len(quotes[i].Hops) < len(quotes[j].Hops)
+	})
+
+	alternatives := quotes[1:]
+	if len(alternatives) > c.cfg.RouteMaxAlternatives {
+		alternatives = alternatives[:c.cfg.RouteMaxAlternatives]
+	}
+
+	ctx.JSON(200, GetRouteResponse{
+		Best:         quotes[0],
+		Alternatives: alternatives,
+	})
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// routeEdges builds the token graph from the pools tracked by ethwss plus the
+// pairs derived on every registered DEX between the source, destination and
+// base tokens.
+func (c *Controller) routeEdges(ctx context.Context, srcTokenAddr, dstTokenAddr common.Address) []ctrlutils.PairEdge {
+	edges := []ctrlutils.PairEdge{}
+
+	for _, poolAddr := range c.ethWssClient.ListPools(ctx) {
+		if edge, ok := c.lookupPairTokens(poolAddr); ok {
+			edge.PoolAddr = poolAddr
+			edges = append(edges, edge)
+		}
+	}
+
+	hubTokens := []common.Address{srcTokenAddr, dstTokenAddr}
+	seenTokens := map[common.Address]bool{srcTokenAddr: true, dstTokenAddr: true}
+	for _, tokenAddr := range c.cfg.RouteBaseTokens {
+		if seenTokens[tokenAddr] {
+			continue
+		}
+		seenTokens[tokenAddr] = true
+		hubTokens = append(hubTokens, tokenAddr)
+	}
+	for _, dex := range c.cfg.Dexes {
+		for i := range hubTokens {
+			for j := i + 1; j < len(hubTokens); j++ {
+				poolAddr := dex.PairAddr(hubTokens[i], hubTokens[j])
+				c.rememberPairTokens(poolAddr, hubTokens[i], hubTokens[j], dex.Name)
+				edges = append(edges, ctrlutils.PairEdge{
+					PoolAddr: poolAddr,
+					TokenA:   hubTokens[i],
+					TokenB:   hubTokens[j],
+					Dex:      dex.Name,
+				})
+			}
+		}
+	}
+
+	return edges
+}
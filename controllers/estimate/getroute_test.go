@@ -0,0 +1,184 @@
+package estimate
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/WangWilly/swap-estimation/controllers/estimate/ctrlutils"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/eth"
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetRoute(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given a route finding endpoint", t, func() {
+			// Setup test data
+			wethAddr := "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+			usdcAddr := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+			usdtAddr := "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+			validAmount := "1000000000000000000" // 1 ETH
+
+			// Use USDC as the only base token to keep the graph small
+			s.controller.cfg.RouteBaseTokens = ctrlutils.AddrList{common.HexToAddress(usdcAddr)}
+			s.controller.cfg.RouteMaxHops = 2
+			s.controller.cfg.RouteMaxAlternatives = 3
+
+			wethUsdtPoolAddr := ctrlutils.ComputeUniV2PairAddr(common.HexToAddress(wethAddr), common.HexToAddress(usdtAddr))
+			wethUsdcPoolAddr := ctrlutils.ComputeUniV2PairAddr(common.HexToAddress(wethAddr), common.HexToAddress(usdcAddr))
+			usdcUsdtPoolAddr := ctrlutils.ComputeUniV2PairAddr(common.HexToAddress(usdcAddr), common.HexToAddress(usdtAddr))
+
+			wethUsdtPair := &eth.ReservePair{
+				Reserve0: new(big.Int),             // 100 ETH (18 decimals)
+				Reserve1: big.NewInt(150000000000), // 150,000 USDT (6 decimals)
+			}
+			wethUsdtPair.Reserve0.SetString("100000000000000000000", 10)
+
+			wethUsdcPair := &eth.ReservePair{
+				Reserve0: big.NewInt(200000000000), // 200,000 USDC (6 decimals)
+				Reserve1: new(big.Int),             // 100 ETH (18 decimals)
+			}
+			wethUsdcPair.Reserve1.SetString("100000000000000000000", 10)
+
+			usdcUsdtPair := &eth.ReservePair{
+				Reserve0: big.NewInt(1000000000000), // 1,000,000 USDC
+				Reserve1: big.NewInt(1000000000000), // 1,000,000 USDT
+			}
+
+			routeURL := "/route?src=" + wethAddr + "&dst=" + usdtAddr + "&src_amount=" + validAmount
+
+			Convey("When every candidate pool exists", func() {
+				s.ethWssClient.EXPECT().ListPools(gomock.Any()).Return([]common.Address{})
+				s.ethWssClient.EXPECT().
+					SnapshotPools(gomock.Any(), gomock.InAnyOrder([]common.Address{wethUsdtPoolAddr, wethUsdcPoolAddr, usdcUsdtPoolAddr})).
+					Return(map[common.Address]poolmodel.State{
+						wethUsdtPoolAddr: wethUsdtPair,
+						wethUsdcPoolAddr: wethUsdcPair,
+						usdcUsdtPoolAddr: usdcUsdtPair,
+					})
+
+				var resp GetRouteResponse
+				resCode := s.testServer.MustDo(t, http.MethodGet, routeURL, nil, &resp)
+
+				Convey("Then the best route should go through the base token", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(resp.Best.Path, ShouldResemble, []string{wethAddr, usdcAddr, usdtAddr})
+					So(resp.Best.Hops, ShouldHaveLength, 2)
+					So(resp.Best.Hops[0].PoolAddr, ShouldEqual, wethUsdcPoolAddr.Hex())
+					So(resp.Best.Hops[0].AmountOut, ShouldEqual, "1974316068")
+					So(resp.Best.Hops[1].PoolAddr, ShouldEqual, usdcUsdtPoolAddr.Hex())
+					So(resp.Best.AmountOut, ShouldEqual, "1964526160")
+				})
+
+				Convey("Then the direct route should be listed as the runner-up", func() {
+					So(resp.Alternatives, ShouldHaveLength, 1)
+					So(resp.Alternatives[0].Hops, ShouldHaveLength, 1)
+					So(resp.Alternatives[0].Hops[0].PoolAddr, ShouldEqual, wethUsdtPoolAddr.Hex())
+					So(resp.Alternatives[0].AmountOut, ShouldEqual, "1480737051")
+				})
+			})
+
+			Convey("When a derived pool does not exist", func() {
+				s.ethWssClient.EXPECT().ListPools(gomock.Any()).Return([]common.Address{})
+				s.ethWssClient.EXPECT().
+					SnapshotPools(gomock.Any(), gomock.InAnyOrder([]common.Address{wethUsdtPoolAddr, wethUsdcPoolAddr, usdcUsdtPoolAddr})).
+					Return(map[common.Address]poolmodel.State{
+						wethUsdcPoolAddr: wethUsdcPair,
+						usdcUsdtPoolAddr: usdcUsdtPair,
+					})
+				s.ethClient.EXPECT().
+					ContractExists(gomock.Any(), wethUsdtPoolAddr).
+					Return(false, nil)
+
+				// No reserve read expected for the missing pool
+
+				var resp GetRouteResponse
+				resCode := s.testServer.MustDo(t, http.MethodGet, routeURL, nil, &resp)
+
+				Convey("Then routes through that pool should be skipped", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(resp.Best.AmountOut, ShouldEqual, "1964526160")
+					So(resp.Alternatives, ShouldBeEmpty)
+				})
+			})
+
+			Convey("When the hop limit only allows direct routes", func() {
+				s.ethWssClient.EXPECT().ListPools(gomock.Any()).Return([]common.Address{})
+				s.ethWssClient.EXPECT().
+					SnapshotPools(gomock.Any(), []common.Address{wethUsdtPoolAddr}).
+					Return(map[common.Address]poolmodel.State{wethUsdtPoolAddr: wethUsdtPair})
+
+				var resp GetRouteResponse
+				resCode := s.testServer.MustDo(t, http.MethodGet, routeURL+"&max_hops=1", nil, &resp)
+
+				Convey("Then only the direct route should be quoted", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(resp.Best.Hops, ShouldHaveLength, 1)
+					So(resp.Best.AmountOut, ShouldEqual, "1480737051")
+				})
+			})
+
+			Convey("When no candidate route can be quoted", func() {
+				s.ethWssClient.EXPECT().ListPools(gomock.Any()).Return([]common.Address{})
+				s.ethWssClient.EXPECT().
+					SnapshotPools(gomock.Any(), gomock.Any()).
+					Return(map[common.Address]poolmodel.State{})
+				s.ethClient.EXPECT().
+					ContractExists(gomock.Any(), gomock.Any()).
+					Return(true, nil).
+					Times(3)
+				s.ethClient.EXPECT().
+					UniV2ReservePairs(gomock.Any(), gomock.Any()).
+					Return(nil, context.DeadlineExceeded)
+				s.ethClient.EXPECT().
+					UniV2ReservePair(gomock.Any(), gomock.Any()).
+					Return(nil, context.DeadlineExceeded).
+					Times(3)
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(t, http.MethodGet, routeURL, nil, &errorResponse, http.StatusNotFound)
+
+				Convey("Then the response should indicate no route was found", func() {
+					So(errorResponse["error"], ShouldEqual, "no route found")
+				})
+			})
+
+			Convey("When source and destination tokens are the same", func() {
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/route?src="+wethAddr+"&dst="+strings.ToLower(wethAddr)+"&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should reject the request", func() {
+					So(errorResponse["error"], ShouldEqual, "source and destination tokens must differ")
+				})
+			})
+
+			Convey("When making a request with invalid source token address", func() {
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/route?src=0xinvalid&dst="+usdtAddr+"&src_amount="+validAmount,
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate invalid source token address format", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid source token address format")
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,253 @@
+package estimate
+
+import (
+	"errors"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/WangWilly/swap-estimation/pkgs/clients/eth"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/univ3"
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetUniV3(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given an estimate swap endpoint with a Uniswap V3 pool", t, func() {
+			poolAddr := "0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640"
+			token0Addr := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48" // USDC
+			token1Addr := "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2" // WETH
+
+			liquidity, _ := new(big.Int).SetString("3000000000000000000", 10)
+			poolState := &univ3.PoolState{
+				Token0:       common.HexToAddress(token0Addr),
+				Token1:       common.HexToAddress(token1Addr),
+				FeePips:      3000,
+				TickSpacing:  60,
+				SqrtPriceX96: new(big.Int).Lsh(big.NewInt(1), 96),
+				Tick:         0,
+				Liquidity:    liquidity,
+				Ticks: []univ3.Tick{
+					{Index: -1200, LiquidityNet: big.NewInt(2000000000000000000)},
+					{Index: -600, LiquidityNet: big.NewInt(1000000000000000000)},
+					{Index: 600, LiquidityNet: big.NewInt(-1000000000000000000)},
+					{Index: 1200, LiquidityNet: big.NewInt(-2000000000000000000)},
+				},
+				MinWordPos: -1,
+				MaxWordPos: 0,
+			}
+
+			Convey("When making a valid exact input request", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(nil) // Cache miss
+				s.ethV3Client.EXPECT().
+					PoolState(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(poolState, nil)
+				s.ethWssClient.EXPECT().
+					RegPool(gomock.Any(), common.HexToAddress(poolAddr), gomock.Any(), poolState).
+					Return(nil)
+
+				var actualOutput string
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodGet,
+					"/estimate?pool_type=v3&pool="+poolAddr+
+						"&src="+token0Addr+
+						"&dst="+token1Addr+
+						"&src_amount=1000000000000000",
+					nil,
+					&actualOutput,
+				)
+
+				Convey("Then the response should be the simulated output amount", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(actualOutput, ShouldEqual, "996668773744192")
+				})
+			})
+
+			Convey("When the pool is cached as another pool type", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(&eth.ReservePair{Reserve0: big.NewInt(1000), Reserve1: big.NewInt(1000)})
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool_type=v3&pool="+poolAddr+
+						"&src="+token0Addr+
+						"&dst="+token1Addr+
+						"&src_amount=1000000000000000",
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate the pool type does not match", func() {
+					So(errorResponse["error"], ShouldEqual, "pool type does not match the pool")
+				})
+			})
+
+			Convey("When making a request with cached pool state", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(poolState) // Cache hit
+
+				// No call to ethV3Client.PoolState expected
+				// No call to ethWssClient.RegPool expected
+
+				var actualOutput string
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodGet,
+					"/estimate?pool_type=v3&pool="+poolAddr+
+						"&src="+token0Addr+
+						"&dst="+token1Addr+
+						"&src_amount=1000000000000000",
+					nil,
+					&actualOutput,
+				)
+
+				Convey("Then the response should be computed from the cached state", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(actualOutput, ShouldEqual, "996668773744192")
+				})
+			})
+
+			Convey("When making a valid exact output request", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(nil) // Cache miss
+				s.ethV3Client.EXPECT().
+					PoolState(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(poolState, nil)
+				s.ethWssClient.EXPECT().
+					RegPool(gomock.Any(), common.HexToAddress(poolAddr), gomock.Any(), poolState).
+					Return(nil)
+
+				var actualOutput string
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodGet,
+					"/estimate?pool_type=v3&pool="+poolAddr+
+						"&src="+token0Addr+
+						"&dst="+token1Addr+
+						"&dst_amount=100000000000000000",
+					nil,
+					&actualOutput,
+				)
+
+				Convey("Then the response should be the required input amount", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(actualOutput, ShouldEqual, "103783310809923073")
+				})
+			})
+
+			Convey("When the swap crosses beyond the loaded ticks", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(nil) // Cache miss
+				s.ethV3Client.EXPECT().
+					PoolState(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(poolState, nil)
+				s.ethWssClient.EXPECT().
+					RegPool(gomock.Any(), common.HexToAddress(poolAddr), gomock.Any(), poolState).
+					Return(nil)
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool_type=v3&pool="+poolAddr+
+						"&src="+token0Addr+
+						"&dst="+token1Addr+
+						"&src_amount=300000000000000000",
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate the tick range is exceeded", func() {
+					So(errorResponse["error"], ShouldEqual, "amount exceeds the loaded tick range")
+				})
+			})
+
+			Convey("When the tokens do not belong to the pool", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(nil) // Cache miss
+				s.ethV3Client.EXPECT().
+					PoolState(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(poolState, nil)
+				s.ethWssClient.EXPECT().
+					RegPool(gomock.Any(), common.HexToAddress(poolAddr), gomock.Any(), poolState).
+					Return(nil)
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool_type=v3&pool="+poolAddr+
+						"&src="+token0Addr+
+						"&dst=0xdAC17F958D2ee523a2206206994597C13D831ec7"+
+						"&src_amount=1000",
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate the tokens are invalid", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid pool tokens")
+				})
+			})
+
+			Convey("When the pool state cannot be read", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(nil) // Cache miss
+				s.ethV3Client.EXPECT().
+					PoolState(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(nil, errors.New("execution reverted"))
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool_type=v3&pool="+poolAddr+
+						"&src="+token0Addr+
+						"&dst="+token1Addr+
+						"&src_amount=1000",
+					nil,
+					&errorResponse,
+					http.StatusInternalServerError,
+				)
+
+				Convey("Then the response should indicate a server error", func() {
+					So(errorResponse["error"], ShouldEqual, "failed to get pool state")
+				})
+			})
+
+			Convey("When making a request with an unknown pool type", func() {
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool_type=v4&pool="+poolAddr+
+						"&src="+token0Addr+
+						"&dst="+token1Addr+
+						"&src_amount=1000",
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate the pool type is invalid", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid pool type")
+				})
+			})
+		})
+	})
+}
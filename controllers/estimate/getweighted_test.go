@@ -0,0 +1,177 @@
+package estimate
+
+import (
+	"errors"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/WangWilly/swap-estimation/pkgs/clients/balancer"
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetWeighted(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given an estimate swap endpoint with a weighted pool", t, func() {
+			poolAddr := "0x5c6Ee304399DBdB9C8Ef030aB642B10820DB8F56"
+			heavyAddr := "0xba100000625a3754423978a60c9317c58a424e3D" // BAL, 80%
+			lightAddr := "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2" // WETH, 20%
+
+			heavyBalance, _ := new(big.Int).SetString("1000000000000000000000000", 10)
+			lightBalance, _ := new(big.Int).SetString("200000000000000000000000", 10)
+			poolState := &balancer.PoolState{
+				Tokens: []common.Address{
+					common.HexToAddress(heavyAddr),
+					common.HexToAddress(lightAddr),
+				},
+				Balances: []*big.Int{heavyBalance, lightBalance},
+				Weights:  []*big.Int{big.NewInt(800000000000000000), big.NewInt(200000000000000000)},
+				SwapFee:  big.NewInt(3000000000000000),
+			}
+
+			Convey("When making a valid exact input request", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(nil) // Cache miss
+				s.weightedClient.EXPECT().
+					PoolState(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(poolState, nil)
+				s.ethWssClient.EXPECT().
+					RegPool(gomock.Any(), common.HexToAddress(poolAddr), gomock.Any(), poolState).
+					Return(nil)
+
+				var actualOutput string
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodGet,
+					"/estimate?pool_type=weighted&pool="+poolAddr+
+						"&src="+heavyAddr+
+						"&dst="+lightAddr+
+						"&src_amount=1000000000000000000000",
+					nil,
+					&actualOutput,
+				)
+
+				Convey("Then the response should be the weighted output amount", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(actualOutput, ShouldEqual, "795615939202531000000")
+				})
+			})
+
+			Convey("When making a valid exact output request", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(nil) // Cache miss
+				s.weightedClient.EXPECT().
+					PoolState(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(poolState, nil)
+				s.ethWssClient.EXPECT().
+					RegPool(gomock.Any(), common.HexToAddress(poolAddr), gomock.Any(), poolState).
+					Return(nil)
+
+				var actualOutput string
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodGet,
+					"/estimate?pool_type=weighted&pool="+poolAddr+
+						"&src="+heavyAddr+
+						"&dst="+lightAddr+
+						"&dst_amount=100000000000000000000",
+					nil,
+					&actualOutput,
+				)
+
+				Convey("Then the response should be the required input amount", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(actualOutput, ShouldEqual, "125415323123763289870")
+				})
+			})
+
+			Convey("When swapping more than the max in ratio", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(nil) // Cache miss
+				s.weightedClient.EXPECT().
+					PoolState(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(poolState, nil)
+				s.ethWssClient.EXPECT().
+					RegPool(gomock.Any(), common.HexToAddress(poolAddr), gomock.Any(), poolState).
+					Return(nil)
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool_type=weighted&pool="+poolAddr+
+						"&src="+heavyAddr+
+						"&dst="+lightAddr+
+						"&src_amount=500000000000000000000000",
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate the ratio is exceeded", func() {
+					So(errorResponse["error"], ShouldEqual, "amount exceeds the pool's max swap ratio")
+				})
+			})
+
+			Convey("When the tokens do not belong to the pool", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(nil) // Cache miss
+				s.weightedClient.EXPECT().
+					PoolState(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(poolState, nil)
+				s.ethWssClient.EXPECT().
+					RegPool(gomock.Any(), common.HexToAddress(poolAddr), gomock.Any(), poolState).
+					Return(nil)
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool_type=weighted&pool="+poolAddr+
+						"&src="+heavyAddr+
+						"&dst=0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"+
+						"&src_amount=1000",
+					nil,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate the tokens are invalid", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid pool tokens")
+				})
+			})
+
+			Convey("When the pool state cannot be read", func() {
+				s.ethWssClient.EXPECT().
+					GetPool(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(nil) // Cache miss
+				s.weightedClient.EXPECT().
+					PoolState(gomock.Any(), common.HexToAddress(poolAddr)).
+					Return(nil, errors.New("execution reverted"))
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodGet,
+					"/estimate?pool_type=weighted&pool="+poolAddr+
+						"&src="+heavyAddr+
+						"&dst="+lightAddr+
+						"&src_amount=1000",
+					nil,
+					&errorResponse,
+					http.StatusInternalServerError,
+				)
+
+				Convey("Then the response should indicate a server error", func() {
+					So(errorResponse["error"], ShouldEqual, "failed to get pool state")
+				})
+			})
+		})
+	})
+}
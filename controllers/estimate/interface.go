@@ -3,16 +3,19 @@ package estimate
 import (
 	"context"
 
-	"github.com/WangWilly/swap-estimation/pkgs/clients/eth"
-	"github.com/WangWilly/swap-estimation/pkgs/clients/ethwss"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/erc20"
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/ethereum/go-ethereum/common"
 )
 
 //go:generate mockgen -source=interface.go -destination=interface_mock.go -package=estimate
-type EthClient interface {
-	UniV2ReservePair(ctx context.Context, pairAddrStr string) (*eth.ReservePair, error)
+type EthWssClient interface {
+	GetPool(ctx context.Context, address common.Address) poolmodel.State
+	RegPool(ctx context.Context, address common.Address, model poolmodel.PoolModel, initState poolmodel.State) error
+	ListPools(ctx context.Context) []common.Address
+	SnapshotPools(ctx context.Context, addresses []common.Address) map[common.Address]poolmodel.State
 }
 
-type EthWssClient interface {
-	GetPair(ctx context.Context, address string) *ethwss.ReservePair
-	RegPair(ctx context.Context, address string, initPair *ethwss.ReservePair) error
+type Erc20Client interface {
+	Metadata(ctx context.Context, tokenAddr common.Address) (*erc20.Metadata, error)
 }
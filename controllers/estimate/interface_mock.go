@@ -13,98 +13,127 @@ import (
 	context "context"
 	reflect "reflect"
 
-	eth "github.com/WangWilly/swap-estimation/pkgs/clients/eth"
-	ethwss "github.com/WangWilly/swap-estimation/pkgs/clients/ethwss"
+	erc20 "github.com/WangWilly/swap-estimation/pkgs/clients/erc20"
+	poolmodel "github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	common "github.com/ethereum/go-ethereum/common"
 	gomock "go.uber.org/mock/gomock"
 )
 
-// MockEthClient is a mock of EthClient interface.
-type MockEthClient struct {
+// MockEthWssClient is a mock of EthWssClient interface.
+type MockEthWssClient struct {
 	ctrl     *gomock.Controller
-	recorder *MockEthClientMockRecorder
+	recorder *MockEthWssClientMockRecorder
 	isgomock struct{}
 }
 
-// MockEthClientMockRecorder is the mock recorder for MockEthClient.
-type MockEthClientMockRecorder struct {
-	mock *MockEthClient
+// MockEthWssClientMockRecorder is the mock recorder for MockEthWssClient.
+type MockEthWssClientMockRecorder struct {
+	mock *MockEthWssClient
 }
 
-// NewMockEthClient creates a new mock instance.
-func NewMockEthClient(ctrl *gomock.Controller) *MockEthClient {
-	mock := &MockEthClient{ctrl: ctrl}
-	mock.recorder = &MockEthClientMockRecorder{mock}
+// NewMockEthWssClient creates a new mock instance.
+func NewMockEthWssClient(ctrl *gomock.Controller) *MockEthWssClient {
+	mock := &MockEthWssClient{ctrl: ctrl}
+	mock.recorder = &MockEthWssClientMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockEthClient) EXPECT() *MockEthClientMockRecorder {
+func (m *MockEthWssClient) EXPECT() *MockEthWssClientMockRecorder {
 	return m.recorder
 }
 
-// UniV2ReservePair mocks base method.
-func (m *MockEthClient) UniV2ReservePair(ctx context.Context, pairAddrStr string) (*eth.ReservePair, error) {
+// GetPool mocks base method.
+func (m *MockEthWssClient) GetPool(ctx context.Context, address common.Address) poolmodel.State {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UniV2ReservePair", ctx, pairAddrStr)
-	ret0, _ := ret[0].(*eth.ReservePair)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "GetPool", ctx, address)
+	ret0, _ := ret[0].(poolmodel.State)
+	return ret0
 }
 
-// UniV2ReservePair indicates an expected call of UniV2ReservePair.
-func (mr *MockEthClientMockRecorder) UniV2ReservePair(ctx, pairAddrStr any) *gomock.Call {
+// GetPool indicates an expected call of GetPool.
+func (mr *MockEthWssClientMockRecorder) GetPool(ctx, address any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UniV2ReservePair", reflect.TypeOf((*MockEthClient)(nil).UniV2ReservePair), ctx, pairAddrStr)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPool", reflect.TypeOf((*MockEthWssClient)(nil).GetPool), ctx, address)
 }
 
-// MockEthWssClient is a mock of EthWssClient interface.
-type MockEthWssClient struct {
-	ctrl     *gomock.Controller
-	recorder *MockEthWssClientMockRecorder
-	isgomock struct{}
+// ListPools mocks base method.
+func (m *MockEthWssClient) ListPools(ctx context.Context) []common.Address {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPools", ctx)
+	ret0, _ := ret[0].([]common.Address)
+	return ret0
 }
 
-// MockEthWssClientMockRecorder is the mock recorder for MockEthWssClient.
-type MockEthWssClientMockRecorder struct {
-	mock *MockEthWssClient
+// ListPools indicates an expected call of ListPools.
+func (mr *MockEthWssClientMockRecorder) ListPools(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPools", reflect.TypeOf((*MockEthWssClient)(nil).ListPools), ctx)
 }
 
-// NewMockEthWssClient creates a new mock instance.
-func NewMockEthWssClient(ctrl *gomock.Controller) *MockEthWssClient {
-	mock := &MockEthWssClient{ctrl: ctrl}
-	mock.recorder = &MockEthWssClientMockRecorder{mock}
-	return mock
+// RegPool mocks base method.
+func (m *MockEthWssClient) RegPool(ctx context.Context, address common.Address, model poolmodel.PoolModel, initState poolmodel.State) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegPool", ctx, address, model, initState)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockEthWssClient) EXPECT() *MockEthWssClientMockRecorder {
-	return m.recorder
+// RegPool indicates an expected call of RegPool.
+func (mr *MockEthWssClientMockRecorder) RegPool(ctx, address, model, initState any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegPool", reflect.TypeOf((*MockEthWssClient)(nil).RegPool), ctx, address, model, initState)
 }
 
-// GetPair mocks base method.
-func (m *MockEthWssClient) GetPair(ctx context.Context, address string) *ethwss.ReservePair {
+// SnapshotPools mocks base method.
+func (m *MockEthWssClient) SnapshotPools(ctx context.Context, addresses []common.Address) map[common.Address]poolmodel.State {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPair", ctx, address)
-	ret0, _ := ret[0].(*ethwss.ReservePair)
+	ret := m.ctrl.Call(m, "SnapshotPools", ctx, addresses)
+	ret0, _ := ret[0].(map[common.Address]poolmodel.State)
 	return ret0
 }
 
-// GetPair indicates an expected call of GetPair.
-func (mr *MockEthWssClientMockRecorder) GetPair(ctx, address any) *gomock.Call {
+// SnapshotPools indicates an expected call of SnapshotPools.
+func (mr *MockEthWssClientMockRecorder) SnapshotPools(ctx, addresses any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPair", reflect.TypeOf((*MockEthWssClient)(nil).GetPair), ctx, address)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnapshotPools", reflect.TypeOf((*MockEthWssClient)(nil).SnapshotPools), ctx, addresses)
+}
+
+// MockErc20Client is a mock of Erc20Client interface.
+type MockErc20Client struct {
+	ctrl     *gomock.Controller
+	recorder *MockErc20ClientMockRecorder
+	isgomock struct{}
 }
 
-// RegPair mocks base method.
-func (m *MockEthWssClient) RegPair(ctx context.Context, address string, initPair *ethwss.ReservePair) error {
+// MockErc20ClientMockRecorder is the mock recorder for MockErc20Client.
+type MockErc20ClientMockRecorder struct {
+	mock *MockErc20Client
+}
+
+// NewMockErc20Client creates a new mock instance.
+func NewMockErc20Client(ctrl *gomock.Controller) *MockErc20Client {
+	mock := &MockErc20Client{ctrl: ctrl}
+	mock.recorder = &MockErc20ClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockErc20Client) EXPECT() *MockErc20ClientMockRecorder {
+	return m.recorder
+}
+
+// Metadata mocks base method.
+func (m *MockErc20Client) Metadata(ctx context.Context, tokenAddr common.Address) (*erc20.Metadata, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RegPair", ctx, address, initPair)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "Metadata", ctx, tokenAddr)
+	ret0, _ := ret[0].(*erc20.Metadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// RegPair indicates an expected call of RegPair.
-func (mr *MockEthWssClientMockRecorder) RegPair(ctx, address, initPair any) *gomock.Call {
+// Metadata indicates an expected call of Metadata.
+func (mr *MockErc20ClientMockRecorder) Metadata(ctx, tokenAddr any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegPair", reflect.TypeOf((*MockEthWssClient)(nil).RegPair), ctx, address, initPair)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Metadata", reflect.TypeOf((*MockErc20Client)(nil).Metadata), ctx, tokenAddr)
 }
@@ -0,0 +1,141 @@
+package poolmodels
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/WangWilly/swap-estimation/controllers/estimate/ctrlutils"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/curve"
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+const PoolTypeCurve = "curve"
+
+////////////////////////////////////////////////////////////////////////////////
+
+// curveStableSwap models Curve StableSwap pools. Its state is a
+// *curve.PoolState; the pool events differ between pool versions, so any log
+// emitted by the pool triggers a refetch.
+type curveStableSwap struct {
+	curveClient CurveClient
+}
+
+func NewCurve(curveClient CurveClient) *curveStableSwap {
+	return &curveStableSwap{
+		curveClient: curveClient,
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (m *curveStableSwap) Name() string {
+	return "curve"
+}
+
+func (m *curveStableSwap) FetchState(ctx context.Context, poolAddr common.Address) (poolmodel.State, error) {
+	poolState, err := m.curveClient.PoolState(ctx, poolAddr)
+	if err != nil {
+		return nil, err
+	}
+	if poolState == nil {
+		return nil, fmt.Errorf("pool state not found for %s", poolAddr)
+	}
+	if len(poolState.Decimals) != len(poolState.Coins) {
+		return nil, fmt.Errorf("curve pool state has %d coins and %d decimals", len(poolState.Coins), len(poolState.Decimals))
+	}
+	return poolState, nil
+}
+
+func (m *curveStableSwap) LogQuery(poolAddr common.Address, _ poolmodel.State) ethereum.FilterQuery {
+	return ethereum.FilterQuery{
+		Addresses: []common.Address{poolAddr},
+	}
+}
+
+func (m *curveStableSwap) ApplyLog(_ poolmodel.State, _ types.Log) (poolmodel.State, error) {
+	return nil, poolmodel.ErrStateStale
+}
+
+func (m *curveStableSwap) AmountOut(state poolmodel.State, swap poolmodel.Swap, amountIn *big.Int) (*big.Int, error) {
+	pool, i, j, err := m.pool(state, swap)
+	if err != nil {
+		return nil, err
+	}
+	return ctrlutils.CalStableSwapOutAmount(pool, i, j, amountIn)
+}
+
+func (m *curveStableSwap) AmountIn(state poolmodel.State, swap poolmodel.Swap, amountOut *big.Int) (*big.Int, error) {
+	pool, i, j, err := m.pool(state, swap)
+	if err != nil {
+		return nil, err
+	}
+	return ctrlutils.CalStableSwapInAmount(pool, i, j, amountOut)
+}
+
+func (m *curveStableSwap) SpotPrice(state poolmodel.State, swap poolmodel.Swap) (*poolmodel.SpotPrice, error) {
+	pool, i, j, err := m.pool(state, swap)
+	if err != nil {
+		return nil, err
+	}
+
+	price, err := ctrlutils.CalStableSwapSpotPrice(pool, i, j)
+	if err != nil {
+		return nil, err
+	}
+	// Curve charges its fee on the output, which amounts to the same rate
+	return &poolmodel.SpotPrice{
+		Price:   price,
+		FeeRate: new(big.Rat).SetFrac(pool.Fee, big.NewInt(1e10)),
+	}, nil
+}
+
+func (m *curveStableSwap) Reserves(state poolmodel.State, swap poolmodel.Swap) (*poolmodel.Reserves, error) {
+	_, i, j, err := m.pool(state, swap)
+	if err != nil {
+		return nil, err
+	}
+	if i > j {
+		i, j = j, i
+	}
+
+	poolState := state.(*curve.PoolState)
+	return &poolmodel.Reserves{
+		Token0:   poolState.Coins[i],
+		Token1:   poolState.Coins[j],
+		Reserve0: poolState.Balances[i],
+		Reserve1: poolState.Balances[j],
+	}, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// pool converts the state into the invariant input and resolves coin indexes.
+func (m *curveStableSwap) pool(state poolmodel.State, swap poolmodel.Swap) (ctrlutils.StableSwapPool, int, int, error) {
+	poolState, ok := state.(*curve.PoolState)
+	if !ok || poolState == nil {
+		return ctrlutils.StableSwapPool{}, 0, 0, ErrInvalidState
+	}
+
+	i, j := tokenIndex(poolState.Coins, swap.Src), tokenIndex(poolState.Coins, swap.Dst)
+	if i < 0 || j < 0 || i == j {
+		return ctrlutils.StableSwapPool{}, 0, 0, poolmodel.ErrUnknownToken
+	}
+
+	pool := ctrlutils.StableSwapPool{
+		A:        poolState.A,
+		Fee:      poolState.Fee,
+		Balances: poolState.Balances,
+	}
+	for _, decimals := range poolState.Decimals {
+		// Scale every coin to 18 decimals with a 1e18 precision
+		rate := new(big.Int).Exp(big.NewInt(10), big.NewInt(36-int64(decimals)), nil)
+		pool.Rates = append(pool.Rates, rate)
+	}
+	return pool, i, j, nil
+}
@@ -0,0 +1,105 @@
+package poolmodels
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/WangWilly/swap-estimation/pkgs/clients/curve"
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCurve(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given the Curve pool model", t, func() {
+			ctx := context.Background()
+			poolAddr := common.HexToAddress("0xbEbc44782C7dB0a1A60Cb6fe97d0b483032FF1C7")
+			daiAddr := common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+			usdcAddr := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+			usdtAddr := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+
+			poolState := &curve.PoolState{
+				A:   big.NewInt(2000),
+				Fee: big.NewInt(4000000),
+				Coins: []common.Address{
+					daiAddr,
+					usdcAddr,
+					usdtAddr,
+				},
+				Balances: []*big.Int{
+					new(big.Int).Exp(big.NewInt(10), big.NewInt(24), nil),
+					big.NewInt(1000000000000),
+					big.NewInt(1000000000000),
+				},
+				Decimals: []uint8{18, 6, 6},
+			}
+
+			Convey("When the pool state has missing decimals", func() {
+				s.curveClient.EXPECT().
+					PoolState(gomock.Any(), poolAddr).
+					Return(&curve.PoolState{Coins: poolState.Coins, Decimals: []uint8{18}}, nil)
+
+				state, err := s.curve.FetchState(ctx, poolAddr)
+
+				Convey("Then it should return an error", func() {
+					So(err, ShouldNotBeNil)
+					So(state, ShouldBeNil)
+				})
+			})
+
+			Convey("When any pool log is applied", func() {
+				query := s.curve.LogQuery(poolAddr, poolState)
+				_, err := s.curve.ApplyLog(poolState, types.Log{})
+
+				Convey("Then every pool log should make the state stale", func() {
+					So(query.Addresses, ShouldResemble, []common.Address{poolAddr})
+					So(query.Topics, ShouldBeEmpty)
+					So(err, ShouldEqual, poolmodel.ErrStateStale)
+				})
+			})
+
+			Convey("When quoting coins with different decimals", func() {
+				amountOut, err := s.curve.AmountOut(poolState, poolmodel.Swap{
+					Pool: poolAddr,
+					Src:  usdcAddr,
+					Dst:  daiAddr,
+				}, big.NewInt(1000000000))
+
+				Convey("Then the amounts should be scaled by the coin rates", func() {
+					So(err, ShouldBeNil)
+					So(amountOut.String(), ShouldEqual, "999599500449525711807")
+				})
+			})
+
+			Convey("When pricing a balanced pool before a trade", func() {
+				spot, err := s.curve.SpotPrice(poolState, poolmodel.Swap{
+					Pool: poolAddr,
+					Src:  usdcAddr,
+					Dst:  daiAddr,
+				})
+
+				Convey("Then it should only convert decimals and report the pool fee", func() {
+					So(err, ShouldBeNil)
+					So(spot.Price.RatString(), ShouldEqual, "1000000000000")
+					So(spot.FeeRate.RatString(), ShouldEqual, "1/2500")
+				})
+			})
+
+			Convey("When quoting the same coin on both sides", func() {
+				_, err := s.curve.AmountIn(poolState, poolmodel.Swap{
+					Pool: poolAddr,
+					Src:  usdcAddr,
+					Dst:  usdcAddr,
+				}, big.NewInt(1000000000))
+
+				Convey("Then it should report an unknown token", func() {
+					So(err, ShouldEqual, poolmodel.ErrUnknownToken)
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,32 @@
+package poolmodels
+
+import (
+	"context"
+
+	"github.com/WangWilly/swap-estimation/pkgs/clients/balancer"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/curve"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/eth"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/univ3"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+//go:generate mockgen -source=interface.go -destination=interface_mock.go -package=poolmodels
+type EthClient interface {
+	ContractExists(ctx context.Context, addr common.Address) (bool, error)
+	UniV2PairMetadata(ctx context.Context, pairAddr common.Address) (*eth.PairMetadata, error)
+	UniV2ReservePair(ctx context.Context, pairAddr common.Address) (*eth.ReservePair, error)
+	UniV2ReservePairAt(ctx context.Context, pairAddr common.Address, blockNumber uint64) (*eth.ReservePair, error)
+	UniV2ReservePairs(ctx context.Context, pairAddrs []common.Address) (map[common.Address]*eth.ReservePair, error)
+}
+
+type EthV3Client interface {
+	PoolState(ctx context.Context, poolAddr common.Address) (*univ3.PoolState, error)
+}
+
+type CurveClient interface {
+	PoolState(ctx context.Context, poolAddr common.Address) (*curve.PoolState, error)
+}
+
+type WeightedClient interface {
+	PoolState(ctx context.Context, poolAddr common.Address) (*balancer.PoolState, error)
+}
@@ -0,0 +1,43 @@
+package poolmodels
+
+import (
+	"testing"
+
+	"go.uber.org/mock/gomock"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+type testSuite struct {
+	ethClient      *MockEthClient
+	ethV3Client    *MockEthV3Client
+	curveClient    *MockCurveClient
+	weightedClient *MockWeightedClient
+
+	uniV2    *uniV2
+	uniV3    *uniV3
+	curve    *curveStableSwap
+	weighted *weighted
+}
+
+func testInit(t *testing.T, test func(*testSuite)) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ethClient := NewMockEthClient(ctrl)
+	ethV3Client := NewMockEthV3Client(ctrl)
+	curveClient := NewMockCurveClient(ctrl)
+	weightedClient := NewMockWeightedClient(ctrl)
+
+	ts := &testSuite{
+		ethClient:      ethClient,
+		ethV3Client:    ethV3Client,
+		curveClient:    curveClient,
+		weightedClient: weightedClient,
+		uniV2:          NewUniV2(ethClient),
+		uniV3:          NewUniV3(ethV3Client),
+		curve:          NewCurve(curveClient),
+		weighted:       NewWeighted(weightedClient),
+	}
+	test(ts)
+}
@@ -0,0 +1,23 @@
+package poolmodels
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+var ErrInvalidState = errors.New("unexpected pool state type")
+
+////////////////////////////////////////////////////////////////////////////////
+
+// tokenIndex returns the position of a token address in tokens, or -1.
+func tokenIndex(tokens []common.Address, addr common.Address) int {
+	for i, token := range tokens {
+		if token == addr {
+			return i
+		}
+	}
+	return -1
+}
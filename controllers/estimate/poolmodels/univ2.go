@@ -0,0 +1,269 @@
+package poolmodels
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/WangWilly/swap-estimation/controllers/estimate/ctrlutils"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/eth"
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+const PoolTypeUniV2 = "v2"
+
+const uniswapV2PairABI = `[{"anonymous":false,"inputs":[{"indexed":false,"internalType":"uint112","name":"reserve0","type":"uint112"},{"indexed":false,"internalType":"uint112","name":"reserve1","type":"uint112"}],"name":"Sync","type":"event"}]`
+
+var uniV2SyncTopic = crypto.Keccak256Hash([]byte("Sync(uint112,uint112)"))
+
+////////////////////////////////////////////////////////////////////////////////
+
+// uniV2 models Uniswap V2 compatible pairs. Its state is an *eth.ReservePair
+// kept up to date by Sync events, carrying the tokens read from the pair.
+type uniV2 struct {
+	ethClient EthClient
+}
+
+func NewUniV2(ethClient EthClient) *uniV2 {
+	return &uniV2{
+		ethClient: ethClient,
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (m *uniV2) Name() string {
+	return "uniswapv2"
+}
+
+func (m *uniV2) FetchState(ctx context.Context, poolAddr common.Address) (poolmodel.State, error) {
+	pair, err := m.ethClient.UniV2ReservePair(ctx, poolAddr)
+	if err != nil {
+		return nil, err
+	}
+	if pair == nil {
+		return nil, fmt.Errorf("reserve pair not found for %s", poolAddr)
+	}
+	return m.withPairTokens(ctx, poolAddr, pair)
+}
+
+// FetchStateAt reads the reserves of the pair as of a past block.
+func (m *uniV2) FetchStateAt(ctx context.Context, poolAddr common.Address, blockNumber uint64) (poolmodel.State, error) {
+	pair, err := m.ethClient.UniV2ReservePairAt(ctx, poolAddr, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if pair == nil {
+		return nil, fmt.Errorf("reserve pair not found for %s at block %d", poolAddr, blockNumber)
+	}
+	return m.withPairTokens(ctx, poolAddr, pair)
+}
+
+// FetchStates reads the reserves of several pairs at one pinned block. The
+// pairs are read with their tokens, which are only read apart for the pairs
+// that come without them.
+func (m *uniV2) FetchStates(ctx context.Context, poolAddrs []common.Address) (map[common.Address]poolmodel.State, error) {
+	pairs, err := m.ethClient.UniV2ReservePairs(ctx, poolAddrs)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[common.Address]poolmodel.State, len(pairs))
+	for poolAddr, pair := range pairs {
+		if hasPairTokens(pair) {
+			states[poolAddr] = pair
+			continue
+		}
+		withTokens, err := m.withPairTokens(ctx, poolAddr, pair)
+		if err != nil {
+			// Left out like the pairs the node failed to read
+			continue
+		}
+		states[poolAddr] = withTokens
+	}
+	return states, nil
+}
+
+// withPairTokens returns a copy of the reserves with the tokens of the pair,
+// so that the swap tokens are checked against them and the reserves oriented
+// from the chain rather than from the swap.
+func (m *uniV2) withPairTokens(ctx context.Context, poolAddr common.Address, pair *eth.ReservePair) (*eth.ReservePair, error) {
+	metadata, err := m.ethClient.UniV2PairMetadata(ctx, poolAddr)
+	if err != nil {
+		return nil, err
+	}
+	withTokens := *pair
+	withTokens.Token0, withTokens.Token1 = metadata.Token0, metadata.Token1
+	return &withTokens, nil
+}
+
+// IsDeployed tells whether the pair contract exists.
+func (m *uniV2) IsDeployed(ctx context.Context, poolAddr common.Address) (bool, error) {
+	return m.ethClient.ContractExists(ctx, poolAddr)
+}
+
+func (m *uniV2) LogQuery(poolAddr common.Address, _ poolmodel.State) ethereum.FilterQuery {
+	return ethereum.FilterQuery{
+		Addresses: []common.Address{poolAddr},
+		Topics:    [][]common.Hash{{uniV2SyncTopic}},
+	}
+}
+
+func (m *uniV2) ApplyLog(state poolmodel.State, vLog types.Log) (poolmodel.State, error) {
+	if len(vLog.Topics) == 0 || vLog.Topics[0] != uniV2SyncTopic {
+		return state, nil
+	}
+
+	parsedABI, err := abi.JSON(strings.NewReader(uniswapV2PairABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ABI: %v", err)
+	}
+	var pair eth.ReservePair
+	if err := parsedABI.UnpackIntoInterface(&pair, "Sync", vLog.Data); err != nil {
+		return nil, fmt.Errorf("failed to unpack log data: %v", err)
+	}
+	pair.BlockNumber = vLog.BlockNumber
+	pair.BlockHash = vLog.BlockHash
+	pair.LogIndex = vLog.Index
+	if prev, ok := state.(*eth.ReservePair); ok && prev != nil {
+		pair.Token0, pair.Token1 = prev.Token0, prev.Token1
+	}
+	return &pair, nil
+}
+
+func (m *uniV2) AmountOut(state poolmodel.State, swap poolmodel.Swap, amountIn *big.Int) (*big.Int, error) {
+	pair, ok := state.(*eth.ReservePair)
+	if !ok || pair == nil {
+		return nil, ErrInvalidState
+	}
+
+	reserveLow, reserveHigh, err := sortedReserves(pair, swap)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctrlutils.ValidateReserves(reserveLow, reserveHigh); err != nil {
+		return nil, err
+	}
+
+	amountOut := ctrlutils.CalOutAmountWithFee(swap.Src, swap.Dst, amountIn, reserveLow, reserveHigh, swap.FeeBps)
+	if amountOut == nil {
+		return nil, ctrlutils.ErrInvalidAmountInput
+	}
+	if err := ctrlutils.ValidateSwap(swap.Src, swap.Dst, amountIn, amountOut, reserveLow, reserveHigh, swap.FeeBps); err != nil {
+		return nil, err
+	}
+	return amountOut, nil
+}
+
+func (m *uniV2) AmountIn(state poolmodel.State, swap poolmodel.Swap, amountOut *big.Int) (*big.Int, error) {
+	pair, ok := state.(*eth.ReservePair)
+	if !ok || pair == nil {
+		return nil, ErrInvalidState
+	}
+
+	reserveLow, reserveHigh, err := sortedReserves(pair, swap)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctrlutils.ValidateReserves(reserveLow, reserveHigh); err != nil {
+		return nil, err
+	}
+
+	amountIn, err := ctrlutils.CalInAmountWithFee(swap.Src, swap.Dst, amountOut, reserveLow, reserveHigh, swap.FeeBps)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctrlutils.ValidateSwap(swap.Src, swap.Dst, amountIn, amountOut, reserveLow, reserveHigh, swap.FeeBps); err != nil {
+		return nil, err
+	}
+	return amountIn, nil
+}
+
+func (m *uniV2) SpotPrice(state poolmodel.State, swap poolmodel.Swap) (*poolmodel.SpotPrice, error) {
+	pair, ok := state.(*eth.ReservePair)
+	if !ok || pair == nil {
+		return nil, ErrInvalidState
+	}
+
+	reserveLow, reserveHigh, err := sortedReserves(pair, swap)
+	if err != nil {
+		return nil, err
+	}
+
+	price, err := ctrlutils.CalSpotPrice(swap.Src, swap.Dst, reserveLow, reserveHigh)
+	if err != nil {
+		return nil, err
+	}
+	return &poolmodel.SpotPrice{
+		Price:   price,
+		FeeRate: new(big.Rat).SetFrac64(int64(swap.FeeBps), 10000),
+	}, nil
+}
+
+// Reserves orders the swap tokens like the pair does: as read from it when
+// known, otherwise by address.
+func (m *uniV2) Reserves(state poolmodel.State, swap poolmodel.Swap) (*poolmodel.Reserves, error) {
+	pair, ok := state.(*eth.ReservePair)
+	if !ok || pair == nil {
+		return nil, ErrInvalidState
+	}
+
+	token0, token1 := pair.Token0, pair.Token1
+	if !hasPairTokens(pair) {
+		token0, token1 = swap.Src, swap.Dst
+		if bytes.Compare(token0.Bytes(), token1.Bytes()) > 0 {
+			token0, token1 = token1, token0
+		}
+	} else if !isPairSwap(pair, swap) {
+		return nil, poolmodel.ErrUnknownToken
+	}
+	return &poolmodel.Reserves{
+		Token0:      token0,
+		Token1:      token1,
+		Reserve0:    pair.Reserve0,
+		Reserve1:    pair.Reserve1,
+		BlockNumber: pair.BlockNumber,
+		BlockHash:   pair.BlockHash,
+	}, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func hasPairTokens(pair *eth.ReservePair) bool {
+	return pair.Token0 != (common.Address{}) && pair.Token1 != (common.Address{})
+}
+
+// isPairSwap tells whether the swap is between the two tokens of the pair.
+func isPairSwap(pair *eth.ReservePair, swap poolmodel.Swap) bool {
+	return (swap.Src == pair.Token0 && swap.Dst == pair.Token1) ||
+		(swap.Src == pair.Token1 && swap.Dst == pair.Token0)
+}
+
+// sortedReserves returns the reserves of the pair ordered by token address,
+// the order ctrlutils orients them in. When the tokens of the pair are known
+// the swap must be between them, and the reserves are taken from their
+// on-chain order; otherwise token0 is assumed to be the lower address, as in
+// Uniswap V2.
+func sortedReserves(pair *eth.ReservePair, swap poolmodel.Swap) (*big.Int, *big.Int, error) {
+	if !hasPairTokens(pair) {
+		return pair.Reserve0, pair.Reserve1, nil
+	}
+	if !isPairSwap(pair, swap) {
+		return nil, nil, poolmodel.ErrUnknownToken
+	}
+	if bytes.Compare(pair.Token0.Bytes(), pair.Token1.Bytes()) > 0 {
+		return pair.Reserve1, pair.Reserve0, nil
+	}
+	return pair.Reserve0, pair.Reserve1, nil
+}
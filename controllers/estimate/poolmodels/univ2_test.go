@@ -0,0 +1,297 @@
+package poolmodels
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/WangWilly/swap-estimation/pkgs/clients/eth"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/univ3"
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+func TestUniV2(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given the Uniswap V2 pool model", t, func() {
+			ctx := context.Background()
+			pairAddr := common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc")
+			wethAddr := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+			usdcAddr := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+
+			pair := &eth.ReservePair{
+				Reserve0: big.NewInt(200000000000), // 200,000 USDC (6 decimals)
+				Reserve1: new(big.Int),             // 100 ETH (18 decimals)
+			}
+			pair.Reserve1.SetString("100000000000000000000", 10)
+			metadata := &eth.PairMetadata{
+				Token0:  usdcAddr,
+				Token1:  wethAddr,
+				Factory: common.HexToAddress("0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f"),
+			}
+
+			Convey("When the pair cannot be found", func() {
+				s.ethClient.EXPECT().
+					UniV2ReservePair(gomock.Any(), pairAddr).
+					Return(nil, nil)
+
+				state, err := s.uniV2.FetchState(ctx, pairAddr)
+
+				Convey("Then it should return an error", func() {
+					So(err, ShouldNotBeNil)
+					So(state, ShouldBeNil)
+				})
+			})
+
+			Convey("When the state is fetched", func() {
+				s.ethClient.EXPECT().
+					UniV2ReservePair(gomock.Any(), pairAddr).
+					Return(pair, nil)
+				s.ethClient.EXPECT().
+					UniV2PairMetadata(gomock.Any(), pairAddr).
+					Return(metadata, nil)
+
+				state, err := s.uniV2.FetchState(ctx, pairAddr)
+
+				Convey("Then the reserves should carry the tokens of the pair", func() {
+					So(err, ShouldBeNil)
+					So(state.(*eth.ReservePair).Reserve0, ShouldEqual, pair.Reserve0)
+					So(state.(*eth.ReservePair).Token0, ShouldEqual, usdcAddr)
+					So(state.(*eth.ReservePair).Token1, ShouldEqual, wethAddr)
+					So(pair.Token0, ShouldEqual, common.Address{})
+				})
+			})
+
+			Convey("When the pair metadata cannot be read", func() {
+				s.ethClient.EXPECT().
+					UniV2ReservePair(gomock.Any(), pairAddr).
+					Return(pair, nil)
+				s.ethClient.EXPECT().
+					UniV2PairMetadata(gomock.Any(), pairAddr).
+					Return(nil, errors.New("execution reverted"))
+
+				state, err := s.uniV2.FetchState(ctx, pairAddr)
+
+				Convey("Then it should return an error", func() {
+					So(err, ShouldNotBeNil)
+					So(state, ShouldBeNil)
+				})
+			})
+
+			Convey("When the state is fetched at a past block", func() {
+				s.ethClient.EXPECT().
+					UniV2ReservePairAt(gomock.Any(), pairAddr, uint64(14000000)).
+					Return(pair, nil)
+				s.ethClient.EXPECT().
+					UniV2PairMetadata(gomock.Any(), pairAddr).
+					Return(metadata, nil)
+
+				state, err := s.uniV2.FetchStateAt(ctx, pairAddr, 14000000)
+
+				Convey("Then it should return the historical reserve pair", func() {
+					So(err, ShouldBeNil)
+					So(state.(*eth.ReservePair).Reserve0, ShouldEqual, pair.Reserve0)
+					So(state.(*eth.ReservePair).Reserve1, ShouldEqual, pair.Reserve1)
+					So(state.(*eth.ReservePair).Token0, ShouldEqual, usdcAddr)
+				})
+			})
+
+			Convey("When the states of several pairs are fetched", func() {
+				otherPairAddr := common.HexToAddress("0x3041CbD36888bECc7bbCBc0045E3B1f144466f5f")
+				thirdPairAddr := common.HexToAddress("0x0d4a11d5EEaaC28EC3F61d100daF4d40471f1852")
+				pinnedPair := *pair
+				pinnedPair.BlockNumber = 15000000
+				otherPair := pinnedPair
+				pairWithTokens := pinnedPair
+				pairWithTokens.Token0, pairWithTokens.Token1 = wethAddr, usdcAddr
+
+				s.ethClient.EXPECT().
+					UniV2ReservePairs(gomock.Any(), []common.Address{pairAddr, otherPairAddr, thirdPairAddr}).
+					Return(map[common.Address]*eth.ReservePair{
+						pairAddr:      &pinnedPair,
+						otherPairAddr: &otherPair,
+						thirdPairAddr: &pairWithTokens,
+					}, nil)
+				s.ethClient.EXPECT().
+					UniV2PairMetadata(gomock.Any(), pairAddr).
+					Return(metadata, nil)
+				s.ethClient.EXPECT().
+					UniV2PairMetadata(gomock.Any(), otherPairAddr).
+					Return(nil, errors.New("execution reverted"))
+				// No metadata call expected for the pair read with its tokens
+
+				states, err := s.uniV2.FetchStates(ctx, []common.Address{pairAddr, otherPairAddr, thirdPairAddr})
+
+				Convey("Then it should return the pairs whose tokens could be read, at the pinned block", func() {
+					So(err, ShouldBeNil)
+					So(states, ShouldHaveLength, 2)
+					So(states[pairAddr].(*eth.ReservePair).BlockNumber, ShouldEqual, 15000000)
+					So(states[pairAddr].(*eth.ReservePair).Token0, ShouldEqual, usdcAddr)
+					So(states[thirdPairAddr], ShouldEqual, &pairWithTokens)
+				})
+			})
+
+			Convey("When checking whether the pair is deployed", func() {
+				s.ethClient.EXPECT().
+					ContractExists(gomock.Any(), pairAddr).
+					Return(true, nil)
+
+				deployed, err := s.uniV2.IsDeployed(ctx, pairAddr)
+
+				Convey("Then it should report the pair contract", func() {
+					So(err, ShouldBeNil)
+					So(deployed, ShouldBeTrue)
+				})
+			})
+
+			Convey("When a Sync log is applied", func() {
+				parsedABI, err := abi.JSON(strings.NewReader(uniswapV2PairABI))
+				So(err, ShouldBeNil)
+				data, err := parsedABI.Events["Sync"].Inputs.Pack(big.NewInt(1000), big.NewInt(2000))
+				So(err, ShouldBeNil)
+
+				pairWithTokens := *pair
+				pairWithTokens.Token0, pairWithTokens.Token1 = usdcAddr, wethAddr
+				state, err := s.uniV2.ApplyLog(&pairWithTokens, types.Log{
+					Topics:      []common.Hash{uniV2SyncTopic},
+					Data:        data,
+					BlockNumber: 15000000,
+					BlockHash:   common.HexToHash("0xabc"),
+					Index:       7,
+				})
+
+				Convey("Then the reserves and their block should be replaced", func() {
+					So(err, ShouldBeNil)
+					So(state.(*eth.ReservePair).Reserve0.String(), ShouldEqual, "1000")
+					So(state.(*eth.ReservePair).Reserve1.String(), ShouldEqual, "2000")
+					So(state.(*eth.ReservePair).BlockNumber, ShouldEqual, 15000000)
+					So(state.(*eth.ReservePair).BlockHash, ShouldEqual, common.HexToHash("0xabc"))
+					So(state.(*eth.ReservePair).LogIndex, ShouldEqual, 7)
+				})
+
+				Convey("Then the tokens of the pair should be kept", func() {
+					So(state.(*eth.ReservePair).Token0, ShouldEqual, usdcAddr)
+					So(state.(*eth.ReservePair).Token1, ShouldEqual, wethAddr)
+				})
+			})
+
+			Convey("When another log is applied", func() {
+				state, err := s.uniV2.ApplyLog(pair, types.Log{})
+
+				Convey("Then the state should be kept", func() {
+					So(err, ShouldBeNil)
+					So(state, ShouldEqual, pair)
+				})
+			})
+
+			Convey("When quoting an exact input swap with the pool fee", func() {
+				amountOut, err := s.uniV2.AmountOut(pair, poolmodel.Swap{
+					Pool:   pairAddr,
+					Src:    wethAddr,
+					Dst:    usdcAddr,
+					FeeBps: 30,
+				}, big.NewInt(1000000000000000000))
+
+				Convey("Then the output should follow the constant product", func() {
+					So(err, ShouldBeNil)
+					So(amountOut.String(), ShouldEqual, "1974316068")
+				})
+			})
+
+			Convey("When the pair orders its tokens differently from their addresses", func() {
+				// A fork pair with WETH as token0
+				forkPair := &eth.ReservePair{
+					Reserve0: pair.Reserve1,
+					Reserve1: pair.Reserve0,
+					Token0:   wethAddr,
+					Token1:   usdcAddr,
+				}
+				amountOut, err := s.uniV2.AmountOut(forkPair, poolmodel.Swap{
+					Pool:   pairAddr,
+					Src:    wethAddr,
+					Dst:    usdcAddr,
+					FeeBps: 30,
+				}, big.NewInt(1000000000000000000))
+				reserves, reservesErr := s.uniV2.Reserves(forkPair, poolmodel.Swap{
+					Pool: pairAddr,
+					Src:  wethAddr,
+					Dst:  usdcAddr,
+				})
+
+				Convey("Then the reserves should be oriented from the pair tokens", func() {
+					So(err, ShouldBeNil)
+					So(amountOut.String(), ShouldEqual, "1974316068")
+					So(reservesErr, ShouldBeNil)
+					So(reserves.Token0, ShouldEqual, wethAddr)
+					So(reserves.Reserve0, ShouldEqual, pair.Reserve1)
+				})
+			})
+
+			Convey("When quoting a token that is not in the pair", func() {
+				pairWithTokens := *pair
+				pairWithTokens.Token0, pairWithTokens.Token1 = usdcAddr, wethAddr
+				swap := poolmodel.Swap{
+					Pool:   pairAddr,
+					Src:    wethAddr,
+					Dst:    common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"), // USDT
+					FeeBps: 30,
+				}
+
+				_, amountOutErr := s.uniV2.AmountOut(&pairWithTokens, swap, big.NewInt(1000000000000000000))
+				_, amountInErr := s.uniV2.AmountIn(&pairWithTokens, swap, big.NewInt(1000000))
+				_, reservesErr := s.uniV2.Reserves(&pairWithTokens, swap)
+
+				Convey("Then it should reject the token", func() {
+					So(amountOutErr, ShouldEqual, poolmodel.ErrUnknownToken)
+					So(amountInErr, ShouldEqual, poolmodel.ErrUnknownToken)
+					So(reservesErr, ShouldEqual, poolmodel.ErrUnknownToken)
+				})
+			})
+
+			Convey("When pricing the pair before a trade", func() {
+				spot, err := s.uniV2.SpotPrice(pair, poolmodel.Swap{
+					Pool:   pairAddr,
+					Src:    wethAddr,
+					Dst:    usdcAddr,
+					FeeBps: 30,
+				})
+
+				Convey("Then it should report the reserve ratio and the pool fee", func() {
+					So(err, ShouldBeNil)
+					So(spot.Price.RatString(), ShouldEqual, "1/500000000")
+					So(spot.FeeRate.RatString(), ShouldEqual, "3/1000")
+				})
+			})
+
+			Convey("When reporting the reserves of a swap", func() {
+				reserves, err := s.uniV2.Reserves(pair, poolmodel.Swap{
+					Pool: pairAddr,
+					Src:  wethAddr,
+					Dst:  usdcAddr,
+				})
+
+				Convey("Then the tokens should be ordered like the pair", func() {
+					So(err, ShouldBeNil)
+					So(reserves.Token0, ShouldEqual, usdcAddr)
+					So(reserves.Token1, ShouldEqual, wethAddr)
+					So(reserves.Reserve0, ShouldEqual, pair.Reserve0)
+					So(reserves.Reserve1, ShouldEqual, pair.Reserve1)
+				})
+			})
+
+			Convey("When quoting against a state of another model", func() {
+				_, err := s.uniV2.AmountOut(&univ3.PoolState{}, poolmodel.Swap{}, big.NewInt(1))
+
+				Convey("Then it should reject the state", func() {
+					So(err, ShouldEqual, ErrInvalidState)
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,257 @@
+package poolmodels
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/WangWilly/swap-estimation/controllers/estimate/ctrlutils"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/univ3"
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+const PoolTypeUniV3 = "v3"
+
+const uniswapV3PoolEventsABI = `[
+	{"anonymous":false,"inputs":[{"indexed":true,"internalType":"address","name":"sender","type":"address"},{"indexed":true,"internalType":"address","name":"recipient","type":"address"},{"indexed":false,"internalType":"int256","name":"amount0","type":"int256"},{"indexed":false,"internalType":"int256","name":"amount1","type":"int256"},{"indexed":false,"internalType":"uint160","name":"sqrtPriceX96","type":"uint160"},{"indexed":false,"internalType":"uint128","name":"liquidity","type":"uint128"},{"indexed":false,"internalType":"int24","name":"tick","type":"int24"}],"name":"Swap","type":"event"},
+	{"anonymous":false,"inputs":[{"indexed":false,"internalType":"address","name":"sender","type":"address"},{"indexed":true,"internalType":"address","name":"owner","type":"address"},{"indexed":true,"internalType":"int24","name":"tickLower","type":"int24"},{"indexed":true,"internalType":"int24","name":"tickUpper","type":"int24"},{"indexed":false,"internalType":"uint128","name":"amount","type":"uint128"},{"indexed":false,"internalType":"uint256","name":"amount0","type":"uint256"},{"indexed":false,"internalType":"uint256","name":"amount1","type":"uint256"}],"name":"Mint","type":"event"}
+]`
+
+var (
+	uniV3SwapTopic = crypto.Keccak256Hash([]byte("Swap(address,address,int256,int256,uint160,uint128,int24)"))
+	uniV3MintTopic = crypto.Keccak256Hash([]byte("Mint(address,address,int24,int24,uint128,uint256,uint256)"))
+	uniV3BurnTopic = crypto.Keccak256Hash([]byte("Burn(address,int24,int24,uint128,uint256,uint256)"))
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// uniV3 models Uniswap V3 pools. Its state is a *univ3.PoolState; Swap and
+// Mint events are applied in place, Burn events trigger a refetch since they
+// may uninitialize ticks.
+type uniV3 struct {
+	ethV3Client EthV3Client
+}
+
+func NewUniV3(ethV3Client EthV3Client) *uniV3 {
+	return &uniV3{
+		ethV3Client: ethV3Client,
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (m *uniV3) Name() string {
+	return "uniswapv3"
+}
+
+func (m *uniV3) FetchState(ctx context.Context, poolAddr common.Address) (poolmodel.State, error) {
+	poolState, err := m.ethV3Client.PoolState(ctx, poolAddr)
+	if err != nil {
+		return nil, err
+	}
+	if poolState == nil {
+		return nil, fmt.Errorf("pool state not found for %s", poolAddr)
+	}
+	return poolState, nil
+}
+
+func (m *uniV3) LogQuery(poolAddr common.Address, _ poolmodel.State) ethereum.FilterQuery {
+	return ethereum.FilterQuery{
+		Addresses: []common.Address{poolAddr},
+		Topics:    [][]common.Hash{{uniV3SwapTopic, uniV3MintTopic, uniV3BurnTopic}},
+	}
+}
+
+func (m *uniV3) ApplyLog(state poolmodel.State, vLog types.Log) (poolmodel.State, error) {
+	poolState, ok := state.(*univ3.PoolState)
+	if !ok || poolState == nil {
+		return nil, ErrInvalidState
+	}
+	if len(vLog.Topics) == 0 {
+		return state, nil
+	}
+
+	switch vLog.Topics[0] {
+	case uniV3SwapTopic:
+		parsedABI, err := abi.JSON(strings.NewReader(uniswapV3PoolEventsABI))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ABI: %v", err)
+		}
+		values, err := parsedABI.Unpack("Swap", vLog.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack log data: %v", err)
+		}
+
+		next := *poolState
+		next.BlockNumber = vLog.BlockNumber
+		next.SqrtPriceX96 = values[2].(*big.Int)
+		next.Liquidity = values[3].(*big.Int)
+		next.Tick = int(values[4].(*big.Int).Int64())
+
+		// The loaded ticks no longer surround the price
+		wordPos := tickWordPos(next.Tick, next.TickSpacing)
+		if wordPos < next.MinWordPos || wordPos > next.MaxWordPos {
+			return nil, poolmodel.ErrStateStale
+		}
+		return &next, nil
+
+	case uniV3MintTopic:
+		if len(vLog.Topics) < 4 {
+			return nil, fmt.Errorf("unexpected Mint topics: %d", len(vLog.Topics))
+		}
+		parsedABI, err := abi.JSON(strings.NewReader(uniswapV3PoolEventsABI))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ABI: %v", err)
+		}
+		values, err := parsedABI.Unpack("Mint", vLog.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack log data: %v", err)
+		}
+		amount := values[1].(*big.Int)
+		tickLower := topicToInt24(vLog.Topics[2])
+		tickUpper := topicToInt24(vLog.Topics[3])
+
+		next := *poolState
+		next.BlockNumber = vLog.BlockNumber
+		next.Ticks = slices.Clone(poolState.Ticks)
+		// Ticks outside the loaded words are not tracked
+		for _, tick := range []struct {
+			index int
+			delta *big.Int
+		}{{tickLower, amount}, {tickUpper, new(big.Int).Neg(amount)}} {
+			wordPos := tickWordPos(tick.index, next.TickSpacing)
+			if wordPos >= next.MinWordPos && wordPos <= next.MaxWordPos {
+				next.Ticks = addLiquidityNet(next.Ticks, tick.index, tick.delta)
+			}
+		}
+		if tickLower <= next.Tick && next.Tick < tickUpper {
+			next.Liquidity = new(big.Int).Add(poolState.Liquidity, amount)
+		}
+		return &next, nil
+
+	case uniV3BurnTopic:
+		return nil, poolmodel.ErrStateStale
+	}
+	return state, nil
+}
+
+func (m *uniV3) AmountOut(state poolmodel.State, swap poolmodel.Swap, amountIn *big.Int) (*big.Int, error) {
+	pool, zeroForOne, err := m.pool(state, swap)
+	if err != nil {
+		return nil, err
+	}
+	return ctrlutils.CalUniV3OutAmount(pool, zeroForOne, amountIn)
+}
+
+func (m *uniV3) AmountIn(state poolmodel.State, swap poolmodel.Swap, amountOut *big.Int) (*big.Int, error) {
+	pool, zeroForOne, err := m.pool(state, swap)
+	if err != nil {
+		return nil, err
+	}
+	return ctrlutils.CalUniV3InAmount(pool, zeroForOne, amountOut)
+}
+
+func (m *uniV3) SpotPrice(state poolmodel.State, swap poolmodel.Swap) (*poolmodel.SpotPrice, error) {
+	pool, zeroForOne, err := m.pool(state, swap)
+	if err != nil {
+		return nil, err
+	}
+
+	price, err := ctrlutils.CalUniV3SpotPrice(pool, zeroForOne)
+	if err != nil {
+		return nil, err
+	}
+	return &poolmodel.SpotPrice{
+		Price:   price,
+		FeeRate: new(big.Rat).SetFrac64(int64(pool.FeePips), 1000000),
+	}, nil
+}
+
+// Reserves reports the pool tokens and the block of the state only; liquidity
+// is spread over ticks.
+func (m *uniV3) Reserves(state poolmodel.State, swap poolmodel.Swap) (*poolmodel.Reserves, error) {
+	if _, _, err := m.pool(state, swap); err != nil {
+		return nil, err
+	}
+
+	poolState := state.(*univ3.PoolState)
+	return &poolmodel.Reserves{
+		Token0:      poolState.Token0,
+		Token1:      poolState.Token1,
+		BlockNumber: poolState.BlockNumber,
+	}, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// pool converts the state into the swap simulator input and orients the swap.
+func (m *uniV3) pool(state poolmodel.State, swap poolmodel.Swap) (ctrlutils.UniV3Pool, bool, error) {
+	poolState, ok := state.(*univ3.PoolState)
+	if !ok || poolState == nil {
+		return ctrlutils.UniV3Pool{}, false, ErrInvalidState
+	}
+
+	tokens := []common.Address{poolState.Token0, poolState.Token1}
+	src, dst := tokenIndex(tokens, swap.Src), tokenIndex(tokens, swap.Dst)
+	if src < 0 || dst < 0 || src == dst {
+		return ctrlutils.UniV3Pool{}, false, poolmodel.ErrUnknownToken
+	}
+
+	pool := ctrlutils.UniV3Pool{
+		FeePips:      poolState.FeePips,
+		TickSpacing:  poolState.TickSpacing,
+		SqrtPriceX96: poolState.SqrtPriceX96,
+		Tick:         poolState.Tick,
+		Liquidity:    poolState.Liquidity,
+		MinWordPos:   poolState.MinWordPos,
+		MaxWordPos:   poolState.MaxWordPos,
+	}
+	for _, tick := range poolState.Ticks {
+		pool.Ticks = append(pool.Ticks, ctrlutils.UniV3Tick{
+			Index:        tick.Index,
+			LiquidityNet: tick.LiquidityNet,
+		})
+	}
+	return pool, src == 0, nil
+}
+
+// addLiquidityNet adds delta to the liquidityNet of a tick, inserting it in
+// order if it was not initialized yet.
+func addLiquidityNet(ticks []univ3.Tick, index int, delta *big.Int) []univ3.Tick {
+	i, found := slices.BinarySearchFunc(ticks, index, func(t univ3.Tick, index int) int {
+		return t.Index - index
+	})
+	if found {
+		ticks[i].LiquidityNet = new(big.Int).Add(ticks[i].LiquidityNet, delta)
+		return ticks
+	}
+	return slices.Insert(ticks, i, univ3.Tick{Index: index, LiquidityNet: new(big.Int).Set(delta)})
+}
+
+// tickWordPos returns the tick bitmap word holding a tick.
+func tickWordPos(tick, tickSpacing int) int {
+	return floorDiv(floorDiv(tick, tickSpacing), 256)
+}
+
+// topicToInt24 decodes an indexed int24 event argument.
+func topicToInt24(topic common.Hash) int {
+	return int(int32(binary.BigEndian.Uint32(topic[28:])))
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,166 @@
+package poolmodels
+
+import (
+	"encoding/binary"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/WangWilly/swap-estimation/pkgs/clients/univ3"
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUniV3(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given the Uniswap V3 pool model", t, func() {
+			poolAddr := common.HexToAddress("0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640")
+			token0Addr := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48") // USDC
+			token1Addr := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2") // WETH
+
+			liquidity, _ := new(big.Int).SetString("3000000000000000000", 10)
+			poolState := &univ3.PoolState{
+				Token0:       token0Addr,
+				Token1:       token1Addr,
+				FeePips:      3000,
+				TickSpacing:  60,
+				SqrtPriceX96: new(big.Int).Lsh(big.NewInt(1), 96),
+				Tick:         0,
+				Liquidity:    liquidity,
+				Ticks: []univ3.Tick{
+					{Index: -1200, LiquidityNet: big.NewInt(2000000000000000000)},
+					{Index: -600, LiquidityNet: big.NewInt(1000000000000000000)},
+					{Index: 600, LiquidityNet: big.NewInt(-1000000000000000000)},
+					{Index: 1200, LiquidityNet: big.NewInt(-2000000000000000000)},
+				},
+				MinWordPos: -1,
+				MaxWordPos: 0,
+			}
+
+			parsedABI, err := abi.JSON(strings.NewReader(uniswapV3PoolEventsABI))
+			So(err, ShouldBeNil)
+
+			Convey("When building the log query", func() {
+				query := s.uniV3.LogQuery(poolAddr, poolState)
+
+				Convey("Then it should select the Swap, Mint and Burn events of the pool", func() {
+					So(query.Addresses, ShouldResemble, []common.Address{poolAddr})
+					So(query.Topics, ShouldResemble, [][]common.Hash{{uniV3SwapTopic, uniV3MintTopic, uniV3BurnTopic}})
+				})
+			})
+
+			Convey("When a Swap log within the loaded ticks is applied", func() {
+				sqrtPrice := new(big.Int).Lsh(big.NewInt(3), 95)
+				data, err := parsedABI.Events["Swap"].Inputs.NonIndexed().Pack(
+					big.NewInt(-1000), big.NewInt(1000), sqrtPrice, big.NewInt(5), big.NewInt(-120),
+				)
+				So(err, ShouldBeNil)
+
+				state, err := s.uniV3.ApplyLog(poolState, types.Log{
+					Topics: []common.Hash{uniV3SwapTopic, {}, {}},
+					Data:   data,
+				})
+
+				Convey("Then the price, tick and liquidity should be updated", func() {
+					So(err, ShouldBeNil)
+					next := state.(*univ3.PoolState)
+					So(next.SqrtPriceX96.String(), ShouldEqual, sqrtPrice.String())
+					So(next.Liquidity.String(), ShouldEqual, "5")
+					So(next.Tick, ShouldEqual, -120)
+					So(poolState.Tick, ShouldEqual, 0)
+				})
+			})
+
+			Convey("When a Swap log moves the price beyond the loaded ticks", func() {
+				data, err := parsedABI.Events["Swap"].Inputs.NonIndexed().Pack(
+					big.NewInt(-1000), big.NewInt(1000), big.NewInt(1), big.NewInt(5), big.NewInt(20000),
+				)
+				So(err, ShouldBeNil)
+
+				_, err = s.uniV3.ApplyLog(poolState, types.Log{
+					Topics: []common.Hash{uniV3SwapTopic, {}, {}},
+					Data:   data,
+				})
+
+				Convey("Then the state should be reported as stale", func() {
+					So(err, ShouldEqual, poolmodel.ErrStateStale)
+				})
+			})
+
+			Convey("When a Mint log around the current tick is applied", func() {
+				data, err := parsedABI.Events["Mint"].Inputs.NonIndexed().Pack(
+					common.Address{}, big.NewInt(500), big.NewInt(0), big.NewInt(0),
+				)
+				So(err, ShouldBeNil)
+
+				state, err := s.uniV3.ApplyLog(poolState, types.Log{
+					Topics: []common.Hash{uniV3MintTopic, {}, int24Topic(-600), int24Topic(60)},
+					Data:   data,
+				})
+
+				Convey("Then the ticks and active liquidity should include the position", func() {
+					So(err, ShouldBeNil)
+					next := state.(*univ3.PoolState)
+					So(next.Liquidity.String(), ShouldEqual, "3000000000000000500")
+					So(next.Ticks, ShouldHaveLength, 5)
+					So(next.Ticks[1].Index, ShouldEqual, -600)
+					So(next.Ticks[1].LiquidityNet.String(), ShouldEqual, "1000000000000000500")
+					So(next.Ticks[2].Index, ShouldEqual, 60)
+					So(next.Ticks[2].LiquidityNet.String(), ShouldEqual, "-500")
+					So(poolState.Ticks, ShouldHaveLength, 4)
+					So(poolState.Ticks[1].LiquidityNet.String(), ShouldEqual, "1000000000000000000")
+				})
+			})
+
+			Convey("When a Burn log is applied", func() {
+				_, err := s.uniV3.ApplyLog(poolState, types.Log{
+					Topics: []common.Hash{uniV3BurnTopic, {}, int24Topic(-600), int24Topic(600)},
+				})
+
+				Convey("Then the state should be reported as stale", func() {
+					So(err, ShouldEqual, poolmodel.ErrStateStale)
+				})
+			})
+
+			Convey("When quoting tokens that do not belong to the pool", func() {
+				_, err := s.uniV3.AmountOut(poolState, poolmodel.Swap{
+					Pool: poolAddr,
+					Src:  token0Addr,
+					Dst:  common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"),
+				}, big.NewInt(1000))
+
+				Convey("Then it should report an unknown token", func() {
+					So(err, ShouldEqual, poolmodel.ErrUnknownToken)
+				})
+			})
+
+			Convey("When quoting an exact input swap", func() {
+				amountOut, err := s.uniV3.AmountOut(poolState, poolmodel.Swap{
+					Pool: poolAddr,
+					Src:  token0Addr,
+					Dst:  token1Addr,
+				}, big.NewInt(1000000000000000))
+
+				Convey("Then the output should be simulated over the loaded ticks", func() {
+					So(err, ShouldBeNil)
+					So(amountOut.String(), ShouldEqual, "996668773744192")
+				})
+			})
+		})
+	})
+}
+
+// int24Topic encodes an indexed int24 event argument.
+func int24Topic(tick int) common.Hash {
+	var topic common.Hash
+	if tick < 0 {
+		for i := range topic {
+			topic[i] = 0xff
+		}
+	}
+	binary.BigEndian.PutUint32(topic[28:], uint32(int32(tick)))
+	return topic
+}
@@ -0,0 +1,140 @@
+package poolmodels
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/WangWilly/swap-estimation/controllers/estimate/ctrlutils"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/balancer"
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+const PoolTypeWeighted = "weighted"
+
+////////////////////////////////////////////////////////////////////////////////
+
+// weighted models Balancer weighted pools. Its state is a *balancer.PoolState;
+// balances live in the Vault, so any Vault log indexed by the pool id triggers
+// a refetch.
+type weighted struct {
+	weightedClient WeightedClient
+}
+
+func NewWeighted(weightedClient WeightedClient) *weighted {
+	return &weighted{
+		weightedClient: weightedClient,
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (m *weighted) Name() string {
+	return "balancer"
+}
+
+func (m *weighted) FetchState(ctx context.Context, poolAddr common.Address) (poolmodel.State, error) {
+	poolState, err := m.weightedClient.PoolState(ctx, poolAddr)
+	if err != nil {
+		return nil, err
+	}
+	if poolState == nil {
+		return nil, fmt.Errorf("pool state not found for %s", poolAddr)
+	}
+	return poolState, nil
+}
+
+func (m *weighted) LogQuery(poolAddr common.Address, state poolmodel.State) ethereum.FilterQuery {
+	poolState, ok := state.(*balancer.PoolState)
+	if !ok || poolState == nil {
+		// Without the pool id only the pool's own logs can be selected
+		return ethereum.FilterQuery{
+			Addresses: []common.Address{poolAddr},
+		}
+	}
+	return ethereum.FilterQuery{
+		Addresses: []common.Address{poolState.Vault},
+		Topics:    [][]common.Hash{{}, {poolState.PoolID}},
+	}
+}
+
+func (m *weighted) ApplyLog(_ poolmodel.State, _ types.Log) (poolmodel.State, error) {
+	return nil, poolmodel.ErrStateStale
+}
+
+func (m *weighted) AmountOut(state poolmodel.State, swap poolmodel.Swap, amountIn *big.Int) (*big.Int, error) {
+	pool, i, j, err := m.pool(state, swap)
+	if err != nil {
+		return nil, err
+	}
+	return ctrlutils.CalWeightedOutAmount(pool, i, j, amountIn)
+}
+
+func (m *weighted) AmountIn(state poolmodel.State, swap poolmodel.Swap, amountOut *big.Int) (*big.Int, error) {
+	pool, i, j, err := m.pool(state, swap)
+	if err != nil {
+		return nil, err
+	}
+	return ctrlutils.CalWeightedInAmount(pool, i, j, amountOut)
+}
+
+func (m *weighted) SpotPrice(state poolmodel.State, swap poolmodel.Swap) (*poolmodel.SpotPrice, error) {
+	pool, i, j, err := m.pool(state, swap)
+	if err != nil {
+		return nil, err
+	}
+
+	price, err := ctrlutils.CalWeightedSpotPrice(pool, i, j)
+	if err != nil {
+		return nil, err
+	}
+	return &poolmodel.SpotPrice{
+		Price:   price,
+		FeeRate: new(big.Rat).SetFrac(pool.SwapFee, big.NewInt(1e18)),
+	}, nil
+}
+
+func (m *weighted) Reserves(state poolmodel.State, swap poolmodel.Swap) (*poolmodel.Reserves, error) {
+	_, i, j, err := m.pool(state, swap)
+	if err != nil {
+		return nil, err
+	}
+	if i > j {
+		i, j = j, i
+	}
+
+	poolState := state.(*balancer.PoolState)
+	return &poolmodel.Reserves{
+		Token0:   poolState.Tokens[i],
+		Token1:   poolState.Tokens[j],
+		Reserve0: poolState.Balances[i],
+		Reserve1: poolState.Balances[j],
+	}, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// pool converts the state into the invariant input and resolves token indexes.
+func (m *weighted) pool(state poolmodel.State, swap poolmodel.Swap) (ctrlutils.WeightedPool, int, int, error) {
+	poolState, ok := state.(*balancer.PoolState)
+	if !ok || poolState == nil {
+		return ctrlutils.WeightedPool{}, 0, 0, ErrInvalidState
+	}
+
+	i, j := tokenIndex(poolState.Tokens, swap.Src), tokenIndex(poolState.Tokens, swap.Dst)
+	if i < 0 || j < 0 || i == j {
+		return ctrlutils.WeightedPool{}, 0, 0, poolmodel.ErrUnknownToken
+	}
+
+	pool := ctrlutils.WeightedPool{
+		SwapFee:  poolState.SwapFee,
+		Balances: poolState.Balances,
+		Weights:  poolState.Weights,
+	}
+	return pool, i, j, nil
+}
@@ -0,0 +1,86 @@
+package poolmodels
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/WangWilly/swap-estimation/pkgs/clients/balancer"
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWeighted(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given the Balancer weighted pool model", t, func() {
+			ctx := context.Background()
+			poolAddr := common.HexToAddress("0x5c6Ee304399DBdB9C8Ef030aB642B10820DB8F56")
+			vaultAddr := common.HexToAddress("0xBA12222222228d8Ba445958a75a0704d566BF2C8")
+			poolID := common.HexToHash("0x5c6ee304399dbdb9c8ef030ab642b10820db8f56000200000000000000000014")
+			heavyAddr := common.HexToAddress("0xba100000625a3754423978a60c9317c58a424e3D") // BAL, 80%
+			lightAddr := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2") // WETH, 20%
+
+			heavyBalance, _ := new(big.Int).SetString("1000000000000000000000000", 10)
+			lightBalance, _ := new(big.Int).SetString("200000000000000000000000", 10)
+			poolState := &balancer.PoolState{
+				Vault:  vaultAddr,
+				PoolID: poolID,
+				Tokens: []common.Address{
+					heavyAddr,
+					lightAddr,
+				},
+				Balances: []*big.Int{heavyBalance, lightBalance},
+				Weights:  []*big.Int{big.NewInt(800000000000000000), big.NewInt(200000000000000000)},
+				SwapFee:  big.NewInt(3000000000000000),
+			}
+
+			Convey("When fetching the pool state", func() {
+				s.weightedClient.EXPECT().
+					PoolState(gomock.Any(), poolAddr).
+					Return(poolState, nil)
+
+				state, err := s.weighted.FetchState(ctx, poolAddr)
+
+				Convey("Then it should return the client's snapshot", func() {
+					So(err, ShouldBeNil)
+					So(state, ShouldEqual, poolState)
+				})
+			})
+
+			Convey("When building the log query", func() {
+				query := s.weighted.LogQuery(poolAddr, poolState)
+
+				Convey("Then it should select the Vault logs indexed by the pool id", func() {
+					So(query.Addresses, ShouldResemble, []common.Address{vaultAddr})
+					So(query.Topics, ShouldHaveLength, 2)
+					So(query.Topics[0], ShouldBeEmpty)
+					So(query.Topics[1], ShouldResemble, []common.Hash{poolID})
+				})
+			})
+
+			Convey("When a Vault log is applied", func() {
+				_, err := s.weighted.ApplyLog(poolState, types.Log{})
+
+				Convey("Then the state should be reported as stale", func() {
+					So(err, ShouldEqual, poolmodel.ErrStateStale)
+				})
+			})
+
+			Convey("When quoting an exact input swap", func() {
+				amountOut, err := s.weighted.AmountOut(poolState, poolmodel.Swap{
+					Pool: poolAddr,
+					Src:  heavyAddr,
+					Dst:  lightAddr,
+				}, new(big.Int).Exp(big.NewInt(10), big.NewInt(21), nil))
+
+				Convey("Then the output should follow the weighted invariant", func() {
+					So(err, ShouldBeNil)
+					So(amountOut.String(), ShouldEqual, "795615939202531000000")
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,289 @@
+package estimate
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/WangWilly/swap-estimation/controllers/estimate/ctrlutils"
+	"github.com/WangWilly/swap-estimation/controllers/estimate/poolmodels"
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+type BatchItem struct {
+	PoolAddr      string `json:"pool"`
+	SrcTokenAddr  string `json:"src"`
+	DestTokenAddr string `json:"dst"`
+	SrcAmountStr  string `json:"src_amount"`
+}
+
+// BatchResult is the outcome of one batch item: the estimated destination
+// amount, or the error that item failed with.
+type BatchResult struct {
+	AmountOut string `json:"amount_out,omitempty"`
+	// Set when a transfer tax was applied to either token
+	Taxed bool `json:"taxed,omitempty"`
+	// The block the pool state was read at, when known
+	BlockNumber uint64 `json:"block_number,omitempty"`
+	BlockHash   string `json:"block_hash,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+var (
+	errBatchInvalidPoolAddr     = errors.New("invalid pool address format")
+	errBatchInvalidSrcAddr      = errors.New("invalid source token address format")
+	errBatchInvalidDestAddr     = errors.New("invalid destination token address format")
+	errBatchInvalidPoolChecksum = errors.New("invalid pool address checksum")
+	errBatchInvalidSrcChecksum  = errors.New("invalid source token address checksum")
+	errBatchInvalidDestChecksum = errors.New("invalid destination token address checksum")
+	errBatchInvalidPairAddr     = errors.New("invalid Uniswap V2 pair address")
+	errBatchInvalidSrcAmount    = errors.New("invalid source amount")
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (c *Controller) PostBatch(ctx *gin.Context) {
+	logger := log.Ctx(ctx.Request.Context())
+	logger.Debug().Msg("Received batch estimate request")
+
+	var items []BatchItem
+	if err := ctx.ShouldBindJSON(&items); err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to bind request body")
+		ctx.JSON(400, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if len(items) == 0 {
+		logger.Error().Msg("Empty batch")
+		ctx.JSON(400, gin.H{"error": "empty batch"})
+		return
+	}
+
+	if len(items) > c.cfg.BatchMaxItems {
+		logger.Error().
+			Int("item_count", len(items)).
+			Int("max_items", c.cfg.BatchMaxItems).
+			Msg("Too many batch items")
+		ctx.JSON(400, gin.H{"error": "too many batch items"})
+		return
+	}
+
+	model, ok := c.models.Get(poolmodels.PoolTypeUniV2)
+	if !ok {
+		logger.Error().Msg("No Uniswap V2 pool model registered")
+		ctx.JSON(500, gin.H{"error": "failed to get reserve pair"})
+		return
+	}
+
+	////////////////////////////////////////////////////////////////////////////
+
+	results := make([]BatchResult, len(items))
+	swaps := make([]poolmodel.Swap, len(items))
+	amounts := make([]*big.Int, len(items))
+	pools := make([]common.Address, 0, len(items))
+	seenPools := make(map[common.Address]bool, len(items))
+	for i, item := range items {
+		swap, amount, err := c.batchSwap(item)
+		if err != nil {
+			logger.Debug().
+				Err(err).
+				Int("item", i).
+				Msg("Invalid batch item")
+			results[i].Error = err.Error()
+			continue
+		}
+		swaps[i], amounts[i] = swap, amount
+		if !seenPools[swap.Pool] {
+			seenPools[swap.Pool] = true
+			pools = append(pools, swap.Pool)
+		}
+	}
+
+	states := c.batchPoolStates(ctx.Request.Context(), pools, model, false)
+
+	for i := range items {
+		if amounts[i] == nil {
+			continue
+		}
+
+		state, ok := states[swaps[i].Pool]
+		if !ok {
+			results[i].Error = "failed to get reserve pair"
+			continue
+		}
+
+		srcTax, srcTaxed := c.cfg.TokenTaxes.Find(swaps[i].Src)
+		dstTax, dstTaxed := c.cfg.TokenTaxes.Find(swaps[i].Dst)
+		amountOut, err := calTaxedAmount(model, state, swaps[i], amounts[i], false, srcTax.SellBps, dstTax.BuyBps)
+		switch {
+		case err == nil:
+			results[i].AmountOut = amountOut.String()
+			results[i].Taxed = srcTaxed || dstTaxed
+			if reserves, err := model.Reserves(state, swaps[i]); err == nil && reserves.BlockNumber != 0 {
+				results[i].BlockNumber = reserves.BlockNumber
+				results[i].BlockHash = reserves.BlockHash.Hex()
+			}
+		case errors.Is(err, poolmodel.ErrUnknownToken):
+			results[i].Error = "invalid pool tokens"
+		case errors.Is(err, ctrlutils.ErrInsufficientLiquidity):
+			results[i].Error = "insufficient pool liquidity"
+		case errors.Is(err, ctrlutils.ErrZeroReserve):
+			results[i].Error = "pool has no liquidity"
+		case errors.Is(err, ctrlutils.ErrReserveOverflow):
+			results[i].Error = "invalid pool reserves"
+		case errors.Is(err, ctrlutils.ErrInvariantViolated):
+			results[i].Error = "quote failed the pool invariant check"
+		default:
+			logger.Error().
+				Err(err).
+				Int("item", i).
+				Msg("Failed to calculate output amount")
+			results[i].Error = "failed to calculate output amount"
+		}
+	}
+
+	ctx.JSON(200, results)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// batchSwap validates a batch item the same way Get validates its query.
+func (c *Controller) batchSwap(item BatchItem) (poolmodel.Swap, *big.Int, error) {
+	poolAddr, err := c.parseAddr(item.PoolAddr)
+	if err != nil {
+		return poolmodel.Swap{}, nil, batchAddrError(err, errBatchInvalidPoolAddr, errBatchInvalidPoolChecksum)
+	}
+	srcTokenAddr, err := c.parseAddr(item.SrcTokenAddr)
+	if err != nil {
+		return poolmodel.Swap{}, nil, batchAddrError(err, errBatchInvalidSrcAddr, errBatchInvalidSrcChecksum)
+	}
+	destTokenAddr, err := c.parseAddr(item.DestTokenAddr)
+	if err != nil {
+		return poolmodel.Swap{}, nil, batchAddrError(err, errBatchInvalidDestAddr, errBatchInvalidDestChecksum)
+	}
+
+	dex, ok := c.cfg.Dexes.MatchPair(srcTokenAddr, destTokenAddr, poolAddr)
+	if !ok {
+		return poolmodel.Swap{}, nil, errBatchInvalidPairAddr
+	}
+
+	amount, ok := new(big.Int).SetString(item.SrcAmountStr, 10)
+	if !ok {
+		return poolmodel.Swap{}, nil, errBatchInvalidSrcAmount
+	}
+
+	c.rememberPairTokens(poolAddr, srcTokenAddr, destTokenAddr, dex.Name)
+	return poolmodel.Swap{
+		Pool:   poolAddr,
+		Src:    srcTokenAddr,
+		Dst:    destTokenAddr,
+		FeeBps: c.resolveFeeBps(poolAddr, dex.Name),
+	}, amount, nil
+}
+
+// batchAddrError picks the item error of an address that failed to parse.
+func batchAddrError(err, formatErr, checksumErr error) error {
+	if errors.Is(err, ctrlutils.ErrInvalidAddrChecksum) {
+		return checksumErr
+	}
+	return formatErr
+}
+
+// batchPoolStates reads the cached pools of a batch as one snapshot and
+// fetches the others at one pinned block when the model supports it, so that
+// every pool is read once and all items on it see the same state. Pools the
+// pinned read misses are fetched through the singleflight group, at most
+// BatchFetchConcurrency at once, and pools that cannot be fetched at all are
+// left out. With checkDeployed
+// set, uncached pools are fetched only once their contract is found, for
+// derived addresses that may not hold a pool.
+func (c *Controller) batchPoolStates(
+	ctx context.Context,
+	pools []common.Address,
+	model poolmodel.PoolModel,
+	checkDeployed bool,
+) map[common.Address]poolmodel.State {
+	logger := log.Ctx(ctx)
+
+	states := c.ethWssClient.SnapshotPools(ctx, pools)
+	if states == nil {
+		states = make(map[common.Address]poolmodel.State, len(pools))
+	}
+
+	missingPools := make([]common.Address, 0, len(pools))
+	for _, poolAddr := range pools {
+		if _, cached := states[poolAddr]; !cached {
+			missingPools = append(missingPools, poolAddr)
+		}
+	}
+
+	if checkDeployed {
+		missingPools = c.deployedPools(ctx, missingPools, model)
+	}
+
+	if batchModel, ok := model.(poolmodel.BatchPoolModel); ok && len(missingPools) > 0 {
+		fetched, err := batchModel.FetchStates(ctx, missingPools)
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Int("pool_count", len(missingPools)).
+				Msg("Failed to fetch batch pool states at a pinned block")
+		}
+
+		unfetchedPools := make([]common.Address, 0, len(missingPools))
+		for _, poolAddr := range missingPools {
+			state, ok := fetched[poolAddr]
+			if !ok {
+				unfetchedPools = append(unfetchedPools, poolAddr)
+				continue
+			}
+			states[poolAddr] = state
+			c.ethWssClient.RegPool(context.Background(), poolAddr, model, state)
+		}
+		missingPools = unfetchedPools
+	}
+
+	var (
+		wg         sync.WaitGroup
+		statesLock sync.Mutex
+	)
+	sem := make(chan struct{}, max(c.cfg.BatchFetchConcurrency, 1))
+	for _, poolAddr := range missingPools {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			state, err := c.fetchPoolState(ctx, poolAddr, model)
+			if err != nil {
+				logger.Error().
+					Err(err).
+					Str("pool_address", poolAddr.Hex()).
+					Msg("Failed to get batch pool state")
+				return
+			}
+
+			statesLock.Lock()
+			states[poolAddr] = state
+			statesLock.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	logger.Debug().
+		Int("pool_count", len(pools)).
+		Int("resolved_count", len(states)).
+		Msg("Batch pool states resolved")
+	return states
+}
@@ -0,0 +1,281 @@
+package estimate
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/WangWilly/swap-estimation/controllers/estimate/ctrlutils"
+	"github.com/WangWilly/swap-estimation/pkgs/clients/eth"
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPostBatch(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given a batch estimate endpoint", t, func() {
+			// Setup test data
+			wethAddr := "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+			usdcAddr := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+			usdtAddr := "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+
+			wethUsdcPoolAddr := ctrlutils.ComputeUniV2PairAddr(common.HexToAddress(wethAddr), common.HexToAddress(usdcAddr))
+			usdcUsdtPoolAddr := ctrlutils.ComputeUniV2PairAddr(common.HexToAddress(usdcAddr), common.HexToAddress(usdtAddr))
+
+			wethUsdcPair := &eth.ReservePair{
+				Reserve0: big.NewInt(200000000000), // 200,000 USDC (6 decimals)
+				Reserve1: new(big.Int),             // 100 ETH (18 decimals)
+			}
+			wethUsdcPair.Reserve1.SetString("100000000000000000000", 10)
+
+			usdcUsdtPair := &eth.ReservePair{
+				Reserve0: big.NewInt(1000000000000), // 1,000,000 USDC
+				Reserve1: big.NewInt(1000000000000), // 1,000,000 USDT
+			}
+
+			wethUsdcMetadata := &eth.PairMetadata{Token0: common.HexToAddress(usdcAddr), Token1: common.HexToAddress(wethAddr)}
+			usdcUsdtMetadata := &eth.PairMetadata{Token0: common.HexToAddress(usdcAddr), Token1: common.HexToAddress(usdtAddr)}
+
+			items := []BatchItem{
+				{PoolAddr: wethUsdcPoolAddr.Hex(), SrcTokenAddr: wethAddr, DestTokenAddr: usdcAddr, SrcAmountStr: "1000000000000000000"},
+				{PoolAddr: usdcUsdtPoolAddr.Hex(), SrcTokenAddr: usdcAddr, DestTokenAddr: usdtAddr, SrcAmountStr: "1000000"},
+				{PoolAddr: "invalid", SrcTokenAddr: wethAddr, DestTokenAddr: usdcAddr, SrcAmountStr: "1"},
+				{PoolAddr: wethUsdcPoolAddr.Hex(), SrcTokenAddr: usdcAddr, DestTokenAddr: wethAddr, SrcAmountStr: "2000000000"},
+				{PoolAddr: wethUsdcPoolAddr.Hex(), SrcTokenAddr: wethAddr, DestTokenAddr: usdcAddr, SrcAmountStr: "abc"},
+			}
+
+			Convey("When every pool is cached", func() {
+				s.ethWssClient.EXPECT().
+					SnapshotPools(gomock.Any(), []common.Address{wethUsdcPoolAddr, usdcUsdtPoolAddr}).
+					Return(map[common.Address]poolmodel.State{
+						wethUsdcPoolAddr: wethUsdcPair,
+						usdcUsdtPoolAddr: usdcUsdtPair,
+					})
+
+				var results []BatchResult
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodPost,
+					"/estimate/batch",
+					items,
+					&results,
+				)
+
+				Convey("Then the results should follow the order of the items", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(results, ShouldResemble, []BatchResult{
+						{AmountOut: "1974316068"},
+						{AmountOut: "996999"},
+						{Error: "invalid pool address format"},
+						{AmountOut: "987158034397061298"},
+						{Error: "invalid source amount"},
+					})
+				})
+			})
+
+			Convey("When a token is taxed", func() {
+				s.controller.cfg.TokenTaxes = ctrlutils.TokenTaxList{
+					{Token: common.HexToAddress(usdcAddr), SellBps: 300, BuyBps: 300},
+				}
+				defer func() { s.controller.cfg.TokenTaxes = nil }()
+
+				s.ethWssClient.EXPECT().
+					SnapshotPools(gomock.Any(), []common.Address{wethUsdcPoolAddr}).
+					Return(map[common.Address]poolmodel.State{
+						wethUsdcPoolAddr: wethUsdcPair,
+					})
+
+				var results []BatchResult
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodPost,
+					"/estimate/batch",
+					items[:1],
+					&results,
+				)
+
+				Convey("Then the result should be net of the tax and flagged", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(results, ShouldResemble, []BatchResult{
+						{AmountOut: "1915086586", Taxed: true},
+					})
+				})
+			})
+
+			Convey("When a pool is not cached", func() {
+				pinnedPair := *usdcUsdtPair
+				pinnedPair.BlockNumber = 15000000
+				pinnedPair.BlockHash = common.HexToHash("0xabc")
+
+				s.ethWssClient.EXPECT().
+					SnapshotPools(gomock.Any(), []common.Address{wethUsdcPoolAddr, usdcUsdtPoolAddr}).
+					Return(map[common.Address]poolmodel.State{
+						wethUsdcPoolAddr: wethUsdcPair,
+					})
+				s.ethClient.EXPECT().
+					UniV2ReservePairs(gomock.Any(), []common.Address{usdcUsdtPoolAddr}).
+					Return(map[common.Address]*eth.ReservePair{usdcUsdtPoolAddr: &pinnedPair}, nil)
+				s.ethClient.EXPECT().
+					UniV2PairMetadata(gomock.Any(), usdcUsdtPoolAddr).
+					Return(usdcUsdtMetadata, nil)
+				s.ethWssClient.EXPECT().
+					RegPool(gomock.Any(), usdcUsdtPoolAddr, gomock.Any(), gomock.Any()).
+					Return(nil)
+
+				var results []BatchResult
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodPost,
+					"/estimate/batch",
+					items[:2],
+					&results,
+				)
+
+				Convey("Then the missing pool should be fetched once at a pinned block and registered", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(results, ShouldResemble, []BatchResult{
+						{AmountOut: "1974316068"},
+						{AmountOut: "996999", BlockNumber: 15000000, BlockHash: common.HexToHash("0xabc").Hex()},
+					})
+				})
+			})
+
+			Convey("When a pool cannot be fetched", func() {
+				s.ethWssClient.EXPECT().
+					SnapshotPools(gomock.Any(), []common.Address{wethUsdcPoolAddr, usdcUsdtPoolAddr}).
+					Return(map[common.Address]poolmodel.State{})
+				s.ethClient.EXPECT().
+					UniV2ReservePairs(gomock.Any(), []common.Address{wethUsdcPoolAddr, usdcUsdtPoolAddr}).
+					Return(map[common.Address]*eth.ReservePair{wethUsdcPoolAddr: wethUsdcPair}, nil)
+				s.ethClient.EXPECT().
+					UniV2PairMetadata(gomock.Any(), wethUsdcPoolAddr).
+					Return(wethUsdcMetadata, nil)
+				s.ethWssClient.EXPECT().
+					RegPool(gomock.Any(), wethUsdcPoolAddr, gomock.Any(), gomock.Any()).
+					Return(nil)
+				// The pool the pinned read missed is fetched on its own
+				s.ethClient.EXPECT().
+					UniV2ReservePair(gomock.Any(), usdcUsdtPoolAddr).
+					Return(nil, errors.New("connection refused"))
+
+				var results []BatchResult
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodPost,
+					"/estimate/batch",
+					items[:2],
+					&results,
+				)
+
+				Convey("Then only the items on that pool should fail", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(results, ShouldResemble, []BatchResult{
+						{AmountOut: "1974316068"},
+						{Error: "failed to get reserve pair"},
+					})
+				})
+			})
+
+			Convey("When the pinned read fails and the pools are fetched on their own", func() {
+				s.controller.cfg.BatchFetchConcurrency = 1
+				defer func() { s.controller.cfg.BatchFetchConcurrency = 16 }()
+
+				s.ethWssClient.EXPECT().
+					SnapshotPools(gomock.Any(), []common.Address{wethUsdcPoolAddr, usdcUsdtPoolAddr}).
+					Return(map[common.Address]poolmodel.State{})
+				s.ethClient.EXPECT().
+					UniV2ReservePairs(gomock.Any(), []common.Address{wethUsdcPoolAddr, usdcUsdtPoolAddr}).
+					Return(nil, errors.New("503 Service Unavailable"))
+
+				// Count the fetches running at once
+				var inFlight, maxInFlight atomic.Int32
+				s.ethClient.EXPECT().
+					UniV2ReservePair(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ common.Address) (*eth.ReservePair, error) {
+						n := inFlight.Add(1)
+						defer inFlight.Add(-1)
+						if n > maxInFlight.Load() {
+							maxInFlight.Store(n)
+						}
+						time.Sleep(10 * time.Millisecond)
+						return nil, errors.New("503 Service Unavailable")
+					}).
+					Times(2)
+
+				var results []BatchResult
+				resCode := s.testServer.MustDo(
+					t,
+					http.MethodPost,
+					"/estimate/batch",
+					items[:2],
+					&results,
+				)
+
+				Convey("Then no more pools than the limit should be fetched at once", func() {
+					So(resCode, ShouldEqual, http.StatusOK)
+					So(maxInFlight.Load(), ShouldEqual, 1)
+					So(results, ShouldResemble, []BatchResult{
+						{Error: "failed to get reserve pair"},
+						{Error: "failed to get reserve pair"},
+					})
+				})
+			})
+
+			Convey("When making a request with an empty batch", func() {
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodPost,
+					"/estimate/batch",
+					[]BatchItem{},
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate the batch is empty", func() {
+					So(errorResponse["error"], ShouldEqual, "empty batch")
+				})
+			})
+
+			Convey("When making a request with too many items", func() {
+				tooMany := make([]BatchItem, s.controller.cfg.BatchMaxItems+1)
+
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodPost,
+					"/estimate/batch",
+					tooMany,
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate the batch is too large", func() {
+					So(errorResponse["error"], ShouldEqual, "too many batch items")
+				})
+			})
+
+			Convey("When making a request with a malformed body", func() {
+				var errorResponse map[string]string
+				s.testServer.MustDoAndMatchCode(
+					t,
+					http.MethodPost,
+					"/estimate/batch",
+					map[string]string{"pool": wethUsdcPoolAddr.Hex()},
+					&errorResponse,
+					http.StatusBadRequest,
+				)
+
+				Convey("Then the response should indicate an invalid body", func() {
+					So(errorResponse["error"], ShouldEqual, "invalid request body")
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,20 @@
+package balancer
+
+////////////////////////////////////////////////////////////////////////////////
+
+type Config struct {
+	VaultAddress string `env:"VAULT_ADDRESS,default=0xBA12222222228d8Ba445958a75a0704d566BF2C8"`
+}
+
+type client struct {
+	cfg Config
+
+	gethClient GethClient
+}
+
+func New(cfg Config, gethClient GethClient) *client {
+	return &client{
+		cfg:        cfg,
+		gethClient: gethClient,
+	}
+}
@@ -0,0 +1,13 @@
+package balancer
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+//go:generate mockgen -source=interface.go -destination=interface_mock.go -package=balancer
+type GethClient interface {
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
@@ -0,0 +1,125 @@
+package balancer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// PoolState is a snapshot of a Balancer weighted pool. Weights are normalized
+// and SwapFee is a share of the input, both with 18 decimals.
+type PoolState struct {
+	Vault    common.Address
+	PoolID   common.Hash
+	Tokens   []common.Address
+	Balances []*big.Int
+	Weights  []*big.Int
+	SwapFee  *big.Int
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+const weightedPoolABI = `[
+	{"inputs":[],"name":"getPoolId","outputs":[{"internalType":"bytes32","name":"","type":"bytes32"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"getNormalizedWeights","outputs":[{"internalType":"uint256[]","name":"","type":"uint256[]"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"getSwapFeePercentage","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"}
+]`
+
+const vaultABI = `[{"inputs":[{"internalType":"bytes32","name":"poolId","type":"bytes32"}],"name":"getPoolTokens","outputs":[{"internalType":"contract IERC20[]","name":"tokens","type":"address[]"},{"internalType":"uint256[]","name":"balances","type":"uint256[]"},{"internalType":"uint256","name":"lastChangeBlock","type":"uint256"}],"stateMutability":"view","type":"function"}]`
+
+////////////////////////////////////////////////////////////////////////////////
+
+// PoolState reads the tokens, balances, normalized weights and swap fee of a
+// Balancer weighted pool from the pool and the Vault.
+func (c *client) PoolState(
+	ctx context.Context,
+	poolAddress common.Address,
+) (*PoolState, error) {
+	logger := log.Ctx(ctx)
+	logger.Debug().
+		Str("pool_address", poolAddress.Hex()).
+		Msg("Reading Balancer weighted pool state")
+
+	vaultAddress := common.HexToAddress(c.cfg.VaultAddress)
+	poolABI, err := abi.JSON(strings.NewReader(weightedPoolABI))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to parse Balancer Weighted Pool ABI")
+		return nil, fmt.Errorf("failed to parse ABI: %v", err)
+	}
+	parsedVaultABI, err := abi.JSON(strings.NewReader(vaultABI))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to parse Balancer Vault ABI")
+		return nil, fmt.Errorf("failed to parse ABI: %v", err)
+	}
+
+	call := func(parsedABI abi.ABI, to common.Address, method string, args ...any) ([]any, error) {
+		data, err := parsedABI.Pack(method, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack %s: %v", method, err)
+		}
+		res, err := c.gethClient.CallContract(ctx, ethereum.CallMsg{To: &to, Data: data}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to call %s: %v", method, err)
+		}
+		out, err := parsedABI.Unpack(method, res)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack %s: %v", method, err)
+		}
+		return out, nil
+	}
+
+	state := &PoolState{Vault: vaultAddress}
+
+	poolID, err := call(poolABI, poolAddress, "getPoolId")
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to read pool id")
+		return nil, err
+	}
+	state.PoolID = common.Hash(poolID[0].([32]byte))
+
+	weights, err := call(poolABI, poolAddress, "getNormalizedWeights")
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to read normalized weights")
+		return nil, err
+	}
+	state.Weights = weights[0].([]*big.Int)
+
+	swapFee, err := call(poolABI, poolAddress, "getSwapFeePercentage")
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to read swap fee")
+		return nil, err
+	}
+	state.SwapFee = swapFee[0].(*big.Int)
+
+	poolTokens, err := call(parsedVaultABI, vaultAddress, "getPoolTokens", state.PoolID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to read pool tokens")
+		return nil, err
+	}
+	state.Tokens = poolTokens[0].([]common.Address)
+	state.Balances = poolTokens[1].([]*big.Int)
+
+	if len(state.Tokens) < 2 || len(state.Tokens) != len(state.Weights) {
+		logger.Error().
+			Int("tokens", len(state.Tokens)).
+			Int("weights", len(state.Weights)).
+			Msg("Unexpected Balancer pool layout")
+		return nil, fmt.Errorf("pool has %d tokens and %d weights", len(state.Tokens), len(state.Weights))
+	}
+
+	logger.Debug().
+		Str("pool_address", poolAddress.Hex()).
+		Str("pool_id", state.PoolID.Hex()).
+		Int("tokens", len(state.Tokens)).
+		Msg("Balancer weighted pool state details")
+
+	return state, nil
+}
@@ -0,0 +1,103 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPoolState(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given the PoolState function", t, func() {
+			ctx := context.Background()
+			poolAddr := common.HexToAddress("0x5c6Ee304399DBdB9C8Ef030aB642B10820DB8F56") // BAL/WETH 80/20
+			poolID := common.HexToHash("0x5c6ee304399dbdb9c8ef030ab642b10820db8f56000200000000000000000014")
+			balAddr := common.HexToAddress("0xba100000625a3754423978a60c9317c58a424e3D")
+			wethAddr := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+
+			poolABI, err := abi.JSON(strings.NewReader(weightedPoolABI))
+			So(err, ShouldBeNil)
+			parsedVaultABI, err := abi.JSON(strings.NewReader(vaultABI))
+			So(err, ShouldBeNil)
+
+			weights := []*big.Int{big.NewInt(800000000000000000), big.NewInt(200000000000000000)}
+			balances := []*big.Int{big.NewInt(4000), big.NewInt(1000)}
+
+			respond := func(c C, call ethereum.CallMsg) ([]byte, error) {
+				if *call.To == common.HexToAddress(s.client.cfg.VaultAddress) {
+					method, err := parsedVaultABI.MethodById(call.Data[:4])
+					if err != nil {
+						return nil, err
+					}
+					args, err := method.Inputs.Unpack(call.Data[4:])
+					if err != nil {
+						return nil, err
+					}
+					c.So(common.Hash(args[0].([32]byte)), ShouldEqual, poolID)
+					return method.Outputs.Pack([]common.Address{balAddr, wethAddr}, balances, big.NewInt(1))
+				}
+
+				c.So(*call.To, ShouldEqual, poolAddr)
+				method, err := poolABI.MethodById(call.Data[:4])
+				if err != nil {
+					return nil, err
+				}
+				switch method.Name {
+				case "getPoolId":
+					return method.Outputs.Pack([32]byte(poolID))
+				case "getNormalizedWeights":
+					return method.Outputs.Pack(weights)
+				case "getSwapFeePercentage":
+					return method.Outputs.Pack(big.NewInt(3000000000000000))
+				}
+				return nil, errors.New("unexpected method " + method.Name)
+			}
+
+			Convey("When reading a two-token weighted pool", func(c C) {
+				s.gethClient.EXPECT().
+					CallContract(gomock.Any(), gomock.Any(), nil).
+					DoAndReturn(func(_ context.Context, call ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+						return respond(c, call)
+					}).
+					Times(4) // 3 pool fields and the Vault pool tokens
+
+				result, err := s.client.PoolState(ctx, poolAddr)
+
+				Convey("Then it should return the pool tokens, balances and weights", func() {
+					So(err, ShouldBeNil)
+					So(result, ShouldNotBeNil)
+					So(result.Vault, ShouldEqual, common.HexToAddress(s.client.cfg.VaultAddress))
+					So(result.PoolID, ShouldEqual, poolID)
+					So(result.Tokens, ShouldResemble, []common.Address{balAddr, wethAddr})
+					So(result.Balances[0].String(), ShouldEqual, "4000")
+					So(result.Balances[1].String(), ShouldEqual, "1000")
+					So(result.Weights[0].String(), ShouldEqual, "800000000000000000")
+					So(result.Weights[1].String(), ShouldEqual, "200000000000000000")
+					So(result.SwapFee.String(), ShouldEqual, "3000000000000000")
+				})
+			})
+
+			Convey("When the contract call fails", func() {
+				s.gethClient.EXPECT().
+					CallContract(gomock.Any(), gomock.Any(), nil).
+					Return(nil, errors.New("execution reverted"))
+
+				result, err := s.client.PoolState(ctx, poolAddr)
+
+				Convey("Then it should return an error", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldContainSubstring, "failed to call getPoolId")
+					So(result, ShouldBeNil)
+				})
+			})
+		})
+	})
+}
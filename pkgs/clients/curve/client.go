@@ -0,0 +1,21 @@
+package curve
+
+////////////////////////////////////////////////////////////////////////////////
+
+type Config struct {
+	// Maximum number of coins probed through coins(i)
+	MaxCoins int `env:"MAX_COINS,default=8"`
+}
+
+type client struct {
+	cfg Config
+
+	gethClient GethClient
+}
+
+func New(cfg Config, gethClient GethClient) *client {
+	return &client{
+		cfg:        cfg,
+		gethClient: gethClient,
+	}
+}
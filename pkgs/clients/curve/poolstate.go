@@ -0,0 +1,171 @@
+package curve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/WangWilly/swap-estimation/pkgs/clients/rpcpool"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// PoolState is a snapshot of a Curve StableSwap pool. Fee is in 1e10 units
+// and Decimals holds the decimals of each coin.
+type PoolState struct {
+	A        *big.Int
+	Fee      *big.Int
+	Coins    []common.Address
+	Balances []*big.Int
+	Decimals []uint8
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+const curvePoolABI = `[
+	{"name":"A","outputs":[{"type":"uint256","name":""}],"inputs":[],"stateMutability":"view","type":"function"},
+	{"name":"fee","outputs":[{"type":"uint256","name":""}],"inputs":[],"stateMutability":"view","type":"function"},
+	{"name":"coins","outputs":[{"type":"address","name":""}],"inputs":[{"type":"uint256","name":"arg0"}],"stateMutability":"view","type":"function"},
+	{"name":"balances","outputs":[{"type":"uint256","name":""}],"inputs":[{"type":"uint256","name":"arg0"}],"stateMutability":"view","type":"function"}
+]`
+
+const erc20DecimalsABI = `[{"inputs":[],"name":"decimals","outputs":[{"internalType":"uint8","name":"","type":"uint8"}],"stateMutability":"view","type":"function"}]`
+
+// Placeholder address Curve pools use for native ether
+var nativeEthAddr = common.HexToAddress("0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE")
+
+var errNoMoreCoins = errors.New("no more coins")
+
+////////////////////////////////////////////////////////////////////////////////
+
+// PoolState reads the amplification coefficient, the fee, the coins and their
+// balances of a Curve StableSwap pool.
+func (c *client) PoolState(
+	ctx context.Context,
+	poolAddress common.Address,
+) (*PoolState, error) {
+	logger := log.Ctx(ctx)
+	logger.Debug().
+		Str("pool_address", poolAddress.Hex()).
+		Msg("Reading Curve pool state")
+
+	poolABI, err := abi.JSON(strings.NewReader(curvePoolABI))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to parse Curve Pool ABI")
+		return nil, fmt.Errorf("failed to parse ABI: %v", err)
+	}
+	tokenABI, err := abi.JSON(strings.NewReader(erc20DecimalsABI))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to parse ERC20 ABI")
+		return nil, fmt.Errorf("failed to parse ABI: %v", err)
+	}
+
+	call := func(parsedABI abi.ABI, to common.Address, method string, args ...any) ([]any, error) {
+		data, err := parsedABI.Pack(method, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack %s: %v", method, err)
+		}
+		res, err := c.gethClient.CallContract(ctx, ethereum.CallMsg{To: &to, Data: data}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to call %s: %v", method, err)
+		}
+		out, err := parsedABI.Unpack(method, res)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack %s: %v", method, err)
+		}
+		return out, nil
+	}
+
+	state := &PoolState{}
+
+	amp, err := call(poolABI, poolAddress, "A")
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to read amplification coefficient")
+		return nil, err
+	}
+	state.A = amp[0].(*big.Int)
+
+	fee, err := call(poolABI, poolAddress, "fee")
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to read fee")
+		return nil, err
+	}
+	state.Fee = fee[0].(*big.Int)
+
+	// Probe coins(i) until the pool reverts past its last coin
+	for i := range c.cfg.MaxCoins {
+		coin, err := c.coin(ctx, poolABI, poolAddress, i)
+		if errors.Is(err, errNoMoreCoins) {
+			break
+		}
+		if err != nil {
+			logger.Error().Err(err).Int("coin_index", i).Msg("Failed to read coin")
+			return nil, err
+		}
+
+		balance, err := call(poolABI, poolAddress, "balances", big.NewInt(int64(i)))
+		if err != nil {
+			logger.Error().Err(err).Int("coin_index", i).Msg("Failed to read balance")
+			return nil, err
+		}
+
+		decimals := uint8(18)
+		if coin != nativeEthAddr {
+			res, err := call(tokenABI, coin, "decimals")
+			if err != nil {
+				logger.Error().Err(err).Str("coin", coin.Hex()).Msg("Failed to read coin decimals")
+				return nil, err
+			}
+			decimals = res[0].(uint8)
+		}
+
+		state.Coins = append(state.Coins, coin)
+		state.Balances = append(state.Balances, balance[0].(*big.Int))
+		state.Decimals = append(state.Decimals, decimals)
+	}
+
+	if len(state.Coins) < 2 {
+		logger.Error().Int("coins", len(state.Coins)).Msg("Curve pool has too few coins")
+		return nil, fmt.Errorf("curve pool has %d coins", len(state.Coins))
+	}
+
+	logger.Debug().
+		Str("pool_address", poolAddress.Hex()).
+		Str("a", state.A.String()).
+		Str("fee", state.Fee.String()).
+		Int("coins", len(state.Coins)).
+		Msg("Curve pool state details")
+
+	return state, nil
+}
+
+// coin returns coins(i) of the pool, or errNoMoreCoins when the pool reverts
+// because i is past its last coin.
+func (c *client) coin(ctx context.Context, poolABI abi.ABI, poolAddress common.Address, i int) (common.Address, error) {
+	data, err := poolABI.Pack("coins", big.NewInt(int64(i)))
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to pack coins: %v", err)
+	}
+	res, err := c.gethClient.CallContract(ctx, ethereum.CallMsg{To: &poolAddress, Data: data}, nil)
+	if err != nil {
+		if rpcpool.IsRevertError(err) {
+			return common.Address{}, errNoMoreCoins
+		}
+		return common.Address{}, fmt.Errorf("failed to call coins: %v", err)
+	}
+	if len(res) == 0 {
+		return common.Address{}, errNoMoreCoins
+	}
+
+	out, err := poolABI.Unpack("coins", res)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to unpack coins: %v", err)
+	}
+	return out[0].(common.Address), nil
+}
@@ -0,0 +1,114 @@
+package curve
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPoolState(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given the PoolState function", t, func() {
+			ctx := context.Background()
+			poolAddr := common.HexToAddress("0xbEbc44782C7dB0a1A60Cb6fe97d0b483032FF1C7") // 3pool
+			daiAddr := common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+			usdcAddr := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+
+			poolABI, err := abi.JSON(strings.NewReader(curvePoolABI))
+			So(err, ShouldBeNil)
+			tokenABI, err := abi.JSON(strings.NewReader(erc20DecimalsABI))
+			So(err, ShouldBeNil)
+
+			coins := []common.Address{daiAddr, usdcAddr}
+			balances := []*big.Int{big.NewInt(5000), big.NewInt(7000)}
+			decimals := map[common.Address]uint8{daiAddr: 18, usdcAddr: 6}
+
+			respond := func(call ethereum.CallMsg) ([]byte, error) {
+				if *call.To != poolAddr {
+					method, err := tokenABI.MethodById(call.Data[:4])
+					if err != nil {
+						return nil, err
+					}
+					return method.Outputs.Pack(decimals[*call.To])
+				}
+
+				method, err := poolABI.MethodById(call.Data[:4])
+				if err != nil {
+					return nil, err
+				}
+				switch method.Name {
+				case "A":
+					return method.Outputs.Pack(big.NewInt(2000))
+				case "fee":
+					return method.Outputs.Pack(big.NewInt(4000000))
+				case "coins", "balances":
+					args, err := method.Inputs.Unpack(call.Data[4:])
+					if err != nil {
+						return nil, err
+					}
+					i := args[0].(*big.Int).Int64()
+					if i >= int64(len(coins)) {
+						return nil, errors.New("execution reverted")
+					}
+					if method.Name == "coins" {
+						return method.Outputs.Pack(coins[i])
+					}
+					return method.Outputs.Pack(balances[i])
+				}
+				return nil, errors.New("unexpected method " + method.Name)
+			}
+
+			Convey("When reading a two-coin pool", func() {
+				s.gethClient.EXPECT().
+					CallContract(gomock.Any(), gomock.Any(), nil).
+					DoAndReturn(func(_ context.Context, call ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+						return respond(call)
+					}).
+					Times(9) // A, fee, 2 coins with balances and decimals, and the reverted coins(2)
+
+				result, err := s.client.PoolState(ctx, poolAddr)
+
+				Convey("Then it should return the pool parameters and coins", func() {
+					So(err, ShouldBeNil)
+					So(result, ShouldNotBeNil)
+					So(result.A.String(), ShouldEqual, "2000")
+					So(result.Fee.String(), ShouldEqual, "4000000")
+					So(result.Coins, ShouldResemble, coins)
+					So(result.Balances, ShouldHaveLength, 2)
+					So(result.Balances[0].String(), ShouldEqual, "5000")
+					So(result.Balances[1].String(), ShouldEqual, "7000")
+					So(result.Decimals, ShouldResemble, []uint8{18, 6})
+				})
+			})
+
+			Convey("When a coin read fails for another reason", func() {
+				s.gethClient.EXPECT().
+					CallContract(gomock.Any(), gomock.Any(), nil).
+					DoAndReturn(func(_ context.Context, call ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+						method, err := poolABI.MethodById(call.Data[:4])
+						if err == nil && method.Name == "coins" {
+							return nil, errors.New("connection refused")
+						}
+						return respond(call)
+					}).
+					Times(3)
+
+				result, err := s.client.PoolState(ctx, poolAddr)
+
+				Convey("Then it should return an error", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldContainSubstring, "failed to call coins")
+					So(result, ShouldBeNil)
+				})
+			})
+		})
+	})
+}
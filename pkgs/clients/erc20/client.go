@@ -0,0 +1,41 @@
+package erc20
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/sync/singleflight"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+type Config struct {
+	// Token metadata hardly ever changes, so it is kept for long
+	CacheTTL time.Duration `env:"CACHE_TTL,default=24h"`
+}
+
+type client struct {
+	cfg Config
+
+	gethClient GethClient
+
+	// Metadata of the tokens read so far, keyed by token address
+	cacheLock     sync.RWMutex
+	metadataCache map[common.Address]cachedMetadata
+	g4Metadata    *singleflight.Group
+}
+
+type cachedMetadata struct {
+	metadata  *Metadata
+	expiresAt time.Time
+}
+
+func New(cfg Config, gethClient GethClient) *client {
+	return &client{
+		cfg:           cfg,
+		gethClient:    gethClient,
+		metadataCache: make(map[common.Address]cachedMetadata),
+		g4Metadata:    &singleflight.Group{},
+	}
+}
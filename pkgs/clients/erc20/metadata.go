@@ -0,0 +1,138 @@
+package erc20
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Metadata describes an ERC-20 token. Symbol and Name are empty for tokens
+// that do not implement them.
+type Metadata struct {
+	Decimals uint8
+	Symbol   string
+	Name     string
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+const erc20MetadataABI = `[
+	{"inputs":[],"name":"decimals","outputs":[{"internalType":"uint8","name":"","type":"uint8"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"symbol","outputs":[{"internalType":"string","name":"","type":"string"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"name","outputs":[{"internalType":"string","name":"","type":"string"}],"stateMutability":"view","type":"function"}
+]`
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Metadata returns the decimals, symbol and name of a token, read once and
+// cached for the configured TTL.
+func (c *client) Metadata(
+	ctx context.Context,
+	tokenAddress common.Address,
+) (*Metadata, error) {
+	logger := log.Ctx(ctx)
+
+	c.cacheLock.RLock()
+	cached, ok := c.metadataCache[tokenAddress]
+	c.cacheLock.RUnlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.metadata, nil
+	}
+
+	res, err, _ := c.g4Metadata.Do(tokenAddress.Hex(), func() (any, error) {
+		return c.readMetadata(ctx, tokenAddress)
+	})
+	if err != nil {
+		return nil, err
+	}
+	metadata := res.(*Metadata)
+
+	c.cacheLock.Lock()
+	c.metadataCache[tokenAddress] = cachedMetadata{
+		metadata:  metadata,
+		expiresAt: time.Now().Add(c.cfg.CacheTTL),
+	}
+	c.cacheLock.Unlock()
+
+	logger.Debug().
+		Str("token_address", tokenAddress.Hex()).
+		Uint8("decimals", metadata.Decimals).
+		Str("symbol", metadata.Symbol).
+		Msg("ERC-20 metadata details")
+
+	return metadata, nil
+}
+
+// readMetadata calls the token. decimals is required; symbol and name are
+// optional in the standard and left empty when they cannot be read.
+func (c *client) readMetadata(ctx context.Context, tokenAddress common.Address) (*Metadata, error) {
+	logger := log.Ctx(ctx)
+	logger.Debug().
+		Str("token_address", tokenAddress.Hex()).
+		Msg("Reading ERC-20 metadata")
+
+	tokenABI, err := abi.JSON(strings.NewReader(erc20MetadataABI))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to parse ERC20 ABI")
+		return nil, fmt.Errorf("failed to parse ABI: %v", err)
+	}
+
+	call := func(method string) ([]byte, error) {
+		data, err := tokenABI.Pack(method)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack %s: %v", method, err)
+		}
+		res, err := c.gethClient.CallContract(ctx, ethereum.CallMsg{To: &tokenAddress, Data: data}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to call %s: %v", method, err)
+		}
+		return res, nil
+	}
+
+	res, err := call("decimals")
+	if err != nil {
+		logger.Error().Err(err).Str("token_address", tokenAddress.Hex()).Msg("Failed to read decimals")
+		return nil, err
+	}
+	decimals, err := tokenABI.Unpack("decimals", res)
+	if err != nil {
+		logger.Error().Err(err).Str("token_address", tokenAddress.Hex()).Msg("Failed to unpack decimals")
+		return nil, fmt.Errorf("failed to unpack decimals: %v", err)
+	}
+
+	metadata := &Metadata{Decimals: decimals[0].(uint8)}
+	for _, field := range []struct {
+		method string
+		value  *string
+	}{{"symbol", &metadata.Symbol}, {"name", &metadata.Name}} {
+		res, err := call(field.method)
+		if err != nil {
+			logger.Warn().Err(err).Str("token_address", tokenAddress.Hex()).Msgf("Failed to read %s", field.method)
+			continue
+		}
+		*field.value = unpackString(tokenABI, field.method, res)
+	}
+
+	return metadata, nil
+}
+
+// unpackString decodes a string return value. Some early tokens (e.g. MKR)
+// return a bytes32 instead, which is decoded as a NUL padded string.
+func unpackString(tokenABI abi.ABI, method string, res []byte) string {
+	if out, err := tokenABI.Unpack(method, res); err == nil {
+		return out[0].(string)
+	}
+	if len(res) == 32 {
+		return string(bytes.TrimRight(res, "\x00"))
+	}
+	return ""
+}
@@ -0,0 +1,126 @@
+package erc20
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMetadata(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given the Metadata function", t, func() {
+			ctx := context.Background()
+			usdcAddr := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+			mkrAddr := "0x9f8F72aA9304c8B593d555F12eF6589cC3A579A2"
+
+			tokenABI, err := abi.JSON(strings.NewReader(erc20MetadataABI))
+			So(err, ShouldBeNil)
+
+			// The cache is shared by the runs of the suite
+			for tokenAddress := range s.client.metadataCache {
+				delete(s.client.metadataCache, tokenAddress)
+			}
+
+			Convey("When reading a token twice", func() {
+				s.gethClient.EXPECT().
+					CallContract(gomock.Any(), gomock.Any(), nil).
+					DoAndReturn(func(_ context.Context, call ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+						method, err := tokenABI.MethodById(call.Data[:4])
+						if err != nil {
+							return nil, err
+						}
+						switch method.Name {
+						case "decimals":
+							return method.Outputs.Pack(uint8(6))
+						case "symbol":
+							return method.Outputs.Pack("USDC")
+						}
+						return method.Outputs.Pack("USD Coin")
+					}).
+					Times(3) // decimals, symbol and name, once
+
+				first, err := s.client.Metadata(ctx, common.HexToAddress(usdcAddr))
+				So(err, ShouldBeNil)
+				second, err := s.client.Metadata(ctx, common.HexToAddress(strings.ToLower(usdcAddr)))
+
+				Convey("Then it should return the cached metadata the second time", func() {
+					So(err, ShouldBeNil)
+					So(first, ShouldResemble, &Metadata{Decimals: 6, Symbol: "USDC", Name: "USD Coin"})
+					So(second, ShouldEqual, first)
+				})
+			})
+
+			Convey("When the token returns bytes32 strings", func() {
+				s.gethClient.EXPECT().
+					CallContract(gomock.Any(), gomock.Any(), nil).
+					DoAndReturn(func(_ context.Context, call ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+						method, err := tokenABI.MethodById(call.Data[:4])
+						if err != nil {
+							return nil, err
+						}
+						switch method.Name {
+						case "decimals":
+							return method.Outputs.Pack(uint8(18))
+						case "symbol":
+							return common.RightPadBytes([]byte("MKR"), 32), nil
+						}
+						return common.RightPadBytes([]byte("Maker"), 32), nil
+					}).
+					Times(3)
+
+				result, err := s.client.Metadata(ctx, common.HexToAddress(mkrAddr))
+
+				Convey("Then the strings should be decoded", func() {
+					So(err, ShouldBeNil)
+					So(result, ShouldResemble, &Metadata{Decimals: 18, Symbol: "MKR", Name: "Maker"})
+				})
+			})
+
+			Convey("When the token does not implement symbol and name", func() {
+				s.gethClient.EXPECT().
+					CallContract(gomock.Any(), gomock.Any(), nil).
+					DoAndReturn(func(_ context.Context, call ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+						method, err := tokenABI.MethodById(call.Data[:4])
+						if err != nil {
+							return nil, err
+						}
+						if method.Name == "decimals" {
+							return method.Outputs.Pack(uint8(18))
+						}
+						return nil, errors.New("execution reverted")
+					}).
+					Times(3)
+
+				result, err := s.client.Metadata(ctx, common.HexToAddress(usdcAddr))
+
+				Convey("Then the decimals should still be returned", func() {
+					So(err, ShouldBeNil)
+					So(result, ShouldResemble, &Metadata{Decimals: 18})
+				})
+			})
+
+			Convey("When the decimals cannot be read", func() {
+				s.gethClient.EXPECT().
+					CallContract(gomock.Any(), gomock.Any(), nil).
+					Return(nil, errors.New("execution reverted"))
+
+				result, err := s.client.Metadata(ctx, common.HexToAddress(usdcAddr))
+
+				Convey("Then it should return an error and cache nothing", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldContainSubstring, "failed to call decimals")
+					So(result, ShouldBeNil)
+					So(s.client.metadataCache, ShouldBeEmpty)
+				})
+			})
+		})
+	})
+}
@@ -1,20 +1,61 @@
 package eth
 
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/sync/singleflight"
+)
+
 ////////////////////////////////////////////////////////////////////////////////
 
 type Config struct {
 	BlockRangeSize uint64 `env:"BLOCK_RANGE_SIZE,default=9900"`
+	// LogQueryRetries is the number of times a failed log query is retried,
+	// waiting LogQueryBackoff before the first retry and twice as long before
+	// each next one
+	LogQueryRetries int           `env:"LOG_QUERY_RETRIES,default=3"`
+	LogQueryBackoff time.Duration `env:"LOG_QUERY_BACKOFF,default=250ms"`
+	// PinnedBlockRetries is the number of times a read pinned to a block is
+	// retried while the node serving it has not seen the block yet, waiting
+	// PinnedBlockBackoff before the first retry and twice as long before each
+	// next one
+	PinnedBlockRetries int           `env:"PINNED_BLOCK_RETRIES,default=3"`
+	PinnedBlockBackoff time.Duration `env:"PINNED_BLOCK_BACKOFF,default=200ms"`
+	// SyncLogFallback scans the Sync logs of a pair for its reserves when the
+	// node fails the getReserves call, e.g. a pruned node at a past block
+	SyncLogFallback bool `env:"SYNC_LOG_FALLBACK,default=true"`
+	// Multicall3Address is the Multicall3 contract batched reads go through
+	Multicall3Address string `env:"MULTICALL3_ADDRESS,default=0xcA11bde05977b3631167028862bE2a173976CA11"`
+	// MulticallMaxCalldataSize bounds the calldata of one Multicall3 call, in
+	// bytes; larger batches are split across several calls
+	MulticallMaxCalldataSize int `env:"MULTICALL_MAX_CALLDATA_SIZE,default=65536"`
 }
 
 type client struct {
 	cfg Config
 
 	gethClient GethClient
+
+	// Metadata of the pairs read so far, keyed by pair address. It is
+	// immutable, so it is never evicted
+	pairMetadataLock  sync.RWMutex
+	pairMetadataCache map[common.Address]*PairMetadata
+	g4PairMetadata    *singleflight.Group
+
+	// Block range limits learned from the providers rejecting larger ranges,
+	// keyed by provider name
+	rangeLimitLock sync.Mutex
+	rangeLimits    map[string]uint64
 }
 
 func New(cfg Config, gethClient GethClient) *client {
 	return &client{
-		cfg:        cfg,
-		gethClient: gethClient,
+		cfg:               cfg,
+		gethClient:        gethClient,
+		pairMetadataCache: make(map[common.Address]*PairMetadata),
+		g4PairMetadata:    &singleflight.Group{},
+		rangeLimits:       make(map[string]uint64),
 	}
 }
@@ -2,6 +2,7 @@ package eth
 
 import (
 	"testing"
+	"time"
 
 	"github.com/sethvargo/go-envconfig"
 	"go.uber.org/mock/gomock"
@@ -21,7 +22,10 @@ func testInit(t *testing.T, test func(*testSuite)) {
 
 	gethClient := NewMockGethClient(ctrl)
 
-	cfg := Config{}
+	cfg := Config{
+		LogQueryBackoff:    time.Millisecond,
+		PinnedBlockBackoff: time.Millisecond,
+	}
 	if err := envconfig.Process(t.Context(), &cfg); err != nil {
 		t.Fatal(err)
 	}
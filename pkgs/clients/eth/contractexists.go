@@ -0,0 +1,32 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// ContractExists tells whether a contract is deployed at an address as of the
+// latest block.
+func (c *client) ContractExists(ctx context.Context, addr common.Address) (bool, error) {
+	logger := log.Ctx(ctx)
+
+	code, err := c.gethClient.CodeAt(ctx, addr, nil)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Str("address", addr.Hex()).
+			Msg("Failed to get contract code")
+		return false, fmt.Errorf("failed to get code: %v", err)
+	}
+
+	logger.Debug().
+		Str("address", addr.Hex()).
+		Int("code_size", len(code)).
+		Msg("Contract code details")
+	return len(code) > 0, nil
+}
@@ -0,0 +1,60 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+func TestContractExists(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given the ContractExists function", t, func() {
+			ctx := context.Background()
+			pairAddr := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc" // WETH-USDC pair
+
+			Convey("When the address holds code", func() {
+				s.gethClient.EXPECT().
+					CodeAt(gomock.Any(), common.HexToAddress(pairAddr), nil).
+					Return([]byte{0x60, 0x80, 0x60, 0x40}, nil)
+
+				exists, err := s.client.ContractExists(ctx, common.HexToAddress(pairAddr))
+
+				Convey("Then the contract should exist", func() {
+					So(err, ShouldBeNil)
+					So(exists, ShouldBeTrue)
+				})
+			})
+
+			Convey("When the address holds no code", func() {
+				s.gethClient.EXPECT().
+					CodeAt(gomock.Any(), common.HexToAddress(pairAddr), nil).
+					Return([]byte{}, nil)
+
+				exists, err := s.client.ContractExists(ctx, common.HexToAddress(pairAddr))
+
+				Convey("Then the contract should not exist", func() {
+					So(err, ShouldBeNil)
+					So(exists, ShouldBeFalse)
+				})
+			})
+
+			Convey("When the node fails", func() {
+				s.gethClient.EXPECT().
+					CodeAt(gomock.Any(), common.HexToAddress(pairAddr), nil).
+					Return(nil, errors.New("connection refused"))
+
+				exists, err := s.client.ContractExists(ctx, common.HexToAddress(pairAddr))
+
+				Convey("Then it should return an error", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldContainSubstring, "failed to get code")
+					So(exists, ShouldBeFalse)
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,126 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/WangWilly/swap-estimation/pkgs/clients/rpcpool"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rs/zerolog/log"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// nextLogProvider returns the provider the next log query goes to first, when
+// the node client is a pool of providers, and an empty name otherwise.
+func (c *client) nextLogProvider() string {
+	if pool, ok := c.gethClient.(interface{ NextProvider() string }); ok {
+		return pool.NextProvider()
+	}
+	return ""
+}
+
+// blockRange returns the block range of a log query sent to provider: the
+// range limit learned from it, if lower than BlockRangeSize.
+func (c *client) blockRange(provider string) uint64 {
+	c.rangeLimitLock.Lock()
+	defer c.rangeLimitLock.Unlock()
+
+	if limit, ok := c.rangeLimits[provider]; ok && limit < c.cfg.BlockRangeSize {
+		return limit
+	}
+	return c.cfg.BlockRangeSize
+}
+
+// lowerRangeLimit records that a provider rejects block ranges larger than
+// limit. Limits are only ever lowered, and never to 0.
+func (c *client) lowerRangeLimit(provider string, limit uint64) {
+	c.rangeLimitLock.Lock()
+	defer c.rangeLimitLock.Unlock()
+
+	if limit == 0 {
+		return
+	}
+	if current, ok := c.rangeLimits[provider]; !ok || limit < current {
+		c.rangeLimits[provider] = limit
+	}
+}
+
+// filterLogsBefore queries the logs of the block range ending at toBlock, and
+// returns them with the first block of the range. The range is halved until
+// the provider serves it, down to a span of one block: a range too large for
+// the provider lowers its range limit, while too many results only narrow this
+// query. Other errors are retried with exponential backoff, up to
+// LogQueryRetries times.
+func (c *client) filterLogsBefore(
+	ctx context.Context,
+	query ethereum.FilterQuery,
+	toBlock uint64,
+) ([]types.Log, uint64, error) {
+	logger := log.Ctx(ctx)
+
+	span := c.blockRange(c.nextLogProvider())
+	backoff := c.cfg.LogQueryBackoff
+	retries := 0
+	for {
+		fromBlock := toBlock - min(span, toBlock)
+		query.FromBlock = new(big.Int).SetUint64(fromBlock)
+		query.ToBlock = new(big.Int).SetUint64(toBlock)
+
+		reportCtx, report := rpcpool.WithReport(ctx)
+		logs, err := c.gethClient.FilterLogs(reportCtx, query)
+		if err == nil {
+			return logs, fromBlock, nil
+		}
+		if ctx.Err() != nil {
+			return nil, fromBlock, err
+		}
+
+		// The provider is only known through a pool, which returns the range
+		// errors of the provider answering without failing over, and names the
+		// last provider tried when all of them failed
+		provider := report.Provider
+		var providerErr *rpcpool.ProviderError
+		if errors.As(err, &providerErr) {
+			provider = providerErr.Provider
+		}
+
+		if span > 1 && (rpcpool.IsRangeTooLargeError(err) || rpcpool.IsTooManyResultsError(err)) {
+			span /= 2
+			if rpcpool.IsRangeTooLargeError(err) {
+				c.lowerRangeLimit(report.Provider, span)
+			}
+			logger.Warn().
+				Err(err).
+				Str("provider", provider).
+				Uint64("from_block", fromBlock).
+				Uint64("to_block", toBlock).
+				Uint64("block_range", span).
+				Msg("Narrowing the block range of a log query")
+			continue
+		}
+
+		if retries >= c.cfg.LogQueryRetries {
+			return nil, fromBlock, err
+		}
+		retries++
+		logger.Warn().
+			Err(err).
+			Str("provider", provider).
+			Uint64("from_block", fromBlock).
+			Uint64("to_block", toBlock).
+			Int("retry", retries).
+			Dur("backoff", backoff).
+			Msg("Retrying a log query")
+
+		select {
+		case <-ctx.Done():
+			return nil, fromBlock, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
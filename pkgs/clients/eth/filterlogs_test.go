@@ -0,0 +1,290 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/WangWilly/swap-estimation/pkgs/clients/rpcpool"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFilterLogsBefore(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given a log query over a block range of 100 blocks", t, func() {
+			ctx := context.Background()
+			s.client.cfg.BlockRangeSize = 100
+			s.client.rangeLimits = make(map[string]uint64)
+
+			query := ethereum.FilterQuery{
+				Addresses: []common.Address{common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc")},
+			}
+			logs := []types.Log{{BlockNumber: 960}}
+
+			// expectRange expects a query over fromBlock to toBlock
+			expectRange := func(c C, fromBlock, toBlock int64, res []types.Log, err error) *gomock.Call {
+				return s.gethClient.EXPECT().
+					FilterLogs(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+						c.So(q.FromBlock.Int64(), ShouldEqual, fromBlock)
+						c.So(q.ToBlock.Int64(), ShouldEqual, toBlock)
+						c.So(q.Addresses, ShouldResemble, query.Addresses)
+						return res, err
+					})
+			}
+
+			Convey("When the provider rejects the range as too large", func(c C) {
+				gomock.InOrder(
+					expectRange(c, 900, 1000, nil, errors.New("exceed maximum block range: 50")),
+					expectRange(c, 950, 1000, logs, nil),
+				)
+
+				result, fromBlock, err := s.client.filterLogsBefore(ctx, query, 1000)
+
+				Convey("Then the range should be halved until served", func() {
+					So(err, ShouldBeNil)
+					So(result, ShouldResemble, logs)
+					So(fromBlock, ShouldEqual, 950)
+				})
+
+				Convey("Then the range limit of the node should be remembered", func() {
+					So(s.client.rangeLimits, ShouldResemble, map[string]uint64{"": 50})
+				})
+			})
+
+			Convey("When the range matches too many logs", func(c C) {
+				gomock.InOrder(
+					expectRange(c, 900, 1000, nil, errors.New("query returned more than 10000 results")),
+					expectRange(c, 950, 1000, nil, errors.New("query returned more than 10000 results")),
+					expectRange(c, 975, 1000, logs, nil),
+				)
+
+				result, fromBlock, err := s.client.filterLogsBefore(ctx, query, 1000)
+
+				Convey("Then the range should be halved until served", func() {
+					So(err, ShouldBeNil)
+					So(result, ShouldResemble, logs)
+					So(fromBlock, ShouldEqual, 975)
+				})
+
+				Convey("Then no range limit should be remembered", func() {
+					So(s.client.rangeLimits, ShouldBeEmpty)
+					So(s.client.blockRange(""), ShouldEqual, 100)
+				})
+			})
+
+			Convey("When the provider fails once", func(c C) {
+				gomock.InOrder(
+					expectRange(c, 900, 1000, nil, errors.New("503 Service Unavailable")),
+					expectRange(c, 900, 1000, logs, nil),
+				)
+
+				result, fromBlock, err := s.client.filterLogsBefore(ctx, query, 1000)
+
+				Convey("Then the same range should be retried", func() {
+					So(err, ShouldBeNil)
+					So(result, ShouldResemble, logs)
+					So(fromBlock, ShouldEqual, 900)
+				})
+			})
+
+			Convey("When the provider rejects even a range of one block", func(c C) {
+				s.client.cfg.BlockRangeSize = 4
+				rangeErr := errors.New("block range too large")
+				gomock.InOrder(
+					expectRange(c, 996, 1000, nil, rangeErr),
+					expectRange(c, 998, 1000, nil, rangeErr),
+					expectRange(c, 999, 1000, nil, rangeErr),
+					expectRange(c, 999, 1000, logs, nil),
+				)
+
+				result, fromBlock, err := s.client.filterLogsBefore(ctx, query, 1000)
+
+				Convey("Then the range should be retried instead of narrowed further", func() {
+					So(err, ShouldBeNil)
+					So(result, ShouldResemble, logs)
+					So(fromBlock, ShouldEqual, 999)
+				})
+
+				Convey("Then the range limit should not go below one block", func() {
+					So(s.client.rangeLimits, ShouldResemble, map[string]uint64{"": 1})
+				})
+			})
+
+			Convey("When the provider rate limits the query", func(c C) {
+				gomock.InOrder(
+					expectRange(c, 900, 1000, nil, errors.New("your app is limited to a rate of 10 req/s")),
+					expectRange(c, 900, 1000, logs, nil),
+				)
+
+				result, fromBlock, err := s.client.filterLogsBefore(ctx, query, 1000)
+
+				Convey("Then the same range should be retried", func() {
+					So(err, ShouldBeNil)
+					So(result, ShouldResemble, logs)
+					So(fromBlock, ShouldEqual, 900)
+					So(s.client.rangeLimits, ShouldBeEmpty)
+				})
+			})
+
+			Convey("When the provider keeps failing", func() {
+				s.gethClient.EXPECT().
+					FilterLogs(gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("503 Service Unavailable")).
+					Times(1 + s.client.cfg.LogQueryRetries)
+
+				result, _, err := s.client.filterLogsBefore(ctx, query, 1000)
+
+				Convey("Then its error should be returned once the retries are exhausted", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldEqual, "503 Service Unavailable")
+					So(result, ShouldBeNil)
+				})
+			})
+
+			Convey("When the range reaches the genesis block", func(c C) {
+				expectRange(c, 0, 60, logs, nil)
+
+				_, fromBlock, err := s.client.filterLogsBefore(ctx, query, 60)
+
+				Convey("Then it should start at the genesis block", func() {
+					So(err, ShouldBeNil)
+					So(fromBlock, ShouldEqual, 0)
+				})
+			})
+		})
+	})
+}
+
+func TestFilterLogsBeforeWithPool(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := rpcpool.NewMockGethClient(ctrl)
+	secondary := rpcpool.NewMockGethClient(ctrl)
+
+	Convey("Given a pool of two providers serving different block ranges", t, func() {
+		ctx := context.Background()
+		pool := rpcpool.New(rpcpool.Config{
+			HedgeDelay:             time.Minute,
+			MaxConsecutiveFailures: 3,
+			CooldownPeriod:         time.Minute,
+		}, []rpcpool.Provider{
+			{Name: "primary", Client: primary},
+			{Name: "secondary", Client: secondary},
+		})
+		client := New(Config{
+			BlockRangeSize:  100,
+			LogQueryRetries: 1,
+			LogQueryBackoff: time.Millisecond,
+		}, pool)
+
+		query := ethereum.FilterQuery{
+			Addresses: []common.Address{common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc")},
+		}
+		logs := []types.Log{{BlockNumber: 960}}
+
+		// expectRange expects a query over fromBlock to toBlock on a provider
+		expectRange := func(c C, provider *rpcpool.MockGethClient, fromBlock, toBlock int64, res []types.Log, err error) *gomock.Call {
+			return provider.EXPECT().
+				FilterLogs(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+					c.So(q.FromBlock.Int64(), ShouldEqual, fromBlock)
+					c.So(q.ToBlock.Int64(), ShouldEqual, toBlock)
+					return res, err
+				})
+		}
+
+		Convey("When the healthiest provider rejects the range as too large", func(c C) {
+			gomock.InOrder(
+				expectRange(c, primary, 900, 1000, nil, errors.New("exceed maximum block range: 50")),
+				expectRange(c, primary, 950, 1000, logs, nil),
+			)
+
+			// No query to the secondary provider expected
+
+			result, fromBlock, err := client.filterLogsBefore(ctx, query, 1000)
+
+			Convey("Then the range limit of that provider only should be lowered", func() {
+				So(err, ShouldBeNil)
+				So(result, ShouldResemble, logs)
+				So(fromBlock, ShouldEqual, 950)
+				So(client.rangeLimits, ShouldResemble, map[string]uint64{"primary": 50})
+			})
+		})
+
+		Convey("When the next query goes to the provider without a limit", func(c C) {
+			client.lowerRangeLimit("primary", 50)
+
+			// A failed call ranks the primary provider last
+			primary.EXPECT().
+				BlockNumber(gomock.Any()).
+				Return(uint64(0), errors.New("503 Service Unavailable"))
+			secondary.EXPECT().
+				BlockNumber(gomock.Any()).
+				Return(uint64(1000), nil)
+			_, err := pool.BlockNumber(ctx)
+			So(err, ShouldBeNil)
+			So(pool.NextProvider(), ShouldEqual, "secondary")
+
+			expectRange(c, secondary, 900, 1000, logs, nil)
+
+			_, fromBlock, err := client.filterLogsBefore(ctx, query, 1000)
+
+			Convey("Then the query should be sized by the limit of that provider", func() {
+				So(err, ShouldBeNil)
+				So(fromBlock, ShouldEqual, 900)
+			})
+		})
+	})
+}
+
+func TestBlockRange(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given range limits learned from two providers", t, func() {
+			s.client.cfg.BlockRangeSize = 100
+			s.client.rangeLimits = make(map[string]uint64)
+			s.client.lowerRangeLimit("primary", 50)
+			s.client.lowerRangeLimit("secondary", 500)
+
+			Convey("When the next log query goes to the first one", func() {
+				Convey("Then its limit should size the query", func() {
+					So(s.client.blockRange("primary"), ShouldEqual, 50)
+				})
+			})
+
+			Convey("When the next log query goes to the second one", func() {
+				Convey("Then the configured block range should bound the query", func() {
+					So(s.client.blockRange("secondary"), ShouldEqual, 100)
+				})
+			})
+
+			Convey("When the next log query goes to a provider without a limit", func() {
+				Convey("Then the configured block range should size the query", func() {
+					So(s.client.blockRange("tertiary"), ShouldEqual, 100)
+				})
+			})
+
+			Convey("When a provider reports a higher limit than learned", func() {
+				s.client.lowerRangeLimit("primary", 80)
+
+				Convey("Then the lower limit should be kept", func() {
+					So(s.client.rangeLimits["primary"], ShouldEqual, 50)
+				})
+			})
+
+			Convey("When a limit of 0 blocks is reported", func() {
+				s.client.lowerRangeLimit("primary", 0)
+
+				Convey("Then it should be ignored", func() {
+					So(s.client.rangeLimits["primary"], ShouldEqual, 50)
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,157 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// call3 is a call batched through Multicall3. Field names follow the ABI so
+// that a slice of them packs as Call3[].
+type call3 struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// result3 is the outcome of a call3, as returned by aggregate3.
+type result3 struct {
+	Success    bool
+	ReturnData []byte
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+const multicall3ABI = `[{"inputs":[{"components":[{"internalType":"address","name":"target","type":"address"},{"internalType":"bool","name":"allowFailure","type":"bool"},{"internalType":"bytes","name":"callData","type":"bytes"}],"internalType":"struct Multicall3.Call3[]","name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"internalType":"bool","name":"success","type":"bool"},{"internalType":"bytes","name":"returnData","type":"bytes"}],"internalType":"struct Multicall3.Result[]","name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"}]`
+
+const (
+	// aggregate3CalldataSize is the calldata of an aggregate3 call without
+	// calls: the selector, the offset of the array and its length
+	aggregate3CalldataSize = 4 + 32 + 32
+	// call3HeadSize is the ABI size of a Call3 without its callData: its
+	// offset in the array, target, allowFailure, and the offset and length
+	// of callData
+	call3HeadSize = 5 * 32
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// aggregate3 runs calls through Multicall3 at the block with the given hash,
+// split into as many eth_calls as the configured calldata size requires. Each
+// call may fail on its own, but a chunk the node fails fails them all, and
+// cancels the other chunks: a node outage is not reported as failed calls.
+// The results follow the order of the calls.
+func (c *client) aggregate3(ctx context.Context, calls []call3, blockHash common.Hash) ([]result3, error) {
+	logger := log.Ctx(ctx)
+
+	multicallABI, err := abi.JSON(strings.NewReader(multicall3ABI))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to parse Multicall3 ABI")
+		return nil, fmt.Errorf("failed to parse ABI: %v", err)
+	}
+	multicallAddress := common.HexToAddress(c.cfg.Multicall3Address)
+
+	chunks := chunkCalls(calls, c.cfg.MulticallMaxCalldataSize)
+	logger.Debug().
+		Int("call_count", len(calls)).
+		Int("chunk_count", len(chunks)).
+		Msg("Running calls through Multicall3")
+
+	chunkCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Each goroutine writes its own slots; the first chunk failing cancels
+	// the others, so its error is the one returned
+	results := make([]result3, len(calls))
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		firstErr error
+	)
+	offset := 0
+	for _, chunk := range chunks {
+		chunkResults := results[offset : offset+len(chunk)]
+		offset += len(chunk)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			res, err := c.aggregate3Chunk(chunkCtx, multicallABI, multicallAddress, chunk, blockHash)
+			if err != nil {
+				logger.Error().
+					Err(err).
+					Int("call_count", len(chunk)).
+					Msg("Failed to run Multicall3 chunk")
+				failOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			copy(chunkResults, res)
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return results, nil
+}
+
+func (c *client) aggregate3Chunk(
+	ctx context.Context,
+	multicallABI abi.ABI,
+	multicallAddress common.Address,
+	calls []call3,
+	blockHash common.Hash,
+) ([]result3, error) {
+	data, err := multicallABI.Pack("aggregate3", calls)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack aggregate3: %v", err)
+	}
+	res, err := readPinned(ctx, c, "aggregate3", func() ([]byte, error) {
+		return c.gethClient.CallContractAtHash(ctx, ethereum.CallMsg{To: &multicallAddress, Data: data}, blockHash)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to call aggregate3: %v", err)
+	}
+	out, err := multicallABI.Unpack("aggregate3", res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack aggregate3: %v", err)
+	}
+	results := *abi.ConvertType(out[0], new([]result3)).(*[]result3)
+	if len(results) != len(calls) {
+		return nil, fmt.Errorf("aggregate3 returned %d results for %d calls", len(results), len(calls))
+	}
+	return results, nil
+}
+
+// chunkCalls splits calls into consecutive chunks whose aggregate3 calldata
+// stays within maxSize bytes. A call too large on its own gets a chunk of its
+// own rather than being dropped.
+func chunkCalls(calls []call3, maxSize int) [][]call3 {
+	var chunks [][]call3
+	start, size := 0, aggregate3CalldataSize
+	for i, call := range calls {
+		callSize := call3HeadSize + (len(call.CallData)+31)/32*32
+		if i > start && size+callSize > maxSize {
+			chunks = append(chunks, calls[start:i])
+			start, size = i, aggregate3CalldataSize
+		}
+		size += callSize
+	}
+	if start < len(calls) {
+		chunks = append(chunks, calls[start:])
+	}
+	return chunks
+}
@@ -0,0 +1,143 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+// serveAggregate3 answers the aggregate3 calls of a test like Multicall3
+// would, running every call through handle.
+func serveAggregate3(handle func(call call3) ([]byte, error)) func(context.Context, ethereum.CallMsg, common.Hash) ([]byte, error) {
+	return func(_ context.Context, msg ethereum.CallMsg, _ common.Hash) ([]byte, error) {
+		multicallABI, err := abi.JSON(strings.NewReader(multicall3ABI))
+		if err != nil {
+			return nil, err
+		}
+		in, err := multicallABI.Methods["aggregate3"].Inputs.Unpack(msg.Data[4:])
+		if err != nil {
+			return nil, err
+		}
+		calls := *abi.ConvertType(in[0], new([]call3)).(*[]call3)
+
+		results := make([]result3, len(calls))
+		for i, call := range calls {
+			data, err := handle(call)
+			results[i] = result3{Success: err == nil, ReturnData: data}
+		}
+		return multicallABI.Methods["aggregate3"].Outputs.Pack(results)
+	}
+}
+
+func TestChunkCalls(t *testing.T) {
+	Convey("Given the chunkCalls function", t, func() {
+		call := call3{CallData: []byte{0x09, 0x02, 0xf1, 0xac}} // getReserves()
+		calls := []call3{call, call, call, call, call}
+
+		Convey("When the calls fit in one chunk", func() {
+			chunks := chunkCalls(calls, 1<<16)
+
+			Convey("Then they should be sent at once", func() {
+				So(chunks, ShouldHaveLength, 1)
+				So(chunks[0], ShouldHaveLength, 5)
+			})
+		})
+
+		Convey("When the calls exceed the calldata size", func() {
+			// Room for two calls of 192 bytes per chunk
+			chunks := chunkCalls(calls, aggregate3CalldataSize+2*192)
+
+			Convey("Then they should be split in order", func() {
+				So(chunks, ShouldHaveLength, 3)
+				So(chunks[0], ShouldHaveLength, 2)
+				So(chunks[1], ShouldHaveLength, 2)
+				So(chunks[2], ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("When a call is larger than the calldata size", func() {
+			chunks := chunkCalls(calls[:2], 1)
+
+			Convey("Then it should get a chunk of its own", func() {
+				So(chunks, ShouldHaveLength, 2)
+			})
+		})
+
+		Convey("When there are no calls", func() {
+			So(chunkCalls(nil, 1<<16), ShouldBeEmpty)
+		})
+	})
+}
+
+func TestAggregate3(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given the aggregate3 function", t, func() {
+			ctx := context.Background()
+			blockHash := common.HexToHash("0xabc")
+			okAddr := common.HexToAddress("0x1000000000000000000000000000000000000000")
+			revertAddr := common.HexToAddress("0x2000000000000000000000000000000000000000")
+
+			calls := []call3{
+				{Target: okAddr, AllowFailure: true, CallData: []byte{0x01}},
+				{Target: revertAddr, AllowFailure: true, CallData: []byte{0x02}},
+				{Target: okAddr, AllowFailure: true, CallData: []byte{0x03}},
+			}
+
+			Convey("When some calls fail", func(c C) {
+				s.gethClient.EXPECT().
+					CallContractAtHash(gomock.Any(), gomock.Any(), blockHash).
+					DoAndReturn(serveAggregate3(func(call call3) ([]byte, error) {
+						if call.Target == revertAddr {
+							return nil, errors.New("execution reverted")
+						}
+						return call.CallData, nil
+					}))
+
+				results, err := s.client.aggregate3(ctx, calls, blockHash)
+
+				Convey("Then only those calls should be reported as failed", func() {
+					So(err, ShouldBeNil)
+					So(results, ShouldResemble, []result3{
+						{Success: true, ReturnData: []byte{0x01}},
+						{Success: false, ReturnData: []byte{}},
+						{Success: true, ReturnData: []byte{0x03}},
+					})
+				})
+			})
+
+			Convey("When the calls are split and the node fails a chunk", func() {
+				s.client.cfg.MulticallMaxCalldataSize = aggregate3CalldataSize + 2*192
+				defer func() { s.client.cfg.MulticallMaxCalldataSize = 65536 }()
+
+				answer := serveAggregate3(func(call call3) ([]byte, error) {
+					return call.CallData, nil
+				})
+				s.gethClient.EXPECT().
+					CallContractAtHash(gomock.Any(), gomock.Any(), blockHash).
+					DoAndReturn(func(ctx context.Context, msg ethereum.CallMsg, hash common.Hash) ([]byte, error) {
+						if len(msg.Data) < aggregate3CalldataSize+2*192 {
+							return nil, errors.New("request timed out")
+						}
+						return answer(ctx, msg, hash)
+					}).
+					MinTimes(1).
+					MaxTimes(2)
+
+				results, err := s.client.aggregate3(ctx, calls, blockHash)
+
+				Convey("Then the error of the node should be returned rather than failed calls", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldContainSubstring, "request timed out")
+					So(results, ShouldBeNil)
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,42 @@
+package eth
+
+import (
+	"context"
+	"time"
+
+	"github.com/WangWilly/swap-estimation/pkgs/clients/rpcpool"
+	"github.com/rs/zerolog/log"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// readPinned runs a read pinned to a block, retrying it with exponential
+// backoff, up to PinnedBlockRetries times, while the node serving it has not
+// seen that block yet. Through a pool of providers, the block may have been
+// picked from a node ahead of the one serving the read.
+func readPinned[T any](ctx context.Context, c *client, method string, read func() (T, error)) (T, error) {
+	logger := log.Ctx(ctx)
+
+	backoff := c.cfg.PinnedBlockBackoff
+	for retries := 0; ; retries++ {
+		res, err := read()
+		if err == nil || !rpcpool.IsBlockNotFoundError(err) || retries >= c.cfg.PinnedBlockRetries {
+			return res, err
+		}
+
+		logger.Warn().
+			Err(err).
+			Str("method", method).
+			Int("retry", retries+1).
+			Dur("backoff", backoff).
+			Msg("Retrying a read at a block the node has not seen yet")
+
+		select {
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,117 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// PairMetadata holds the immutables of a Uniswap V2 pair: its tokens, in pool
+// order, and the factory that deployed it.
+type PairMetadata struct {
+	Token0  common.Address
+	Token1  common.Address
+	Factory common.Address
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+const uniswapV2PairMetadataABI = `[
+	{"inputs":[],"name":"token0","outputs":[{"internalType":"address","name":"","type":"address"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"token1","outputs":[{"internalType":"address","name":"","type":"address"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"factory","outputs":[{"internalType":"address","name":"","type":"address"}],"stateMutability":"view","type":"function"}
+]`
+
+////////////////////////////////////////////////////////////////////////////////
+
+// UniV2PairMetadata returns the tokens and the factory of a pair, read once
+// and cached for the lifetime of the client since a pair cannot change them.
+func (c *client) UniV2PairMetadata(
+	ctx context.Context,
+	pairAddress common.Address,
+) (*PairMetadata, error) {
+	logger := log.Ctx(ctx)
+
+	c.pairMetadataLock.RLock()
+	metadata, ok := c.pairMetadataCache[pairAddress]
+	c.pairMetadataLock.RUnlock()
+	if ok {
+		return metadata, nil
+	}
+
+	res, err, _ := c.g4PairMetadata.Do(pairAddress.Hex(), func() (any, error) {
+		return c.readPairMetadata(ctx, pairAddress)
+	})
+	if err != nil {
+		return nil, err
+	}
+	metadata = res.(*PairMetadata)
+
+	c.pairMetadataLock.Lock()
+	c.pairMetadataCache[pairAddress] = metadata
+	c.pairMetadataLock.Unlock()
+
+	logger.Debug().
+		Str("pair_address", pairAddress.Hex()).
+		Str("token0", metadata.Token0.Hex()).
+		Str("token1", metadata.Token1.Hex()).
+		Str("factory", metadata.Factory.Hex()).
+		Msg("Uniswap V2 pair metadata details")
+
+	return metadata, nil
+}
+
+func (c *client) readPairMetadata(ctx context.Context, pairAddress common.Address) (*PairMetadata, error) {
+	logger := log.Ctx(ctx)
+	logger.Debug().
+		Str("pair_address", pairAddress.Hex()).
+		Msg("Reading Uniswap V2 pair metadata")
+
+	pairABI, err := abi.JSON(strings.NewReader(uniswapV2PairMetadataABI))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to parse ABI")
+		return nil, fmt.Errorf("failed to parse ABI: %v", err)
+	}
+
+	metadata := &PairMetadata{}
+	fields := []struct {
+		method string
+		value  *common.Address
+	}{
+		{"token0", &metadata.Token0},
+		{"token1", &metadata.Token1},
+		{"factory", &metadata.Factory},
+	}
+	for _, field := range fields {
+		data, err := pairABI.Pack(field.method)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack %s: %v", field.method, err)
+		}
+		res, err := c.gethClient.CallContract(ctx, ethereum.CallMsg{To: &pairAddress, Data: data}, nil)
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Str("pair_address", pairAddress.Hex()).
+				Str("method", field.method).
+				Msg("Failed to call pair")
+			return nil, fmt.Errorf("failed to call %s: %v", field.method, err)
+		}
+		out, err := pairABI.Unpack(field.method, res)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack %s: %v", field.method, err)
+		}
+		*field.value = out[0].(common.Address)
+	}
+
+	if metadata.Token0 == (common.Address{}) || metadata.Token1 == (common.Address{}) {
+		return nil, fmt.Errorf("pair %s has no tokens", pairAddress.Hex())
+	}
+	return metadata, nil
+}
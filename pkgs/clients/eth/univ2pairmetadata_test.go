@@ -0,0 +1,80 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+func TestUniV2PairMetadata(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given the UniV2PairMetadata function", t, func() {
+			ctx := context.Background()
+			pairAddr := common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc") // WETH-USDC pair
+			usdcAddr := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+			wethAddr := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+			factoryAddr := common.HexToAddress("0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f")
+
+			pairABI, err := abi.JSON(strings.NewReader(uniswapV2PairMetadataABI))
+			So(err, ShouldBeNil)
+
+			// The cache is shared by the runs of the suite
+			for address := range s.client.pairMetadataCache {
+				delete(s.client.pairMetadataCache, address)
+			}
+
+			Convey("When reading a pair twice", func() {
+				s.gethClient.EXPECT().
+					CallContract(gomock.Any(), gomock.Any(), nil).
+					DoAndReturn(func(_ context.Context, call ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+						So(*call.To, ShouldEqual, pairAddr)
+						method, err := pairABI.MethodById(call.Data[:4])
+						if err != nil {
+							return nil, err
+						}
+						switch method.Name {
+						case "token0":
+							return method.Outputs.Pack(usdcAddr)
+						case "token1":
+							return method.Outputs.Pack(wethAddr)
+						}
+						return method.Outputs.Pack(factoryAddr)
+					}).
+					Times(3) // token0, token1 and factory, once
+
+				first, err := s.client.UniV2PairMetadata(ctx, pairAddr)
+				So(err, ShouldBeNil)
+				second, err := s.client.UniV2PairMetadata(ctx, pairAddr)
+
+				Convey("Then it should return the cached metadata the second time", func() {
+					So(err, ShouldBeNil)
+					So(first, ShouldResemble, &PairMetadata{Token0: usdcAddr, Token1: wethAddr, Factory: factoryAddr})
+					So(second, ShouldEqual, first)
+				})
+			})
+
+			Convey("When the address is not a pair", func() {
+				s.gethClient.EXPECT().
+					CallContract(gomock.Any(), gomock.Any(), nil).
+					Return(nil, errors.New("execution reverted"))
+
+				result, err := s.client.UniV2PairMetadata(ctx, pairAddr)
+
+				Convey("Then it should return an error and cache nothing", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldContainSubstring, "failed to call token0")
+					So(result, ShouldBeNil)
+					So(s.client.pairMetadataCache, ShouldBeEmpty)
+				})
+			})
+		})
+	})
+}
@@ -2,10 +2,12 @@ package eth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
 
+	"github.com/WangWilly/swap-estimation/pkgs/clients/rpcpool"
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
@@ -15,61 +17,264 @@ import (
 
 ////////////////////////////////////////////////////////////////////////////////
 
+// ReservePair holds the reserves of a Uniswap V2 pair as of block
+// BlockNumber, either read from the pair at that block or decoded from the
+// Sync event it emitted there. LogIndex is the index of that Sync event in
+// its block, and zero for reserves read at the end of the block.
+// BlockTimestampLast is the timestamp of the block the reserves were last
+// updated in, as returned by getReserves, or zero when they came from a Sync
+// event. Token0 and Token1 are the tokens of the pair as read from it, or
+// zero when they were not read.
 type ReservePair struct {
-	Reserve0 *big.Int
-	Reserve1 *big.Int
+	Reserve0           *big.Int
+	Reserve1           *big.Int
+	BlockNumber        uint64
+	BlockHash          common.Hash
+	LogIndex           uint
+	BlockTimestampLast uint32
+
+	Token0 common.Address
+	Token1 common.Address
 }
 
+var (
+	// ErrBlockNotReached is returned for a block past the chain head.
+	ErrBlockNotReached = errors.New("block not reached yet")
+	// ErrHistoryUnavailable is returned when the node cannot serve the state
+	// of a past block or the logs of a past block range, e.g. a pruned node.
+	ErrHistoryUnavailable = errors.New("history not available")
+	// ErrNotPair is returned when an address reverts getReserves, or answers
+	// it with no data or data that does not decode: it holds no pair.
+	ErrNotPair = errors.New("address is not a Uniswap V2 pair")
+)
+
 ////////////////////////////////////////////////////////////////////////////////
 
-const uniswapV2PairABI = `[{"anonymous":false,"inputs":[{"indexed":false,"internalType":"uint112","name":"reserve0","type":"uint112"},{"indexed":false,"internalType":"uint112","name":"reserve1","type":"uint112"}],"name":"Sync","type":"event"}]`
+const uniswapV2PairABI = `[
+	{"anonymous":false,"inputs":[{"indexed":false,"internalType":"uint112","name":"reserve0","type":"uint112"},{"indexed":false,"internalType":"uint112","name":"reserve1","type":"uint112"}],"name":"Sync","type":"event"},
+	{"inputs":[],"name":"getReserves","outputs":[{"internalType":"uint112","name":"_reserve0","type":"uint112"},{"internalType":"uint112","name":"_reserve1","type":"uint112"},{"internalType":"uint32","name":"_blockTimestampLast","type":"uint32"}],"stateMutability":"view","type":"function"}
+]`
 
 ////////////////////////////////////////////////////////////////////////////////
 
 func (c *client) UniV2ReservePair(
 	ctx context.Context,
-	pairAddrStr string,
+	pairAddress common.Address,
 ) (*ReservePair, error) {
 	logger := log.Ctx(ctx)
 	logger.Debug().
-		Str("pair_address", pairAddrStr).
+		Str("pair_address", pairAddress.Hex()).
 		Msg("Estimating Uniswap V2 output amount")
 
-	pairAddress := common.HexToAddress(pairAddrStr)
-	parsedABI, err := abi.JSON(strings.NewReader(uniswapV2PairABI))
+	// Get latest block number
+	latestBlock, err := c.gethClient.BlockNumber(ctx)
 	if err != nil {
-		logger.Error().Err(err).Msg("Failed to parse Uniswap V2 Pair ABI")
-		return nil, fmt.Errorf("failed to parse ABI: %v", err)
+		logger.Error().Err(err).Msg("Failed to get latest block number")
+		return nil, fmt.Errorf("failed to get latest block: %v", err)
 	}
 
-	// Get latest block number
+	return c.readReservePair(ctx, pairAddress, latestBlock, false)
+}
+
+// UniV2ReservePairAt returns the reserves of a pair as of the end of block
+// blockNumber, read with getReserves at that block; a block past the chain
+// head fails with ErrBlockNotReached. Its Sync logs are only scanned back from
+// the block as a fallback when the node fails the call, and a block range
+// failing the scan fails it with ErrHistoryUnavailable rather than being
+// skipped, since older reserves would be returned silently.
+func (c *client) UniV2ReservePairAt(
+	ctx context.Context,
+	pairAddress common.Address,
+	blockNumber uint64,
+) (*ReservePair, error) {
+	logger := log.Ctx(ctx)
+	logger.Debug().
+		Str("pair_address", pairAddress.Hex()).
+		Uint64("block_number", blockNumber).
+		Msg("Getting historical Uniswap V2 reserves")
+
 	latestBlock, err := c.gethClient.BlockNumber(ctx)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to get latest block number")
 		return nil, fmt.Errorf("failed to get latest block: %v", err)
 	}
+	if blockNumber > latestBlock {
+		logger.Error().
+			Uint64("block_number", blockNumber).
+			Uint64("latest_block", latestBlock).
+			Msg("Block is past the chain head")
+		return nil, fmt.Errorf("%w: block %d, head %d", ErrBlockNotReached, blockNumber, latestBlock)
+	}
+
+	return c.readReservePair(ctx, pairAddress, blockNumber, true)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// readReservePair reads the reserves of a pair with getReserves at
+// blockNumber, and scans its Sync logs back from that block instead when the
+// node fails the call and SyncLogFallback is set. An address that is not a
+// pair is never scanned. With strict set, for a past block, a failing block
+// range aborts the scan, and without the scan a node missing the state of the
+// block fails with ErrHistoryUnavailable.
+func (c *client) readReservePair(
+	ctx context.Context,
+	pairAddress common.Address,
+	blockNumber uint64,
+	strict bool,
+) (*ReservePair, error) {
+	logger := log.Ctx(ctx)
+
+	pair, err := c.callReservePair(ctx, pairAddress, blockNumber)
+	if err == nil {
+		return pair, nil
+	}
+	if errors.Is(err, ErrNotPair) {
+		return nil, err
+	}
+	if !c.cfg.SyncLogFallback {
+		if strict && rpcpool.IsMissingStateError(err) {
+			return nil, fmt.Errorf("%w: block %d: %v", ErrHistoryUnavailable, blockNumber, err)
+		}
+		return nil, err
+	}
+
+	logger.Warn().
+		Err(err).
+		Str("pair_address", pairAddress.Hex()).
+		Uint64("block_number", blockNumber).
+		Msg("Falling back to a Sync log scan")
+	return c.scanReservePair(ctx, pairAddress, blockNumber, strict)
+}
+
+// callReservePair calls getReserves on a pair at blockNumber, pinned to the
+// hash of that block. A call the contract reverts or answers with no data or
+// undecodable data fails with ErrNotPair.
+func (c *client) callReservePair(
+	ctx context.Context,
+	pairAddress common.Address,
+	blockNumber uint64,
+) (*ReservePair, error) {
+	logger := log.Ctx(ctx)
+
+	parsedABI, err := abi.JSON(strings.NewReader(uniswapV2PairABI))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to parse Uniswap V2 Pair ABI")
+		return nil, fmt.Errorf("failed to parse ABI: %v", err)
+	}
+	data, err := parsedABI.Pack("getReserves")
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack getReserves: %v", err)
+	}
+
+	// The call is pinned by hash, so that the reported hash is the one of the
+	// block read, even across providers or a reorganization
+	block := new(big.Int).SetUint64(blockNumber)
+	header, err := readPinned(ctx, c, "HeaderByNumber", func() (*types.Header, error) {
+		return c.gethClient.HeaderByNumber(ctx, block)
+	})
+	if err != nil {
+		logger.Error().Err(err).Uint64("block_number", blockNumber).Msg("Failed to get block header")
+		return nil, fmt.Errorf("failed to get block header: %v", err)
+	}
+	blockHash := header.Hash()
+
+	res, err := readPinned(ctx, c, "getReserves", func() ([]byte, error) {
+		return c.gethClient.CallContractAtHash(ctx, ethereum.CallMsg{To: &pairAddress, Data: data}, blockHash)
+	})
+	if err != nil && rpcpool.IsRevertError(err) {
+		logger.Error().
+			Err(err).
+			Str("pair_address", pairAddress.Hex()).
+			Msg("getReserves reverted")
+		return nil, fmt.Errorf("%w: getReserves reverted: %v", ErrNotPair, err)
+	}
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Str("pair_address", pairAddress.Hex()).
+			Uint64("block_number", blockNumber).
+			Msg("Failed to call getReserves")
+		return nil, fmt.Errorf("failed to call getReserves: %v", err)
+	}
+	if len(res) == 0 {
+		logger.Error().
+			Str("pair_address", pairAddress.Hex()).
+			Msg("getReserves returned no data")
+		return nil, fmt.Errorf("%w: getReserves returned no data", ErrNotPair)
+	}
+	pair, err := unpackReserves(parsedABI, res)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to unpack getReserves")
+		return nil, fmt.Errorf("%w: %v", ErrNotPair, err)
+	}
+
+	pair.BlockNumber = blockNumber
+	pair.BlockHash = blockHash
+
+	logger.Debug().
+		Str("pair_address", pairAddress.Hex()).
+		Uint64("block_number", blockNumber).
+		Uint32("block_timestamp_last", pair.BlockTimestampLast).
+		Msg("Uniswap V2 reserves details")
+
+	return pair, nil
+}
+
+// unpackReserves decodes the output of getReserves. The block of the
+// reserves is left to the caller.
+func unpackReserves(parsedABI abi.ABI, res []byte) (*ReservePair, error) {
+	out, err := parsedABI.Unpack("getReserves", res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack getReserves: %v", err)
+	}
+	return &ReservePair{
+		Reserve0:           out[0].(*big.Int),
+		Reserve1:           out[1].(*big.Int),
+		BlockTimestampLast: out[2].(uint32),
+	}, nil
+}
+
+// scanReservePair searches backward from latestBlock for the latest Sync event of
+// a pair, in block ranges sized by filterLogsBefore. A block range the node
+// keeps failing aborts the search, as ErrHistoryUnavailable with strict set.
+func (c *client) scanReservePair(
+	ctx context.Context,
+	pairAddress common.Address,
+	latestBlock uint64,
+	strict bool,
+) (*ReservePair, error) {
+	logger := log.Ctx(ctx)
+
+	parsedABI, err := abi.JSON(strings.NewReader(uniswapV2PairABI))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to parse Uniswap V2 Pair ABI")
+		return nil, fmt.Errorf("failed to parse ABI: %v", err)
+	}
 
 	var logs []types.Log
 	var foundLogs bool
 
-	// Start from the latest block and search backward in chunks
-	for toBlock := latestBlock; toBlock > 0; toBlock -= c.cfg.BlockRangeSize {
-		fromBlock := toBlock - c.cfg.BlockRangeSize
-		if fromBlock > toBlock {
-			fromBlock = 0
-		}
+	query := ethereum.FilterQuery{
+		Addresses: []common.Address{pairAddress},
+		Topics:    [][]common.Hash{{parsedABI.Events["Sync"].ID}},
+	}
 
-		query := ethereum.FilterQuery{
-			FromBlock: big.NewInt(int64(fromBlock)),
-			ToBlock:   big.NewInt(int64(toBlock)),
-			Addresses: []common.Address{pairAddress},
-			Topics:    [][]common.Hash{{parsedABI.Events["Sync"].ID}},
+	// Start from the latest block and search backward in chunks, down to the
+	// genesis block
+	for toBlock := latestBlock; ; {
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
 
-		chunkLogs, err := c.gethClient.FilterLogs(ctx, query)
+		chunkLogs, fromBlock, err := c.filterLogsBefore(ctx, query, toBlock)
 		if err != nil {
 			logger.Error().Err(err).Msgf("Failed to filter logs from block %d to %d", fromBlock, toBlock)
-			continue
+			if strict {
+				return nil, fmt.Errorf("%w: blocks %d to %d: %v", ErrHistoryUnavailable, fromBlock, toBlock, err)
+			}
+			// Skipping the range could return older reserves silently
+			return nil, fmt.Errorf("failed to filter logs from block %d to %d: %v", fromBlock, toBlock, err)
 		}
 
 		if len(chunkLogs) > 0 {
@@ -77,6 +282,10 @@ func (c *client) UniV2ReservePair(
 			foundLogs = true
 			break
 		}
+		if fromBlock == 0 {
+			break
+		}
+		toBlock = fromBlock - 1
 	}
 
 	if !foundLogs {
@@ -99,7 +308,10 @@ func (c *client) UniV2ReservePair(
 		Msg("Uniswap V2 swap estimation details")
 
 	return &ReservePair{
-		Reserve0: reserve0,
-		Reserve1: reserve1,
+		Reserve0:    reserve0,
+		Reserve1:    reserve1,
+		BlockNumber: latestLog.BlockNumber,
+		BlockHash:   latestLog.BlockHash,
+		LogIndex:    latestLog.Index,
 	}, nil
 }
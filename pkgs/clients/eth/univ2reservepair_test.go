@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	. "github.com/smartystreets/goconvey/convey"
@@ -23,6 +25,153 @@ func TestUniV2ReservePair(t *testing.T) {
 			// Set block range size for testing
 			s.client.cfg.BlockRangeSize = 100
 
+			pairABI, err := abi.JSON(strings.NewReader(uniswapV2PairABI))
+			So(err, ShouldBeNil)
+
+			Convey("When the pair serves getReserves at the latest block", func(c C) {
+				latestBlock := uint64(15000000)
+				header := &types.Header{Number: new(big.Int).SetUint64(latestBlock)}
+
+				s.gethClient.EXPECT().
+					BlockNumber(gomock.Any()).
+					Return(latestBlock, nil)
+
+				gomock.InOrder(
+					s.gethClient.EXPECT().
+						HeaderByNumber(gomock.Any(), new(big.Int).SetUint64(latestBlock)).
+						Return(header, nil),
+					s.gethClient.EXPECT().
+						CallContractAtHash(gomock.Any(), gomock.Any(), gomock.Any()).
+						DoAndReturn(func(_ context.Context, call ethereum.CallMsg, blockHash common.Hash) ([]byte, error) {
+							// The call is pinned to the block the reserves are reported at
+							c.So(*call.To, ShouldEqual, common.HexToAddress(pairAddr))
+							c.So(blockHash, ShouldEqual, header.Hash())
+							return pairABI.Methods["getReserves"].Outputs.Pack(big.NewInt(5000), big.NewInt(10000), uint32(1700000000))
+						}),
+				)
+
+				// No FilterLogs call expected
+
+				result, err := s.client.UniV2ReservePair(ctx, common.HexToAddress(pairAddr))
+
+				Convey("Then it should return the reserves as of that block", func() {
+					So(err, ShouldBeNil)
+					So(result, ShouldNotBeNil)
+					So(result.Reserve0.Cmp(big.NewInt(5000)), ShouldEqual, 0)
+					So(result.Reserve1.Cmp(big.NewInt(10000)), ShouldEqual, 0)
+					So(result.BlockNumber, ShouldEqual, latestBlock)
+					So(result.BlockHash, ShouldEqual, header.Hash())
+					So(result.BlockTimestampLast, ShouldEqual, 1700000000)
+				})
+			})
+
+			Convey("When the node serving the call has not seen the block yet", func(c C) {
+				latestBlock := uint64(15000000)
+				header := &types.Header{Number: new(big.Int).SetUint64(latestBlock)}
+
+				s.gethClient.EXPECT().
+					BlockNumber(gomock.Any()).
+					Return(latestBlock, nil)
+
+				gomock.InOrder(
+					s.gethClient.EXPECT().
+						HeaderByNumber(gomock.Any(), new(big.Int).SetUint64(latestBlock)).
+						Return(nil, errors.New("header not found")),
+					s.gethClient.EXPECT().
+						HeaderByNumber(gomock.Any(), new(big.Int).SetUint64(latestBlock)).
+						Return(header, nil),
+					s.gethClient.EXPECT().
+						CallContractAtHash(gomock.Any(), gomock.Any(), gomock.Any()).
+						Return(nil, errors.New("header for hash not found")),
+					s.gethClient.EXPECT().
+						CallContractAtHash(gomock.Any(), gomock.Any(), gomock.Any()).
+						DoAndReturn(func(_ context.Context, _ ethereum.CallMsg, blockHash common.Hash) ([]byte, error) {
+							c.So(blockHash, ShouldEqual, header.Hash())
+							return pairABI.Methods["getReserves"].Outputs.Pack(big.NewInt(5000), big.NewInt(10000), uint32(1700000000))
+						}),
+				)
+
+				// No FilterLogs call expected
+
+				result, err := s.client.UniV2ReservePair(ctx, common.HexToAddress(pairAddr))
+
+				Convey("Then the call should be retried at the same block", func() {
+					So(err, ShouldBeNil)
+					So(result.Reserve0.Cmp(big.NewInt(5000)), ShouldEqual, 0)
+					So(result.BlockNumber, ShouldEqual, latestBlock)
+				})
+			})
+
+			Convey("When the address answers getReserves with no data", func() {
+				s.gethClient.EXPECT().
+					BlockNumber(gomock.Any()).
+					Return(uint64(15000000), nil)
+
+				s.gethClient.EXPECT().
+					HeaderByNumber(gomock.Any(), gomock.Any()).
+					Return(&types.Header{}, nil)
+				s.gethClient.EXPECT().
+					CallContractAtHash(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return([]byte{}, nil)
+
+				// No FilterLogs call expected
+
+				result, err := s.client.UniV2ReservePair(ctx, common.HexToAddress(pairAddr))
+
+				Convey("Then it should return a not a pair error without scanning logs", func() {
+					So(errors.Is(err, ErrNotPair), ShouldBeTrue)
+					So(result, ShouldBeNil)
+				})
+			})
+
+			Convey("When the address reverts getReserves", func() {
+				s.gethClient.EXPECT().
+					BlockNumber(gomock.Any()).
+					Return(uint64(15000000), nil)
+
+				s.gethClient.EXPECT().
+					HeaderByNumber(gomock.Any(), gomock.Any()).
+					Return(&types.Header{}, nil)
+				s.gethClient.EXPECT().
+					CallContractAtHash(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("execution reverted"))
+
+				// No FilterLogs call expected
+
+				result, err := s.client.UniV2ReservePair(ctx, common.HexToAddress(pairAddr))
+
+				Convey("Then it should return a not a pair error without scanning logs", func() {
+					So(errors.Is(err, ErrNotPair), ShouldBeTrue)
+					So(result, ShouldBeNil)
+				})
+			})
+
+			Convey("When getReserves fails and the Sync log fallback is disabled", func() {
+				s.client.cfg.SyncLogFallback = false
+				defer func() { s.client.cfg.SyncLogFallback = true }()
+
+				s.gethClient.EXPECT().
+					BlockNumber(gomock.Any()).
+					Return(uint64(15000000), nil)
+
+				s.gethClient.EXPECT().
+					HeaderByNumber(gomock.Any(), gomock.Any()).
+					Return(&types.Header{}, nil)
+				s.gethClient.EXPECT().
+					CallContractAtHash(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("missing trie node"))
+
+				// No FilterLogs call expected
+
+				result, err := s.client.UniV2ReservePair(ctx, common.HexToAddress(pairAddr))
+
+				Convey("Then it should return the error of the call", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldContainSubstring, "failed to call getReserves")
+					So(result, ShouldBeNil)
+				})
+			})
+
 			Convey("When querying for pool reserves and logs are found in the first block range", func(c C) {
 				// Mock responses
 				latestBlock := uint64(15000000)
@@ -49,6 +198,7 @@ func TestUniV2ReservePair(t *testing.T) {
 						Topics:      []common.Hash{common.HexToHash(syncEventSig)},
 						Data:        []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x13, 0x89, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x27, 0x11},
 						BlockNumber: 15000000 - 5,
+						BlockHash:   common.HexToHash("0xabc"),
 						TxHash:      common.HexToHash("0x456"),
 					},
 				}
@@ -58,6 +208,14 @@ func TestUniV2ReservePair(t *testing.T) {
 					BlockNumber(gomock.Any()).
 					Return(latestBlock, nil)
 
+				// The node fails getReserves, so the Sync logs are scanned
+				s.gethClient.EXPECT().
+					HeaderByNumber(gomock.Any(), gomock.Any()).
+					Return(&types.Header{}, nil)
+				s.gethClient.EXPECT().
+					CallContractAtHash(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("missing trie node"))
+
 				s.gethClient.EXPECT().
 					FilterLogs(gomock.Any(), gomock.Any()).
 					DoAndReturn(func(_ context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
@@ -72,7 +230,7 @@ func TestUniV2ReservePair(t *testing.T) {
 					})
 
 				// Call the function
-				result, err := s.client.UniV2ReservePair(ctx, pairAddr)
+				result, err := s.client.UniV2ReservePair(ctx, common.HexToAddress(pairAddr))
 
 				Convey("Then it should return the latest reserve values without error", func() {
 					So(err, ShouldBeNil)
@@ -82,6 +240,10 @@ func TestUniV2ReservePair(t *testing.T) {
 					// Data contains reserve0 = 5001 (0x1389) and reserve1 = 10001 (0x2711)
 					So(result.Reserve0.Cmp(big.NewInt(5001)), ShouldEqual, 0)
 					So(result.Reserve1.Cmp(big.NewInt(10001)), ShouldEqual, 0)
+
+					// The block of the latest Sync event is kept with the reserves
+					So(result.BlockNumber, ShouldEqual, 15000000-5)
+					So(result.BlockHash, ShouldEqual, common.HexToHash("0xabc"))
 				})
 			})
 
@@ -99,8 +261,8 @@ func TestUniV2ReservePair(t *testing.T) {
 
 				// Second query finds logs
 				secondQuery := ethereum.FilterQuery{
-					FromBlock: big.NewInt(14999800), // latestBlock - 2*BlockRangeSize
-					ToBlock:   big.NewInt(14999900), // latestBlock - BlockRangeSize
+					FromBlock: big.NewInt(14999799), // latestBlock - 2*BlockRangeSize - 1
+					ToBlock:   big.NewInt(14999899), // latestBlock - BlockRangeSize - 1
 					Addresses: []common.Address{common.HexToAddress(pairAddr)},
 					Topics:    [][]common.Hash{{common.HexToHash(syncEventSig)}},
 				}
@@ -121,6 +283,14 @@ func TestUniV2ReservePair(t *testing.T) {
 					BlockNumber(gomock.Any()).
 					Return(latestBlock, nil)
 
+				// The node fails getReserves, so the Sync logs are scanned
+				s.gethClient.EXPECT().
+					HeaderByNumber(gomock.Any(), gomock.Any()).
+					Return(&types.Header{}, nil)
+				s.gethClient.EXPECT().
+					CallContractAtHash(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("missing trie node"))
+
 				s.gethClient.EXPECT().
 					FilterLogs(gomock.Any(), gomock.Any()).
 					DoAndReturn(func(_ context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
@@ -146,7 +316,7 @@ func TestUniV2ReservePair(t *testing.T) {
 					})
 
 				// Call the function
-				result, err := s.client.UniV2ReservePair(ctx, pairAddr)
+				result, err := s.client.UniV2ReservePair(ctx, common.HexToAddress(pairAddr))
 
 				Convey("Then it should return the reserve values from the found logs", func() {
 					So(err, ShouldBeNil)
@@ -168,6 +338,14 @@ func TestUniV2ReservePair(t *testing.T) {
 					BlockNumber(gomock.Any()).
 					Return(latestBlock, nil)
 
+				// The node fails getReserves, so the Sync logs are scanned
+				s.gethClient.EXPECT().
+					HeaderByNumber(gomock.Any(), gomock.Any()).
+					Return(&types.Header{}, nil)
+				s.gethClient.EXPECT().
+					CallContractAtHash(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("missing trie node"))
+
 				// Need to set up multiple FilterLogs calls that will all return empty logs
 				// This is a simplification - the real implementation would make many calls
 				// until it reaches block 0 or finds logs
@@ -175,11 +353,12 @@ func TestUniV2ReservePair(t *testing.T) {
 				maxQueries := 3 // Limit the number of queries for the test
 
 				for i := 0; i < maxQueries; i++ {
-					fromBlock := int64(latestBlock) - int64(uint64(i+1)*blockRangeSize)
+					// Each range ends right before the previous one
+					toBlock := int64(latestBlock) - int64(uint64(i)*(blockRangeSize+1))
+					fromBlock := toBlock - int64(blockRangeSize)
 					if fromBlock < 0 {
 						fromBlock = 0
 					}
-					toBlock := int64(latestBlock) - int64(uint64(i)*blockRangeSize)
 
 					s.gethClient.EXPECT().
 						FilterLogs(gomock.Any(), gomock.Any()).
@@ -191,7 +370,7 @@ func TestUniV2ReservePair(t *testing.T) {
 				}
 
 				// Call the function
-				result, err := s.client.UniV2ReservePair(ctx, pairAddr)
+				result, err := s.client.UniV2ReservePair(ctx, common.HexToAddress(pairAddr))
 
 				Convey("Then it should return an error", func() {
 					So(err, ShouldNotBeNil)
@@ -200,6 +379,19 @@ func TestUniV2ReservePair(t *testing.T) {
 				})
 			})
 
+			Convey("When the context is canceled during a scan", func() {
+				canceledCtx, cancel := context.WithCancel(ctx)
+				cancel()
+
+				// No log query is expected
+				result, err := s.client.scanReservePair(canceledCtx, common.HexToAddress(pairAddr), 300, false)
+
+				Convey("Then it should stop before querying any block range", func() {
+					So(err, ShouldEqual, context.Canceled)
+					So(result, ShouldBeNil)
+				})
+			})
+
 			Convey("When there is an error getting the latest block number", func() {
 				// Set up expectations
 				s.gethClient.EXPECT().
@@ -207,7 +399,7 @@ func TestUniV2ReservePair(t *testing.T) {
 					Return(uint64(0), errors.New("blockchain connection error"))
 
 				// Call the function
-				result, err := s.client.UniV2ReservePair(ctx, pairAddr)
+				result, err := s.client.UniV2ReservePair(ctx, common.HexToAddress(pairAddr))
 
 				Convey("Then it should return the error", func() {
 					So(err, ShouldNotBeNil)
@@ -225,18 +417,27 @@ func TestUniV2ReservePair(t *testing.T) {
 					BlockNumber(gomock.Any()).
 					Return(latestBlock, nil)
 
+				// The node fails getReserves, so the Sync logs are scanned
+				s.gethClient.EXPECT().
+					HeaderByNumber(gomock.Any(), gomock.Any()).
+					Return(&types.Header{}, nil)
+				s.gethClient.EXPECT().
+					CallContractAtHash(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("missing trie node"))
+
+				// The query is retried before giving up
 				s.gethClient.EXPECT().
 					FilterLogs(gomock.Any(), gomock.Any()).
-					Return(nil, errors.New("filter logs error"))
+					Return(nil, errors.New("filter logs error")).
+					Times(1 + s.client.cfg.LogQueryRetries)
 
 				// Call the function
-				result, err := s.client.UniV2ReservePair(ctx, pairAddr)
+				result, err := s.client.UniV2ReservePair(ctx, common.HexToAddress(pairAddr))
 
-				Convey("Then it should continue searching in earlier blocks", func() {
-					// In the actual implementation, it would continue searching
-					// For the test, we're just verifying it doesn't immediately return an error
+				Convey("Then it should return the error rather than skip the range", func() {
 					So(err, ShouldNotBeNil)
-					So(err.Error(), ShouldContainSubstring, "no Sync events found")
+					So(err.Error(), ShouldContainSubstring, "failed to filter logs from block 0 to 100")
+					So(err.Error(), ShouldContainSubstring, "filter logs error")
 					So(result, ShouldBeNil)
 				})
 			})
@@ -261,12 +462,20 @@ func TestUniV2ReservePair(t *testing.T) {
 					BlockNumber(gomock.Any()).
 					Return(latestBlock, nil)
 
+				// The node fails getReserves, so the Sync logs are scanned
+				s.gethClient.EXPECT().
+					HeaderByNumber(gomock.Any(), gomock.Any()).
+					Return(&types.Header{}, nil)
+				s.gethClient.EXPECT().
+					CallContractAtHash(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("missing trie node"))
+
 				s.gethClient.EXPECT().
 					FilterLogs(gomock.Any(), gomock.Any()).
 					Return(mockLogs, nil)
 
 				// Call the function
-				result, err := s.client.UniV2ReservePair(ctx, pairAddr)
+				result, err := s.client.UniV2ReservePair(ctx, common.HexToAddress(pairAddr))
 
 				Convey("Then it should return an error", func() {
 					So(err, ShouldNotBeNil)
@@ -277,3 +486,163 @@ func TestUniV2ReservePair(t *testing.T) {
 		})
 	})
 }
+
+func TestUniV2ReservePairAt(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given the UniV2ReservePairAt function", t, func() {
+			ctx := context.Background()
+			pairAddr := "0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"                             // WETH-USDC pair
+			syncEventSig := "0x1c411e9a96e071241c2f21f7726b17ae89e3cab4c78be50e062b03a9fffbbad1" // Sync event signature
+
+			// Set block range size for testing
+			s.client.cfg.BlockRangeSize = 100
+			latestBlock := uint64(15000000)
+			historicalBlock := uint64(14000000)
+
+			Convey("When the pair serves getReserves at a past block", func(c C) {
+				pairABI, err := abi.JSON(strings.NewReader(uniswapV2PairABI))
+				So(err, ShouldBeNil)
+				header := &types.Header{Number: new(big.Int).SetUint64(historicalBlock)}
+
+				s.gethClient.EXPECT().
+					BlockNumber(gomock.Any()).
+					Return(latestBlock, nil)
+
+				gomock.InOrder(
+					s.gethClient.EXPECT().
+						HeaderByNumber(gomock.Any(), new(big.Int).SetUint64(historicalBlock)).
+						Return(header, nil),
+					s.gethClient.EXPECT().
+						CallContractAtHash(gomock.Any(), gomock.Any(), gomock.Any()).
+						DoAndReturn(func(_ context.Context, _ ethereum.CallMsg, blockHash common.Hash) ([]byte, error) {
+							c.So(blockHash, ShouldEqual, header.Hash())
+							return pairABI.Methods["getReserves"].Outputs.Pack(big.NewInt(5000), big.NewInt(10000), uint32(1600000000))
+						}),
+				)
+
+				result, err := s.client.UniV2ReservePairAt(ctx, common.HexToAddress(pairAddr), historicalBlock)
+
+				Convey("Then it should return the reserves as of that block", func() {
+					So(err, ShouldBeNil)
+					So(result, ShouldNotBeNil)
+					So(result.Reserve0.Cmp(big.NewInt(5000)), ShouldEqual, 0)
+					So(result.BlockNumber, ShouldEqual, historicalBlock)
+					So(result.BlockHash, ShouldEqual, header.Hash())
+					So(result.BlockTimestampLast, ShouldEqual, 1600000000)
+				})
+			})
+
+			Convey("When querying for pool reserves at a past block", func(c C) {
+				mockLogs := []types.Log{
+					{
+						Address:     common.HexToAddress(pairAddr),
+						Topics:      []common.Hash{common.HexToHash(syncEventSig)},
+						Data:        []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x13, 0x88, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x27, 0x10},
+						BlockNumber: 14000000 - 20,
+						BlockHash:   common.HexToHash("0xdef"),
+						TxHash:      common.HexToHash("0x123"),
+					},
+				}
+
+				s.gethClient.EXPECT().
+					BlockNumber(gomock.Any()).
+					Return(latestBlock, nil)
+
+				// The node fails getReserves, so the Sync logs are scanned
+				s.gethClient.EXPECT().
+					HeaderByNumber(gomock.Any(), gomock.Any()).
+					Return(&types.Header{}, nil)
+				s.gethClient.EXPECT().
+					CallContractAtHash(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("missing trie node"))
+
+				s.gethClient.EXPECT().
+					FilterLogs(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+						// The scan starts from the requested block, not the head
+						c.So(query.FromBlock.Int64(), ShouldEqual, 13999900)
+						c.So(query.ToBlock.Int64(), ShouldEqual, 14000000)
+						c.So(query.Addresses, ShouldContain, common.HexToAddress(pairAddr))
+						return mockLogs, nil
+					})
+
+				result, err := s.client.UniV2ReservePairAt(ctx, common.HexToAddress(pairAddr), historicalBlock)
+
+				Convey("Then it should return the reserves as of that block", func() {
+					So(err, ShouldBeNil)
+					So(result, ShouldNotBeNil)
+					So(result.Reserve0.Cmp(big.NewInt(5000)), ShouldEqual, 0)
+					So(result.Reserve1.Cmp(big.NewInt(10000)), ShouldEqual, 0)
+					So(result.BlockNumber, ShouldEqual, 14000000-20)
+					So(result.BlockHash, ShouldEqual, common.HexToHash("0xdef"))
+				})
+			})
+
+			Convey("When the block is past the chain head", func() {
+				s.gethClient.EXPECT().
+					BlockNumber(gomock.Any()).
+					Return(latestBlock, nil)
+
+				result, err := s.client.UniV2ReservePairAt(ctx, common.HexToAddress(pairAddr), latestBlock+1)
+
+				Convey("Then it should return a block not reached error", func() {
+					So(errors.Is(err, ErrBlockNotReached), ShouldBeTrue)
+					So(result, ShouldBeNil)
+				})
+			})
+
+			Convey("When the node cannot serve the logs of the block range", func() {
+				s.gethClient.EXPECT().
+					BlockNumber(gomock.Any()).
+					Return(latestBlock, nil)
+
+				// The node fails getReserves, so the Sync logs are scanned
+				s.gethClient.EXPECT().
+					HeaderByNumber(gomock.Any(), gomock.Any()).
+					Return(&types.Header{}, nil)
+				s.gethClient.EXPECT().
+					CallContractAtHash(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("missing trie node"))
+
+				s.gethClient.EXPECT().
+					FilterLogs(gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("missing trie node")).
+					Times(1 + s.client.cfg.LogQueryRetries)
+
+				result, err := s.client.UniV2ReservePairAt(ctx, common.HexToAddress(pairAddr), historicalBlock)
+
+				Convey("Then it should return a history unavailable error without skipping the range", func() {
+					So(errors.Is(err, ErrHistoryUnavailable), ShouldBeTrue)
+					So(err.Error(), ShouldContainSubstring, "missing trie node")
+					So(result, ShouldBeNil)
+				})
+			})
+
+			Convey("When the node misses the state of the block and the Sync log fallback is disabled", func() {
+				s.client.cfg.SyncLogFallback = false
+				defer func() { s.client.cfg.SyncLogFallback = true }()
+
+				s.gethClient.EXPECT().
+					BlockNumber(gomock.Any()).
+					Return(latestBlock, nil)
+
+				s.gethClient.EXPECT().
+					HeaderByNumber(gomock.Any(), gomock.Any()).
+					Return(&types.Header{}, nil)
+				s.gethClient.EXPECT().
+					CallContractAtHash(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("missing trie node 5a0f3e (path ) state 0x1d4a is not available"))
+
+				// No FilterLogs call expected
+
+				result, err := s.client.UniV2ReservePairAt(ctx, common.HexToAddress(pairAddr), historicalBlock)
+
+				Convey("Then it should return a history unavailable error", func() {
+					So(errors.Is(err, ErrHistoryUnavailable), ShouldBeTrue)
+					So(err.Error(), ShouldContainSubstring, "missing trie node")
+					So(result, ShouldBeNil)
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,126 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// UniV2ReservePairs reads the reserves and tokens of several pairs at the
+// latest block, batching getReserves, token0 and token1 of every pair through
+// Multicall3. The block is pinned by hash, so that every pair is read at the
+// same height even if the chain moves or reorganizes between the calls.
+// Pairs with a failing call are left out of the result; there is no Sync log
+// fallback, which could not keep them at the pinned block.
+func (c *client) UniV2ReservePairs(
+	ctx context.Context,
+	pairAddresses []common.Address,
+) (map[common.Address]*ReservePair, error) {
+	logger := log.Ctx(ctx)
+
+	pairABI, err := abi.JSON(strings.NewReader(uniswapV2PairABI))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to parse Uniswap V2 Pair ABI")
+		return nil, fmt.Errorf("failed to parse ABI: %v", err)
+	}
+	metadataABI, err := abi.JSON(strings.NewReader(uniswapV2PairMetadataABI))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to parse Uniswap V2 Pair ABI")
+		return nil, fmt.Errorf("failed to parse ABI: %v", err)
+	}
+	getReservesData, err := pairABI.Pack("getReserves")
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack getReserves: %v", err)
+	}
+	token0Data, err := metadataABI.Pack("token0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack token0: %v", err)
+	}
+	token1Data, err := metadataABI.Pack("token1")
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack token1: %v", err)
+	}
+
+	header, err := c.gethClient.HeaderByNumber(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get latest block header")
+		return nil, fmt.Errorf("failed to get latest block header: %v", err)
+	}
+	blockNumber, blockHash := header.Number.Uint64(), header.Hash()
+
+	logger.Debug().
+		Int("pair_count", len(pairAddresses)).
+		Uint64("block_number", blockNumber).
+		Str("block_hash", blockHash.Hex()).
+		Msg("Reading Uniswap V2 reserves at a pinned block")
+
+	// Three calls per pair, in this order
+	calls := make([]call3, 0, 3*len(pairAddresses))
+	for _, pairAddress := range pairAddresses {
+		calls = append(calls,
+			call3{Target: pairAddress, AllowFailure: true, CallData: getReservesData},
+			call3{Target: pairAddress, AllowFailure: true, CallData: token0Data},
+			call3{Target: pairAddress, AllowFailure: true, CallData: token1Data},
+		)
+	}
+	results, err := c.aggregate3(ctx, calls, blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := make(map[common.Address]*ReservePair, len(pairAddresses))
+	for i, pairAddress := range pairAddresses {
+		pair, err := unpackReservePairResults(pairABI, metadataABI, results[3*i:3*i+3])
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Str("pair_address", pairAddress.Hex()).
+				Msg("Failed to read pair through Multicall3")
+			continue
+		}
+		pair.BlockNumber = blockNumber
+		pair.BlockHash = blockHash
+		pairs[pairAddress] = pair
+	}
+
+	logger.Debug().
+		Int("pair_count", len(pairAddresses)).
+		Int("read_count", len(pairs)).
+		Msg("Uniswap V2 reserves read at a pinned block")
+	return pairs, nil
+}
+
+// unpackReservePairResults decodes the getReserves, token0 and token1 results
+// of a pair. The block of the reserves is left to the caller.
+func unpackReservePairResults(pairABI, metadataABI abi.ABI, results []result3) (*ReservePair, error) {
+	for i, method := range []string{"getReserves", "token0", "token1"} {
+		if !results[i].Success {
+			return nil, fmt.Errorf("%s failed", method)
+		}
+	}
+
+	pair, err := unpackReserves(pairABI, results[0].ReturnData)
+	if err != nil {
+		return nil, err
+	}
+	token0, err := metadataABI.Unpack("token0", results[1].ReturnData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack token0: %v", err)
+	}
+	token1, err := metadataABI.Unpack("token1", results[2].ReturnData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack token1: %v", err)
+	}
+	pair.Token0 = token0[0].(common.Address)
+	pair.Token1 = token1[0].(common.Address)
+	if pair.Token0 == (common.Address{}) || pair.Token1 == (common.Address{}) {
+		return nil, fmt.Errorf("pair has no tokens")
+	}
+	return pair, nil
+}
@@ -0,0 +1,104 @@
+package eth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+func TestUniV2ReservePairs(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given the UniV2ReservePairs function", t, func() {
+			ctx := context.Background()
+			wethUsdcAddr := common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc")
+			usdcUsdtAddr := common.HexToAddress("0x3041CbD36888bECc7bbCBc0045E3B1f144466f5f")
+			usdcAddr := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+			wethAddr := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+			header := &types.Header{Number: big.NewInt(15000000)}
+
+			pairABI, err := abi.JSON(strings.NewReader(uniswapV2PairABI))
+			So(err, ShouldBeNil)
+			metadataABI, err := abi.JSON(strings.NewReader(uniswapV2PairMetadataABI))
+			So(err, ShouldBeNil)
+
+			Convey("When reading two pairs", func() {
+				s.gethClient.EXPECT().
+					HeaderByNumber(gomock.Any(), nil).
+					Return(header, nil)
+
+				// One Multicall3 call for both pairs, where the second pair
+				// reverts on getReserves
+				s.gethClient.EXPECT().
+					CallContractAtHash(gomock.Any(), gomock.Any(), header.Hash()).
+					DoAndReturn(serveAggregate3(func(call call3) ([]byte, error) {
+						switch {
+						case bytes.Equal(call.CallData, metadataABI.Methods["token0"].ID):
+							return metadataABI.Methods["token0"].Outputs.Pack(usdcAddr)
+						case bytes.Equal(call.CallData, metadataABI.Methods["token1"].ID):
+							return metadataABI.Methods["token1"].Outputs.Pack(wethAddr)
+						case call.Target == usdcUsdtAddr:
+							return nil, errors.New("execution reverted")
+						}
+						return pairABI.Methods["getReserves"].Outputs.Pack(big.NewInt(5000), big.NewInt(10000), uint32(1700000000))
+					}))
+
+				result, err := s.client.UniV2ReservePairs(ctx, []common.Address{wethUsdcAddr, usdcUsdtAddr})
+
+				Convey("Then it should return the pairs read at the pinned block and leave out the others", func() {
+					So(err, ShouldBeNil)
+					So(result, ShouldHaveLength, 1)
+					So(result, ShouldContainKey, wethUsdcAddr)
+					So(result[wethUsdcAddr].Reserve0.Cmp(big.NewInt(5000)), ShouldEqual, 0)
+					So(result[wethUsdcAddr].Reserve1.Cmp(big.NewInt(10000)), ShouldEqual, 0)
+					So(result[wethUsdcAddr].Token0, ShouldEqual, usdcAddr)
+					So(result[wethUsdcAddr].Token1, ShouldEqual, wethAddr)
+					So(result[wethUsdcAddr].BlockNumber, ShouldEqual, 15000000)
+					So(result[wethUsdcAddr].BlockHash, ShouldEqual, header.Hash())
+					So(result[wethUsdcAddr].BlockTimestampLast, ShouldEqual, 1700000000)
+				})
+			})
+
+			Convey("When the node fails the Multicall3 call", func() {
+				s.gethClient.EXPECT().
+					HeaderByNumber(gomock.Any(), nil).
+					Return(header, nil)
+				s.gethClient.EXPECT().
+					CallContractAtHash(gomock.Any(), gomock.Any(), header.Hash()).
+					Return(nil, errors.New("503 Service Unavailable"))
+
+				result, err := s.client.UniV2ReservePairs(ctx, []common.Address{wethUsdcAddr, usdcUsdtAddr})
+
+				Convey("Then it should return the error rather than leave the pairs out", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldContainSubstring, "503 Service Unavailable")
+					So(result, ShouldBeNil)
+				})
+			})
+
+			Convey("When the latest block header cannot be read", func() {
+				s.gethClient.EXPECT().
+					HeaderByNumber(gomock.Any(), nil).
+					Return(nil, errors.New("blockchain connection error"))
+
+				// No CallContractAtHash call expected
+
+				result, err := s.client.UniV2ReservePairs(ctx, []common.Address{wethUsdcAddr})
+
+				Convey("Then it should return the error", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldContainSubstring, "failed to get latest block header")
+					So(result, ShouldBeNil)
+				})
+			})
+		})
+	})
+}
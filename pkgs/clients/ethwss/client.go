@@ -1,10 +1,11 @@
 package ethwss
 
 import (
-	"math/big"
 	"sync"
 	"time"
 
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/event"
 )
 
@@ -16,35 +17,30 @@ type Config struct {
 
 ////////////////////////////////////////////////////////////////////////////////
 
-type ReservePair struct {
-	Reserve0 *big.Int
-	Reserve1 *big.Int
-}
-
-////////////////////////////////////////////////////////////////////////////////
-
 type client struct {
 	cfg Config
 
-	reservePairCacheMap map[string]*ReservePair
-	gethWssClient       GethWssClient
+	// Guards poolStateCacheMap so that several pools can be read as one snapshot
+	cacheLock         sync.RWMutex
+	poolStateCacheMap map[common.Address]poolmodel.State
+	gethWssClient     GethWssClient
 
 	// Track subscriptions and timers
-	pairSubscriptions map[string]event.Subscription
-	pairTimers        map[string]*time.Timer
+	poolSubscriptions map[common.Address]event.Subscription
+	poolTimers        map[common.Address]*time.Timer
 
 	// Track addresses being registered to prevent concurrent registration of the same address
 	addressLock      sync.Mutex
-	registeringPairs map[string]bool
+	registeringPools map[common.Address]bool
 }
 
 func New(cfg Config, gethWssClient GethWssClient) *client {
 	return &client{
-		cfg:                 cfg,
-		reservePairCacheMap: make(map[string]*ReservePair),
-		gethWssClient:       gethWssClient,
-		pairSubscriptions:   make(map[string]event.Subscription),
-		pairTimers:          make(map[string]*time.Timer),
-		registeringPairs:    make(map[string]bool),
+		cfg:               cfg,
+		poolStateCacheMap: make(map[common.Address]poolmodel.State),
+		gethWssClient:     gethWssClient,
+		poolSubscriptions: make(map[common.Address]event.Subscription),
+		poolTimers:        make(map[common.Address]*time.Timer),
+		registeringPools:  make(map[common.Address]bool),
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
 	"github.com/sethvargo/go-envconfig"
 	"go.uber.org/mock/gomock"
 )
@@ -12,6 +13,7 @@ import (
 
 type testSuite struct {
 	gethWssClient *MockGethWssClient
+	poolModel     *poolmodel.MockPoolModel
 
 	client *client
 }
@@ -21,6 +23,7 @@ func testInit(t *testing.T, test func(*testSuite)) {
 	defer ctrl.Finish()
 
 	gethWssClient := NewMockGethWssClient(ctrl)
+	poolModel := poolmodel.NewMockPoolModel(ctrl)
 
 	cfg := Config{
 		ListenPairPeriod: 2 * time.Minute,
@@ -32,6 +35,7 @@ func testInit(t *testing.T, test func(*testSuite)) {
 
 	ts := &testSuite{
 		gethWssClient: gethWssClient,
+		poolModel:     poolModel,
 		client:        client,
 	}
 	test(ts)
@@ -0,0 +1,43 @@
+package ethwss
+
+import (
+	"context"
+
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (c *client) GetPool(ctx context.Context, address common.Address) poolmodel.State {
+	logger := log.Ctx(ctx)
+	logger.Debug().
+		Str("pool_address", address.Hex()).
+		Msg("Getting pool state for updates")
+
+	c.cacheLock.RLock()
+	state, ok := c.poolStateCacheMap[address]
+	c.cacheLock.RUnlock()
+	if ok {
+		logger.Debug().
+			Str("pool_address", address.Hex()).
+			Msg("Pool found in cache")
+
+		// Extend the subscription period by resetting the timer
+		if timer, exists := c.poolTimers[address]; exists {
+			timer.Reset(c.cfg.ListenPairPeriod)
+			logger.Debug().
+				Str("pool_address", address.Hex()).
+				Dur("period", c.cfg.ListenPairPeriod).
+				Msg("Extended subscription period")
+		}
+
+		return state
+	}
+
+	logger.Warn().
+		Str("pool_address", address.Hex()).
+		Msg("Pool not found in cache, returning nil")
+	return nil
+}
@@ -0,0 +1,26 @@
+package ethwss
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (c *client) ListPools(ctx context.Context) []common.Address {
+	logger := log.Ctx(ctx)
+
+	c.cacheLock.RLock()
+	addresses := make([]common.Address, 0, len(c.poolStateCacheMap))
+	for address := range c.poolStateCacheMap {
+		addresses = append(addresses, address)
+	}
+	c.cacheLock.RUnlock()
+
+	logger.Debug().
+		Int("pool_count", len(addresses)).
+		Msg("Listing tracked pools")
+	return addresses
+}
@@ -0,0 +1,50 @@
+package ethwss
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/WangWilly/swap-estimation/pkgs/clients/eth"
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestListPools(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given the ListPools function", t, func() {
+			ctx := context.Background()
+			wethUsdcPairAddr := common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc")
+			usdcUsdtPairAddr := common.HexToAddress("0x3041CbD36888bECc7bbCBc0045E3B1f144466f5f")
+
+			Convey("When no pools are tracked", func() {
+				for address := range s.client.poolStateCacheMap {
+					delete(s.client.poolStateCacheMap, address)
+				}
+				result := s.client.ListPools(ctx)
+
+				Convey("Then it should return an empty list", func() {
+					So(result, ShouldBeEmpty)
+				})
+			})
+
+			Convey("When several pools are tracked", func() {
+				s.client.poolStateCacheMap[wethUsdcPairAddr] = &eth.ReservePair{
+					Reserve0: big.NewInt(5000),
+					Reserve1: big.NewInt(10000),
+				}
+				s.client.poolStateCacheMap[usdcUsdtPairAddr] = &eth.ReservePair{
+					Reserve0: big.NewInt(7000),
+					Reserve1: big.NewInt(7000),
+				}
+				result := s.client.ListPools(ctx)
+
+				Convey("Then it should return every tracked pool address", func() {
+					So(result, ShouldHaveLength, 2)
+					So(result, ShouldContain, wethUsdcPairAddr)
+					So(result, ShouldContain, usdcUsdtPairAddr)
+				})
+			})
+		})
+	})
+}
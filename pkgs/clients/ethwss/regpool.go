@@ -0,0 +1,134 @@
+package ethwss
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rs/zerolog/log"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// RegPool caches the state of a pool and keeps it up to date with the logs
+// selected by its model until the listen period expires.
+func (c *client) RegPool(ctx context.Context, address common.Address, model poolmodel.PoolModel, initState poolmodel.State) error {
+	logger := log.Ctx(ctx)
+
+	// Check if this address is already being registered by another thread
+	c.addressLock.Lock()
+	if c.registeringPools[address] {
+		c.addressLock.Unlock()
+		logger.Debug().
+			Str("pool_address", address.Hex()).
+			Msg("Registration for this pool already in progress, skipping")
+		return nil
+	}
+
+	// Mark this address as being registered
+	c.registeringPools[address] = true
+	c.addressLock.Unlock()
+
+	// Ensure we remove the flag when we're done
+	defer func() {
+		c.addressLock.Lock()
+		delete(c.registeringPools, address)
+		c.addressLock.Unlock()
+	}()
+
+	////////////////////////////////////////////////////////////////////////////
+
+	logger.Debug().
+		Str("pool_address", address.Hex()).
+		Str("model", model.Name()).
+		Msg("Registering pool for state updates")
+	c.cacheLock.Lock()
+	if _, ok := c.poolStateCacheMap[address]; ok {
+		c.cacheLock.Unlock()
+		logger.Warn().
+			Str("pool_address", address.Hex()).
+			Msg("Pool already registered, skipping registration")
+		return nil // Pool already registered
+	}
+	// from the model's initial fetch
+	c.poolStateCacheMap[address] = initState
+	c.cacheLock.Unlock()
+
+	query := model.LogQuery(address, initState)
+
+	logs := make(chan types.Log)
+	sub, err := c.gethWssClient.SubscribeFilterLogs(ctx, query, logs)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to subscribe to pool logs")
+		c.cacheLock.Lock()
+		delete(c.poolStateCacheMap, address)
+		c.cacheLock.Unlock()
+		return err
+	}
+
+	// Save subscription for later management
+	c.poolSubscriptions[address] = sub
+
+	// Create and start a timer for this subscription
+	timer := time.NewTimer(c.cfg.ListenPairPeriod)
+	c.poolTimers[address] = timer
+
+	go func() {
+		defer func() {
+			// Cleanup when done
+			c.cacheLock.Lock()
+			delete(c.poolStateCacheMap, address)
+			c.cacheLock.Unlock()
+			delete(c.poolSubscriptions, address)
+			delete(c.poolTimers, address)
+		}()
+
+		for {
+			select {
+			case <-timer.C:
+				logger.Info().
+					Str("pool_address", address.Hex()).
+					Msg("Subscription period expired, unsubscribing")
+				sub.Unsubscribe()
+				return
+			case err := <-sub.Err():
+				logger.Error().
+					Err(err).
+					Msg("Subscription error")
+				return
+			case vLog := <-logs:
+				c.cacheLock.RLock()
+				state := c.poolStateCacheMap[address]
+				c.cacheLock.RUnlock()
+
+				state, err := model.ApplyLog(state, vLog)
+				if errors.Is(err, poolmodel.ErrStateStale) {
+					state, err = model.FetchState(ctx, address)
+				}
+				if err != nil {
+					// Drop the pool so that the next request fetches it again
+					logger.Error().
+						Err(err).
+						Str("pool_address", address.Hex()).
+						Msg("Failed to update pool state, unsubscribing")
+					sub.Unsubscribe()
+					return
+				}
+				c.cacheLock.Lock()
+				c.poolStateCacheMap[address] = state
+				c.cacheLock.Unlock()
+			case <-ctx.Done():
+				logger.Info().
+					Msg("Context done, stopping subscription")
+				return
+			}
+		}
+	}()
+
+	return nil
+}
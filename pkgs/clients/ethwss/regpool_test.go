@@ -0,0 +1,206 @@
+package ethwss
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/WangWilly/swap-estimation/pkgs/clients/eth"
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRegPool(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given the RegPool function", t, func() {
+			ctx := context.Background()
+			pairAddr := common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc")        // WETH-USDC pair
+			syncEventSig := "0x1c411e9a96e071241c2f21f7726b17ae89e3cab4c78be50e062b03a9fffbbad1" // Sync event signature
+
+			initPair := &eth.ReservePair{
+				Reserve0: big.NewInt(5000),
+				Reserve1: big.NewInt(10000),
+			}
+			logQuery := ethereum.FilterQuery{
+				Addresses: []common.Address{pairAddr},
+				Topics:    [][]common.Hash{{common.HexToHash(syncEventSig)}},
+			}
+
+			s.poolModel.EXPECT().Name().Return("uniswapv2").AnyTimes()
+
+			Convey("When registering a new pool", func(c C) {
+				// Mock the subscription
+				mockSub := new(mockSubscription)
+
+				s.poolModel.EXPECT().
+					LogQuery(pairAddr, initPair).
+					Return(logQuery)
+
+				// Mock FilterLogs call
+				s.gethWssClient.EXPECT().
+					SubscribeFilterLogs(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+						c.So(query.Addresses, ShouldContain, pairAddr)
+						c.So(query.Topics, ShouldHaveLength, 1)
+						c.So(query.Topics[0], ShouldHaveLength, 1)
+						c.So(query.Topics[0][0].Hex(), ShouldEqual, syncEventSig)
+						return mockSub, nil
+					})
+
+				// Call the function
+				err := s.client.RegPool(ctx, pairAddr, s.poolModel, initPair)
+
+				Convey("Then it should register the pool without error", func() {
+					So(err, ShouldBeNil)
+
+					// Verify the pool is in the cache map
+					state, exists := s.client.poolStateCacheMap[pairAddr]
+					So(exists, ShouldBeTrue)
+					So(state, ShouldEqual, initPair)
+
+					// Verify the subscription is saved
+					sub, exists := s.client.poolSubscriptions[pairAddr]
+					So(exists, ShouldBeTrue)
+					So(sub, ShouldEqual, mockSub)
+
+					// Verify the timer is created
+					timer, exists := s.client.poolTimers[pairAddr]
+					So(exists, ShouldBeTrue)
+					So(timer, ShouldNotBeNil)
+				})
+			})
+
+			Convey("When the subscription delivers logs", func() {
+				poolAddr := common.HexToAddress("0x3041CbD36888bECc7bbCBc0045E3B1f144466f5f")
+				logsCh := make(chan chan<- types.Log, 1)
+				updatedPair := &eth.ReservePair{
+					Reserve0: big.NewInt(6000),
+					Reserve1: big.NewInt(9000),
+				}
+				refetchedPair := &eth.ReservePair{
+					Reserve0: big.NewInt(7000),
+					Reserve1: big.NewInt(8000),
+				}
+
+				s.poolModel.EXPECT().
+					LogQuery(poolAddr, initPair).
+					Return(logQuery)
+				s.gethWssClient.EXPECT().
+					SubscribeFilterLogs(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+						logsCh <- ch
+						return new(mockSubscription), nil
+					})
+
+				syncLog := types.Log{BlockNumber: 1}
+				staleLog := types.Log{BlockNumber: 2}
+				gomock.InOrder(
+					s.poolModel.EXPECT().
+						ApplyLog(initPair, syncLog).
+						Return(updatedPair, nil),
+					s.poolModel.EXPECT().
+						ApplyLog(updatedPair, staleLog).
+						Return(nil, poolmodel.ErrStateStale),
+					s.poolModel.EXPECT().
+						FetchState(gomock.Any(), poolAddr).
+						Return(refetchedPair, nil),
+				)
+
+				err := s.client.RegPool(ctx, poolAddr, s.poolModel, initPair)
+				So(err, ShouldBeNil)
+
+				ch := <-logsCh
+				ch <- syncLog
+				ch <- staleLog
+
+				Convey("Then the state should be updated by the model, refetching when stale", func() {
+					So(func() bool {
+						for range 100 {
+							if s.client.GetPool(ctx, poolAddr) == refetchedPair {
+								return true
+							}
+							time.Sleep(time.Millisecond)
+						}
+						return false
+					}(), ShouldBeTrue)
+				})
+			})
+
+			Convey("When registering a pool that's already registered", func() {
+				// Pre-populate the cache map
+				s.client.poolStateCacheMap[pairAddr] = initPair
+
+				// Call the function
+				err := s.client.RegPool(ctx, pairAddr, s.poolModel, initPair)
+
+				Convey("Then it should skip registration without error", func() {
+					So(err, ShouldBeNil)
+				})
+			})
+
+			Convey("When registration for a pool is already in progress", func() {
+				// Mark the pool as being registered
+				s.client.addressLock.Lock()
+				s.client.registeringPools[pairAddr] = true
+				delete(s.client.poolStateCacheMap, pairAddr) // Ensure it's not in cache
+				s.client.addressLock.Unlock()
+
+				// Call the function
+				err := s.client.RegPool(ctx, pairAddr, s.poolModel, initPair)
+
+				Convey("Then it should skip without error", func() {
+					So(err, ShouldBeNil)
+
+					// Verify the cache map was not modified
+					_, exists := s.client.poolStateCacheMap[pairAddr]
+					So(exists, ShouldBeFalse)
+				})
+			})
+
+			Convey("When subscription fails", func() {
+				s.client.addressLock.Lock()
+				s.client.registeringPools[pairAddr] = false
+				s.client.addressLock.Unlock()
+				delete(s.client.poolStateCacheMap, pairAddr)
+
+				s.poolModel.EXPECT().
+					LogQuery(pairAddr, initPair).
+					Return(logQuery)
+				// Mock a failed subscription
+				s.gethWssClient.EXPECT().
+					SubscribeFilterLogs(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("subscription failed"))
+
+				// Call the function
+				err := s.client.RegPool(ctx, pairAddr, s.poolModel, initPair)
+
+				Convey("Then it should return an error", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldEqual, "subscription failed")
+
+					// Verify the registration flag was cleared
+					s.client.addressLock.Lock()
+					registering := s.client.registeringPools[pairAddr]
+					s.client.addressLock.Unlock()
+					So(registering, ShouldBeFalse)
+
+					// Verify the pool was not left in the cache without updates
+					_, exists := s.client.poolStateCacheMap[pairAddr]
+					So(exists, ShouldBeFalse)
+				})
+			})
+		})
+	})
+}
+
+// Mock implementation of ethereum.Subscription
+type mockSubscription struct{}
+
+func (m *mockSubscription) Unsubscribe()      {}
+func (m *mockSubscription) Err() <-chan error { return make(<-chan error) }
@@ -0,0 +1,40 @@
+package ethwss
+
+import (
+	"context"
+
+	"github.com/WangWilly/swap-estimation/pkgs/poolmodel"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SnapshotPools returns the cached states of the given pools as read at one
+// instant, so that no log is applied in between. Pools not in the cache are
+// left out of the result.
+func (c *client) SnapshotPools(ctx context.Context, addresses []common.Address) map[common.Address]poolmodel.State {
+	logger := log.Ctx(ctx)
+
+	states := make(map[common.Address]poolmodel.State, len(addresses))
+	c.cacheLock.RLock()
+	for _, address := range addresses {
+		if state, ok := c.poolStateCacheMap[address]; ok {
+			states[address] = state
+		}
+	}
+	c.cacheLock.RUnlock()
+
+	// Extend the subscription period of the pools found
+	for address := range states {
+		if timer, exists := c.poolTimers[address]; exists {
+			timer.Reset(c.cfg.ListenPairPeriod)
+		}
+	}
+
+	logger.Debug().
+		Int("pool_count", len(addresses)).
+		Int("cached_count", len(states)).
+		Msg("Snapshotting pool states")
+	return states
+}
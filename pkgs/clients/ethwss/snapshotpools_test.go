@@ -0,0 +1,56 @@
+package ethwss
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/WangWilly/swap-estimation/pkgs/clients/eth"
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSnapshotPools(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given the SnapshotPools function", t, func() {
+			ctx := context.Background()
+			wethUsdcPairAddr := common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc")
+			usdcUsdtPairAddr := common.HexToAddress("0x3041CbD36888bECc7bbCBc0045E3B1f144466f5f")
+			wethUsdtPairAddr := common.HexToAddress("0x0d4a11d5EEaaC28EC3F61d100daF4d40471f1852")
+
+			wethUsdcPair := &eth.ReservePair{
+				Reserve0: big.NewInt(5000),
+				Reserve1: big.NewInt(10000),
+			}
+			usdcUsdtPair := &eth.ReservePair{
+				Reserve0: big.NewInt(7000),
+				Reserve1: big.NewInt(7000),
+			}
+			s.client.poolStateCacheMap[wethUsdcPairAddr] = wethUsdcPair
+			s.client.poolStateCacheMap[usdcUsdtPairAddr] = usdcUsdtPair
+			s.client.poolTimers[wethUsdcPairAddr] = time.NewTimer(s.client.cfg.ListenPairPeriod)
+			delete(s.client.poolStateCacheMap, wethUsdtPairAddr)
+
+			Convey("When snapshotting cached and uncached pools", func() {
+				result := s.client.SnapshotPools(ctx, []common.Address{wethUsdcPairAddr, usdcUsdtPairAddr, wethUsdtPairAddr})
+
+				Convey("Then it should return the cached states only", func() {
+					So(result, ShouldHaveLength, 2)
+					So(result[wethUsdcPairAddr], ShouldEqual, wethUsdcPair)
+					So(result[usdcUsdtPairAddr], ShouldEqual, usdcUsdtPair)
+					_, exists := result[wethUsdtPairAddr]
+					So(exists, ShouldBeFalse)
+				})
+			})
+
+			Convey("When snapshotting no pools", func() {
+				result := s.client.SnapshotPools(ctx, nil)
+
+				Convey("Then it should return an empty snapshot", func() {
+					So(result, ShouldBeEmpty)
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,48 @@
+package rpcpool
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (c *client) BlockNumber(ctx context.Context) (uint64, error) {
+	return do(ctx, c, "eth_blockNumber", func(ctx context.Context, client GethClient) (uint64, error) {
+		return client.BlockNumber(ctx)
+	})
+}
+
+func (c *client) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return do(ctx, c, "eth_call", func(ctx context.Context, client GethClient) ([]byte, error) {
+		return client.CallContract(ctx, call, blockNumber)
+	})
+}
+
+func (c *client) CallContractAtHash(ctx context.Context, call ethereum.CallMsg, blockHash common.Hash) ([]byte, error) {
+	return do(ctx, c, "eth_call", func(ctx context.Context, client GethClient) ([]byte, error) {
+		return client.CallContractAtHash(ctx, call, blockHash)
+	})
+}
+
+func (c *client) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	return do(ctx, c, "eth_getCode", func(ctx context.Context, client GethClient) ([]byte, error) {
+		return client.CodeAt(ctx, account, blockNumber)
+	})
+}
+
+func (c *client) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	return do(ctx, c, "eth_getLogs", func(ctx context.Context, client GethClient) ([]types.Log, error) {
+		return client.FilterLogs(ctx, q)
+	})
+}
+
+func (c *client) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return do(ctx, c, "eth_getBlockByNumber", func(ctx context.Context, client GethClient) (*types.Header, error) {
+		return client.HeaderByNumber(ctx, number)
+	})
+}
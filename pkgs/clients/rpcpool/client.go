@@ -0,0 +1,44 @@
+package rpcpool
+
+import (
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+type Config struct {
+	// HedgeDelay is how long a read waits on a provider before the next one is
+	// asked as well; zero disables hedging, leaving failover only
+	HedgeDelay time.Duration `env:"HEDGE_DELAY,default=500ms"`
+	// A provider failing MaxConsecutiveFailures calls in a row is ranked last
+	// for CooldownPeriod
+	MaxConsecutiveFailures int           `env:"MAX_CONSECUTIVE_FAILURES,default=3"`
+	CooldownPeriod         time.Duration `env:"COOLDOWN_PERIOD,default=30s"`
+}
+
+// Provider is one endpoint of the pool. Name identifies it in logs and
+// reports, and must not leak credentials held in the URL.
+type Provider struct {
+	Name   string
+	Client GethClient
+}
+
+// client spreads calls over several providers, healthiest first. It
+// implements the GethClient interfaces of the other clients, and the
+// GethWssClient of ethwss when its providers are WebSocket endpoints.
+type client struct {
+	cfg Config
+
+	providers []*provider
+}
+
+func New(cfg Config, providers []Provider) *client {
+	pool := &client{
+		cfg:       cfg,
+		providers: make([]*provider, len(providers)),
+	}
+	for i, p := range providers {
+		pool.providers[i] = &provider{name: p.Name, client: p.Client}
+	}
+	return pool
+}
@@ -0,0 +1,51 @@
+package rpcpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sethvargo/go-envconfig"
+	"go.uber.org/mock/gomock"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+type testSuite struct {
+	primary   *MockGethClient
+	secondary *MockGethClient
+
+	client *client
+}
+
+func testInit(t *testing.T, test func(*testSuite)) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := NewMockGethClient(ctrl)
+	secondary := NewMockGethClient(ctrl)
+
+	cfg := Config{
+		HedgeDelay: 20 * time.Millisecond,
+	}
+	if err := envconfig.Process(t.Context(), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	client := New(cfg, []Provider{
+		{Name: "primary", Client: primary},
+		{Name: "secondary", Client: secondary},
+	})
+
+	ts := &testSuite{
+		primary:   primary,
+		secondary: secondary,
+		client:    client,
+	}
+	test(ts)
+}
+
+// resetHealth forgets the calls of the previous runs of a suite.
+func (s *testSuite) resetHealth() {
+	for _, p := range s.client.providers {
+		p.latency, p.errorRate, p.consecutiveFailures, p.cooldownUntil = 0, 0, 0, time.Time{}
+	}
+}
@@ -0,0 +1,47 @@
+package rpcpool
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Dial connects to every endpoint of rawURLs, in order. Providers are named
+// after the host of their URL, so that API keys in the path or the query
+// string stay out of logs.
+func Dial(ctx context.Context, rawURLs []string) ([]Provider, error) {
+	providers := make([]Provider, 0, len(rawURLs))
+	seenNames := make(map[string]int, len(rawURLs))
+	for _, rawURL := range rawURLs {
+		name := providerName(rawURL, seenNames)
+
+		ethClient, err := ethclient.DialContext(ctx, rawURL)
+		if err != nil {
+			for _, p := range providers {
+				p.Client.Close()
+			}
+			return nil, fmt.Errorf("failed to dial %s: %v", name, err)
+		}
+		providers = append(providers, Provider{Name: name, Client: ethClient})
+	}
+	return providers, nil
+}
+
+// providerName names an endpoint after the host of its URL, numbering the
+// endpoints that share a host.
+func providerName(rawURL string, seenNames map[string]int) string {
+	name := "provider"
+	if parsed, err := url.Parse(rawURL); err == nil && parsed.Host != "" {
+		name = parsed.Host
+	}
+
+	seenNames[name]++
+	if count := seenNames[name]; count > 1 {
+		return fmt.Sprintf("%s#%d", name, count)
+	}
+	return name
+}
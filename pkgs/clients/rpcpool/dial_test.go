@@ -0,0 +1,30 @@
+package rpcpool
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProviderName(t *testing.T) {
+	Convey("Given the providerName function", t, func() {
+		seenNames := make(map[string]int)
+
+		Convey("When naming endpoints", func() {
+			infura := providerName("https://mainnet.infura.io/v3/secret-key", seenNames)
+			infuraWss := providerName("wss://mainnet.infura.io/ws/v3/secret-key", seenNames)
+			alchemy := providerName("https://eth-mainnet.g.alchemy.com/v2/secret-key", seenNames)
+			unparsable := providerName("::", seenNames)
+
+			Convey("Then they should be named after their host only", func() {
+				So(infura, ShouldEqual, "mainnet.infura.io")
+				So(alchemy, ShouldEqual, "eth-mainnet.g.alchemy.com")
+				So(unparsable, ShouldEqual, "provider")
+			})
+
+			Convey("Then endpoints sharing a host should be numbered", func() {
+				So(infuraWss, ShouldEqual, "mainnet.infura.io#2")
+			})
+		})
+	})
+}
@@ -0,0 +1,140 @@
+package rpcpool
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// ErrNoProvider is returned by a pool without providers.
+var ErrNoProvider = errors.New("no RPC provider configured")
+
+// Report records the provider that served the calls made with a context, for
+// callers that keep per-provider state. Set by the pool once a call succeeds.
+type Report struct {
+	Provider string
+}
+
+type reportKey struct{}
+
+// ProviderError is the error of the last provider tried by a call that no
+// provider served, for callers that keep per-provider state.
+type ProviderError struct {
+	Provider string
+	Err      error
+}
+
+func (e *ProviderError) Error() string {
+	return e.Provider + ": " + e.Err.Error()
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// WithReport returns a context whose calls through the pool record their
+// provider in the returned report. The context is meant for sequential calls.
+func WithReport(ctx context.Context) (context.Context, *Report) {
+	report := &Report{}
+	return context.WithValue(ctx, reportKey{}, report), report
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// do runs a read on the healthiest provider, fails over to the next one when
+// it fails, and hedges it on the next one when it has not answered within
+// HedgeDelay. The first answer wins and the other calls are cancelled.
+func do[T any](ctx context.Context, c *client, method string, call func(ctx context.Context, client GethClient) (T, error)) (T, error) {
+	logger := log.Ctx(ctx)
+
+	var zero T
+	providers := c.ranked(time.Now())
+	if len(providers) == 0 {
+		return zero, ErrNoProvider
+	}
+
+	callCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type outcome struct {
+		provider *provider
+		res      T
+		err      error
+		latency  time.Duration
+	}
+	outcomes := make(chan outcome, len(providers))
+	next := 0
+	start := func() {
+		p := providers[next]
+		next++
+		go func() {
+			begin := time.Now()
+			res, err := call(callCtx, p.client)
+			outcomes <- outcome{p, res, err, time.Since(begin)}
+		}()
+	}
+
+	var hedge <-chan time.Time
+	resetHedge := func() {
+		if c.cfg.HedgeDelay > 0 && next < len(providers) {
+			hedge = time.After(c.cfg.HedgeDelay)
+		} else {
+			hedge = nil
+		}
+	}
+
+	start()
+	resetHedge()
+	pending := 1
+	var lastErr error
+	for pending > 0 {
+		select {
+		case <-hedge:
+			logger.Debug().
+				Str("method", method).
+				Str("provider", providers[next].name).
+				Msg("Hedging RPC call")
+			start()
+			pending++
+			resetHedge()
+		case o := <-outcomes:
+			pending--
+			if o.err == nil || isAnswerError(o.err) {
+				o.provider.recordSuccess(o.latency)
+				if report, ok := ctx.Value(reportKey{}).(*Report); ok {
+					report.Provider = o.provider.name
+				}
+				logger.Debug().
+					Str("method", method).
+					Str("provider", o.provider.name).
+					Dur("latency", o.latency).
+					Msg("RPC call served")
+				return o.res, o.err
+			}
+			if ctx.Err() != nil {
+				// Cancelled by the caller, not the provider's fault
+				return zero, o.err
+			}
+
+			o.provider.recordFailure(c.cfg.MaxConsecutiveFailures, c.cfg.CooldownPeriod, time.Now())
+			logger.Warn().
+				Err(o.err).
+				Str("method", method).
+				Str("provider", o.provider.name).
+				Msg("RPC call failed")
+			lastErr = &ProviderError{Provider: o.provider.name, Err: o.err}
+
+			// Fail over right away rather than waiting for the hedge
+			if next < len(providers) {
+				start()
+				pending++
+				resetHedge()
+			}
+		}
+	}
+	return zero, lastErr
+}
@@ -0,0 +1,168 @@
+package rpcpool
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+// revertError is a JSON-RPC error as returned for a reverted eth_call.
+type revertError struct{}
+
+func (revertError) Error() string  { return "execution reverted" }
+func (revertError) ErrorCode() int { return 3 }
+
+func TestDo(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given a pool of two providers", t, func() {
+			s.resetHealth()
+			ctx, report := WithReport(context.Background())
+			msg := ethereum.CallMsg{Data: []byte{0x09, 0x02, 0xf1, 0xac}}
+
+			Convey("When the healthiest provider answers", func() {
+				s.primary.EXPECT().
+					CallContract(gomock.Any(), msg, nil).
+					Return([]byte{0x01}, nil)
+
+				// No call to the secondary provider expected
+
+				res, err := s.client.CallContract(ctx, msg, nil)
+
+				Convey("Then its answer should be returned and reported", func() {
+					So(err, ShouldBeNil)
+					So(res, ShouldResemble, []byte{0x01})
+					So(report.Provider, ShouldEqual, "primary")
+				})
+			})
+
+			Convey("When the healthiest provider fails", func() {
+				s.primary.EXPECT().
+					CallContract(gomock.Any(), msg, nil).
+					Return(nil, errors.New("503 Service Unavailable"))
+				s.secondary.EXPECT().
+					CallContract(gomock.Any(), msg, nil).
+					Return([]byte{0x02}, nil)
+
+				res, err := s.client.CallContract(ctx, msg, nil)
+
+				Convey("Then the call should fail over to the next provider", func() {
+					So(err, ShouldBeNil)
+					So(res, ShouldResemble, []byte{0x02})
+					So(report.Provider, ShouldEqual, "secondary")
+				})
+
+				Convey("Then the failing provider should rank last", func() {
+					ranked := s.client.ranked(time.Now())
+					So(ranked[0].name, ShouldEqual, "secondary")
+				})
+			})
+
+			Convey("When the healthiest provider is slow", func() {
+				s.primary.EXPECT().
+					CallContract(gomock.Any(), msg, nil).
+					DoAndReturn(func(ctx context.Context, _ ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+						select {
+						case <-ctx.Done():
+							return nil, ctx.Err()
+						case <-time.After(time.Second):
+							return []byte{0x01}, nil
+						}
+					})
+				s.secondary.EXPECT().
+					CallContract(gomock.Any(), msg, nil).
+					Return([]byte{0x02}, nil)
+
+				begin := time.Now()
+				res, err := s.client.CallContract(ctx, msg, nil)
+
+				Convey("Then the call should be hedged on the next provider", func() {
+					So(err, ShouldBeNil)
+					So(res, ShouldResemble, []byte{0x02})
+					So(report.Provider, ShouldEqual, "secondary")
+					So(time.Since(begin), ShouldBeLessThan, time.Second)
+				})
+			})
+
+			Convey("When the call reverts", func() {
+				s.primary.EXPECT().
+					CallContract(gomock.Any(), msg, nil).
+					Return(nil, revertError{})
+
+				// No call to the secondary provider expected
+
+				_, err := s.client.CallContract(ctx, msg, nil)
+
+				Convey("Then the revert should be returned without failing over", func() {
+					So(err, ShouldEqual, revertError{})
+					So(report.Provider, ShouldEqual, "primary")
+				})
+			})
+
+			Convey("When the provider rejects a log query over too large a range", func() {
+				query := ethereum.FilterQuery{FromBlock: big.NewInt(0), ToBlock: big.NewInt(100000)}
+				rangeErr := errors.New("query exceeds max block range 10000")
+				s.primary.EXPECT().
+					FilterLogs(gomock.Any(), query).
+					Return(nil, rangeErr)
+
+				// No call to the secondary provider expected
+
+				_, err := s.client.FilterLogs(ctx, query)
+
+				Convey("Then the error should be returned without failing over", func() {
+					So(err, ShouldEqual, rangeErr)
+					So(report.Provider, ShouldEqual, "primary")
+				})
+
+				Convey("Then the provider should not be penalised", func() {
+					So(s.client.providers[0].errorRate, ShouldEqual, 0)
+					So(s.client.NextProvider(), ShouldEqual, "primary")
+				})
+			})
+
+			Convey("When the provider has not seen the block of a read yet", func() {
+				s.primary.EXPECT().
+					HeaderByNumber(gomock.Any(), big.NewInt(100)).
+					Return(nil, errors.New("header not found"))
+
+				// No call to the secondary provider expected
+
+				_, err := s.client.HeaderByNumber(ctx, big.NewInt(100))
+
+				Convey("Then the error should be returned for the caller to retry", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldEqual, "header not found")
+					So(report.Provider, ShouldEqual, "primary")
+					So(s.client.providers[0].errorRate, ShouldEqual, 0)
+				})
+			})
+
+			Convey("When every provider fails", func() {
+				s.primary.EXPECT().
+					BlockNumber(gomock.Any()).
+					Return(uint64(0), errors.New("connection refused"))
+				s.secondary.EXPECT().
+					BlockNumber(gomock.Any()).
+					Return(uint64(0), errors.New("429 Too Many Requests"))
+
+				_, err := s.client.BlockNumber(ctx)
+
+				Convey("Then the error of the last provider should be returned", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldEqual, "secondary: 429 Too Many Requests")
+
+					var providerErr *ProviderError
+					So(errors.As(err, &providerErr), ShouldBeTrue)
+					So(providerErr.Provider, ShouldEqual, "secondary")
+					So(report.Provider, ShouldBeEmpty)
+				})
+			})
+		})
+	})
+}
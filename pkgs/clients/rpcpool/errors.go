@@ -0,0 +1,110 @@
+package rpcpool
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Fragments of the errors providers return for a log query over a block range
+// larger than they serve, whatever its logs.
+var rangeTooLargeErrors = []string{
+	"range too large",
+	"range is too large",
+	"range too wide",
+	"range is too wide",
+	"maximum block range",
+	"max block range",
+	"block range limit",
+}
+
+// Fragments of the errors providers return for a log query matching more logs
+// than they return at once.
+var tooManyResultsErrors = []string{
+	"too many results",
+	"more than 10000 results",
+	"query returned more than",
+	"response size exceeded",
+	"response size should not",
+	"log response size",
+}
+
+// Fragments of the errors nodes return for a block they have not seen yet.
+var blockNotFoundErrors = []string{
+	"header not found",
+	"header for hash not found",
+	"unknown block",
+	"block not found",
+}
+
+// Fragments of the errors nodes return for a read at a block whose state they
+// no longer hold, e.g. a pruned node asked for an old block.
+var missingStateErrors = []string{
+	"missing trie node",
+	"state is not available",
+	"state not available",
+	"historical state",
+	"state histories haven't been fully indexed",
+}
+
+func containsAny(err error, fragments []string) bool {
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range fragments {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// IsRevertError tells whether a call failed because the contract reverted it,
+// which any provider would answer too.
+func IsRevertError(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == 3 {
+		return true
+	}
+	return strings.Contains(err.Error(), "execution reverted")
+}
+
+// IsRangeTooLargeError tells whether a log query failed for its block range.
+func IsRangeTooLargeError(err error) bool {
+	return containsAny(err, rangeTooLargeErrors)
+}
+
+// IsTooManyResultsError tells whether a log query failed for the number of
+// logs it matched.
+func IsTooManyResultsError(err error) bool {
+	return containsAny(err, tooManyResultsErrors)
+}
+
+// IsBlockNotFoundError tells whether a read pinned to a block failed because
+// the node serving it has not seen that block yet.
+func IsBlockNotFoundError(err error) bool {
+	return errors.Is(err, ethereum.NotFound) || containsAny(err, blockNotFoundErrors)
+}
+
+// IsMissingStateError tells whether a read at a block failed because the node
+// serving it no longer holds the state of that block. Another provider, such
+// as an archive node, may still serve it.
+func IsMissingStateError(err error) bool {
+	return containsAny(err, missingStateErrors)
+}
+
+// isAnswerError tells whether an error is the answer of the provider to the
+// call rather than a failure of the provider, e.g. a reverted eth_call. Such
+// errors are returned to the caller, which knows how to handle them, without
+// failing over: a log query over too large a range is narrowed, and a read at
+// a block the provider has not seen yet is retried.
+func isAnswerError(err error) bool {
+	return IsRevertError(err) ||
+		IsRangeTooLargeError(err) ||
+		IsTooManyResultsError(err) ||
+		IsBlockNotFoundError(err)
+}
@@ -0,0 +1,113 @@
+package rpcpool
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+const (
+	// Weight of the latest call in the moving averages of a provider
+	healthDecay = 0.2
+	// errorRatePenalty scales the latency of a provider by its error rate, so
+	// that a provider failing half of its calls ranks as if 6 times slower
+	errorRatePenalty = 10
+	// minScoreLatency is the latency scored for providers answering faster,
+	// or not at all yet, so that their error rate still counts
+	minScoreLatency = time.Millisecond
+)
+
+// provider is an endpoint of the pool and its health, tracked as moving
+// averages of the latency of its answers and of its error rate.
+type provider struct {
+	name   string
+	client GethClient
+
+	healthLock          sync.Mutex
+	latency             time.Duration
+	errorRate           float64
+	consecutiveFailures int
+	cooldownUntil       time.Time
+}
+
+// recordSuccess records an answer of the provider, errors included that any
+// provider would have returned.
+func (p *provider) recordSuccess(latency time.Duration) {
+	p.healthLock.Lock()
+	defer p.healthLock.Unlock()
+
+	if p.latency == 0 {
+		p.latency = latency
+	} else {
+		p.latency = time.Duration(healthDecay*float64(latency) + (1-healthDecay)*float64(p.latency))
+	}
+	p.errorRate *= 1 - healthDecay
+	p.consecutiveFailures = 0
+}
+
+// recordFailure records a failed call, and puts the provider in cooldown once
+// it failed maxFailures calls in a row.
+func (p *provider) recordFailure(maxFailures int, cooldown time.Duration, now time.Time) {
+	p.healthLock.Lock()
+	defer p.healthLock.Unlock()
+
+	p.errorRate = healthDecay + (1-healthDecay)*p.errorRate
+	p.consecutiveFailures++
+	if p.consecutiveFailures >= maxFailures {
+		p.cooldownUntil = now.Add(cooldown)
+		p.consecutiveFailures = 0
+	}
+}
+
+// health returns the score of the provider, lower being better, and whether
+// it is in cooldown.
+func (p *provider) health(now time.Time) (float64, bool) {
+	p.healthLock.Lock()
+	defer p.healthLock.Unlock()
+
+	score := float64(max(p.latency, minScoreLatency)) * (1 + errorRatePenalty*p.errorRate)
+	return score, now.Before(p.cooldownUntil)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// ranked returns the providers healthiest first: those in cooldown last, then
+// by score. Providers with equal scores, such as those not called yet, keep
+// the configured order.
+func (c *client) ranked(now time.Time) []*provider {
+	type rankedProvider struct {
+		provider *provider
+		score    float64
+		cooling  bool
+	}
+	candidates := make([]rankedProvider, len(c.providers))
+	for i, p := range c.providers {
+		score, cooling := p.health(now)
+		candidates[i] = rankedProvider{p, score, cooling}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].cooling != candidates[j].cooling {
+			return !candidates[i].cooling
+		}
+		return candidates[i].score < candidates[j].score
+	})
+
+	providers := make([]*provider, len(candidates))
+	for i, candidate := range candidates {
+		providers[i] = candidate.provider
+	}
+	return providers
+}
+
+// NextProvider returns the name of the provider the next call goes to first,
+// for callers that keep per-provider state such as request limits. It is empty
+// for a pool without providers.
+func (c *client) NextProvider() string {
+	providers := c.ranked(time.Now())
+	if len(providers) == 0 {
+		return ""
+	}
+	return providers[0].name
+}
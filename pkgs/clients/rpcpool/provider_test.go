@@ -0,0 +1,69 @@
+package rpcpool
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRanked(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given a pool of two providers", t, func() {
+			s.resetHealth()
+			now := time.Now()
+			primary, secondary := s.client.providers[0], s.client.providers[1]
+
+			Convey("When neither was called yet", func() {
+				ranked := s.client.ranked(now)
+
+				Convey("Then they should keep the configured order", func() {
+					So(ranked[0], ShouldEqual, primary)
+					So(ranked[1], ShouldEqual, secondary)
+					So(s.client.NextProvider(), ShouldEqual, "primary")
+				})
+			})
+
+			Convey("When the first one answers slower", func() {
+				primary.recordSuccess(300 * time.Millisecond)
+				secondary.recordSuccess(100 * time.Millisecond)
+				ranked := s.client.ranked(now)
+
+				Convey("Then the faster one should rank first", func() {
+					So(ranked[0], ShouldEqual, secondary)
+				})
+			})
+
+			Convey("When the faster one fails often", func() {
+				primary.recordSuccess(300 * time.Millisecond)
+				secondary.recordSuccess(100 * time.Millisecond)
+				secondary.recordFailure(100, time.Minute, now)
+				secondary.recordFailure(100, time.Minute, now)
+				ranked := s.client.ranked(now)
+
+				Convey("Then its error rate should outweigh its latency", func() {
+					So(ranked[0], ShouldEqual, primary)
+				})
+			})
+
+			Convey("When one fails too many calls in a row", func() {
+				for range s.client.cfg.MaxConsecutiveFailures {
+					primary.recordFailure(s.client.cfg.MaxConsecutiveFailures, time.Minute, now)
+				}
+
+				Convey("Then it should rank last during its cooldown", func() {
+					So(s.client.ranked(now)[1], ShouldEqual, primary)
+				})
+
+				Convey("Then the next call should go to the other one", func() {
+					So(s.client.NextProvider(), ShouldEqual, "secondary")
+				})
+
+				Convey("Then it should be ranked again by score after its cooldown", func() {
+					_, cooling := primary.health(now.Add(2 * time.Minute))
+					So(cooling, ShouldBeFalse)
+				})
+			})
+		})
+	})
+}
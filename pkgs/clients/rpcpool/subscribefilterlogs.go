@@ -0,0 +1,59 @@
+package rpcpool
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rs/zerolog/log"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SubscribeFilterLogs subscribes through the healthiest provider that accepts
+// the subscription. It is not hedged, since two subscriptions would deliver
+// every log twice; a subscription that drops later is left to the caller.
+func (c *client) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	logger := log.Ctx(ctx)
+
+	providers := c.ranked(time.Now())
+	if len(providers) == 0 {
+		return nil, ErrNoProvider
+	}
+
+	var lastErr error
+	for _, p := range providers {
+		begin := time.Now()
+		sub, err := p.client.SubscribeFilterLogs(ctx, q, ch)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			p.recordFailure(c.cfg.MaxConsecutiveFailures, c.cfg.CooldownPeriod, time.Now())
+			logger.Warn().
+				Err(err).
+				Str("provider", p.name).
+				Msg("Failed to subscribe to logs")
+			lastErr = &ProviderError{Provider: p.name, Err: err}
+			continue
+		}
+
+		p.recordSuccess(time.Since(begin))
+		if report, ok := ctx.Value(reportKey{}).(*Report); ok {
+			report.Provider = p.name
+		}
+		logger.Debug().
+			Str("provider", p.name).
+			Msg("Subscribed to logs")
+		return sub, nil
+	}
+	return nil, lastErr
+}
+
+// Close closes every provider of the pool.
+func (c *client) Close() {
+	for _, p := range c.providers {
+		p.client.Close()
+	}
+}
@@ -0,0 +1,70 @@
+package rpcpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSubscribeFilterLogs(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given a pool of two providers", t, func() {
+			s.resetHealth()
+			ctx, report := WithReport(context.Background())
+			logs := make(chan types.Log)
+			sub := event.NewSubscription(func(quit <-chan struct{}) error {
+				<-quit
+				return nil
+			})
+			defer sub.Unsubscribe()
+
+			Convey("When the healthiest provider refuses the subscription", func() {
+				s.primary.EXPECT().
+					SubscribeFilterLogs(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("notifications not supported"))
+				s.secondary.EXPECT().
+					SubscribeFilterLogs(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(sub, nil)
+
+				result, err := s.client.SubscribeFilterLogs(ctx, ethereum.FilterQuery{}, logs)
+
+				Convey("Then it should subscribe through the next provider", func() {
+					So(err, ShouldBeNil)
+					So(result, ShouldEqual, sub)
+					So(report.Provider, ShouldEqual, "secondary")
+				})
+			})
+
+			Convey("When every provider refuses the subscription", func() {
+				s.primary.EXPECT().
+					SubscribeFilterLogs(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("notifications not supported"))
+				s.secondary.EXPECT().
+					SubscribeFilterLogs(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("connection refused"))
+
+				result, err := s.client.SubscribeFilterLogs(ctx, ethereum.FilterQuery{}, logs)
+
+				Convey("Then it should return the error of the last provider", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldEqual, "secondary: connection refused")
+					So(result, ShouldBeNil)
+				})
+			})
+
+			Convey("When the pool is closed", func() {
+				s.primary.EXPECT().Close()
+				s.secondary.EXPECT().Close()
+
+				// Every provider is expected to be closed
+				s.client.Close()
+			})
+		})
+	})
+}
@@ -0,0 +1,23 @@
+package univ3
+
+////////////////////////////////////////////////////////////////////////////////
+
+type Config struct {
+	// Number of tick bitmap words loaded on each side of the current tick
+	TickBitmapWordRange int `env:"TICK_BITMAP_WORD_RANGE,default=2"`
+	// Number of initialized ticks read at once
+	TickFetchConcurrency int `env:"TICK_FETCH_CONCURRENCY,default=8"`
+}
+
+type client struct {
+	cfg Config
+
+	gethClient GethClient
+}
+
+func New(cfg Config, gethClient GethClient) *client {
+	return &client{
+		cfg:        cfg,
+		gethClient: gethClient,
+	}
+}
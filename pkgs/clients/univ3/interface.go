@@ -0,0 +1,14 @@
+package univ3
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+//go:generate mockgen -source=interface.go -destination=interface_mock.go -package=univ3
+type GethClient interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
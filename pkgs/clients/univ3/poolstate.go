@@ -0,0 +1,247 @@
+package univ3
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+type Tick struct {
+	Index        int
+	LiquidityNet *big.Int
+}
+
+// PoolState is a snapshot of a Uniswap V3 pool as of block BlockNumber. Ticks
+// holds every initialized tick found in the tick bitmap words
+// [MinWordPos, MaxWordPos].
+type PoolState struct {
+	Token0       common.Address
+	Token1       common.Address
+	FeePips      uint64
+	TickSpacing  int
+	SqrtPriceX96 *big.Int
+	Tick         int
+	Liquidity    *big.Int
+	Ticks        []Tick
+	MinWordPos   int
+	MaxWordPos   int
+	BlockNumber  uint64
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+const uniswapV3PoolABI = `[
+	{"inputs":[],"name":"token0","outputs":[{"internalType":"address","name":"","type":"address"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"token1","outputs":[{"internalType":"address","name":"","type":"address"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"fee","outputs":[{"internalType":"uint24","name":"","type":"uint24"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"tickSpacing","outputs":[{"internalType":"int24","name":"","type":"int24"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"liquidity","outputs":[{"internalType":"uint128","name":"","type":"uint128"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"slot0","outputs":[{"internalType":"uint160","name":"sqrtPriceX96","type":"uint160"},{"internalType":"int24","name":"tick","type":"int24"},{"internalType":"uint16","name":"observationIndex","type":"uint16"},{"internalType":"uint16","name":"observationCardinality","type":"uint16"},{"internalType":"uint16","name":"observationCardinalityNext","type":"uint16"},{"internalType":"uint8","name":"feeProtocol","type":"uint8"},{"internalType":"bool","name":"unlocked","type":"bool"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"internalType":"int16","name":"","type":"int16"}],"name":"tickBitmap","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"internalType":"int24","name":"","type":"int24"}],"name":"ticks","outputs":[{"internalType":"uint128","name":"liquidityGross","type":"uint128"},{"internalType":"int128","name":"liquidityNet","type":"int128"},{"internalType":"uint256","name":"feeGrowthOutside0X128","type":"uint256"},{"internalType":"uint256","name":"feeGrowthOutside1X128","type":"uint256"},{"internalType":"int56","name":"tickCumulativeOutside","type":"int56"},{"internalType":"uint160","name":"secondsPerLiquidityOutsideX128","type":"uint160"},{"internalType":"uint32","name":"secondsOutside","type":"uint32"},{"internalType":"bool","name":"initialized","type":"bool"}],"stateMutability":"view","type":"function"}
+]`
+
+////////////////////////////////////////////////////////////////////////////////
+
+// PoolState reads slot0, the active liquidity and the initialized ticks around
+// the current price of a Uniswap V3 pool. Every read is pinned to the latest
+// block as of the first one, so that a swap landing in between cannot mix two
+// states of the pool. The initialized ticks are read concurrently, see
+// readTicks.
+func (c *client) PoolState(
+	ctx context.Context,
+	poolAddress common.Address,
+) (*PoolState, error) {
+	logger := log.Ctx(ctx)
+	logger.Debug().
+		Str("pool_address", poolAddress.Hex()).
+		Msg("Reading Uniswap V3 pool state")
+
+	parsedABI, err := abi.JSON(strings.NewReader(uniswapV3PoolABI))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to parse Uniswap V3 Pool ABI")
+		return nil, fmt.Errorf("failed to parse ABI: %v", err)
+	}
+
+	latestBlock, err := c.gethClient.BlockNumber(ctx)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get latest block number")
+		return nil, fmt.Errorf("failed to get latest block: %v", err)
+	}
+	block := new(big.Int).SetUint64(latestBlock)
+
+	callCtx := func(ctx context.Context, method string, args ...any) ([]any, error) {
+		data, err := parsedABI.Pack(method, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack %s: %v", method, err)
+		}
+		res, err := c.gethClient.CallContract(ctx, ethereum.CallMsg{To: &poolAddress, Data: data}, block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to call %s: %v", method, err)
+		}
+		out, err := parsedABI.Unpack(method, res)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack %s: %v", method, err)
+		}
+		return out, nil
+	}
+	call := func(method string, args ...any) ([]any, error) {
+		return callCtx(ctx, method, args...)
+	}
+
+	state := &PoolState{BlockNumber: latestBlock}
+
+	token0, err := call("token0")
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to read token0")
+		return nil, err
+	}
+	state.Token0 = token0[0].(common.Address)
+
+	token1, err := call("token1")
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to read token1")
+		return nil, err
+	}
+	state.Token1 = token1[0].(common.Address)
+
+	fee, err := call("fee")
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to read fee")
+		return nil, err
+	}
+	state.FeePips = fee[0].(*big.Int).Uint64()
+
+	tickSpacing, err := call("tickSpacing")
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to read tick spacing")
+		return nil, err
+	}
+	state.TickSpacing = int(tickSpacing[0].(*big.Int).Int64())
+	if state.TickSpacing <= 0 {
+		logger.Error().Int("tick_spacing", state.TickSpacing).Msg("Invalid tick spacing")
+		return nil, fmt.Errorf("invalid tick spacing: %d", state.TickSpacing)
+	}
+
+	slot0, err := call("slot0")
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to read slot0")
+		return nil, err
+	}
+	state.SqrtPriceX96 = slot0[0].(*big.Int)
+	state.Tick = int(slot0[1].(*big.Int).Int64())
+
+	liquidity, err := call("liquidity")
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to read liquidity")
+		return nil, err
+	}
+	state.Liquidity = liquidity[0].(*big.Int)
+
+	// Load the bitmap words around the word of the current tick
+	compressed := state.Tick / state.TickSpacing
+	if state.Tick < 0 && state.Tick%state.TickSpacing != 0 {
+		compressed--
+	}
+	wordPos := compressed >> 8
+	state.MinWordPos = max(wordPos-c.cfg.TickBitmapWordRange, math.MinInt16)
+	state.MaxWordPos = min(wordPos+c.cfg.TickBitmapWordRange, math.MaxInt16)
+
+	var tickIndexes []int
+	for pos := state.MinWordPos; pos <= state.MaxWordPos; pos++ {
+		word, err := call("tickBitmap", int16(pos))
+		if err != nil {
+			logger.Error().Err(err).Int("word_pos", pos).Msg("Failed to read tick bitmap")
+			return nil, err
+		}
+		bitmap := word[0].(*big.Int)
+
+		for bit := range 256 {
+			if bitmap.Bit(bit) == 0 {
+				continue
+			}
+			tickIndexes = append(tickIndexes, (pos*256+bit)*state.TickSpacing)
+		}
+	}
+
+	ticks, err := c.readTicks(ctx, callCtx, tickIndexes)
+	if err != nil {
+		return nil, err
+	}
+	state.Ticks = ticks
+
+	logger.Debug().
+		Str("pool_address", poolAddress.Hex()).
+		Uint64("block_number", state.BlockNumber).
+		Int("tick", state.Tick).
+		Int("initialized_ticks", len(state.Ticks)).
+		Msg("Uniswap V3 pool state details")
+
+	return state, nil
+}
+
+// readTicks reads the liquidity net of initialized ticks, at most
+// TickFetchConcurrency at once, in the order of tickIndexes. The first tick
+// failing cancels the reads of the others, and its error is returned.
+func (c *client) readTicks(
+	ctx context.Context,
+	call func(ctx context.Context, method string, args ...any) ([]any, error),
+	tickIndexes []int,
+) ([]Tick, error) {
+	logger := log.Ctx(ctx)
+
+	tickCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Each goroutine writes its own slot
+	ticks := make([]Tick, len(tickIndexes))
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, max(c.cfg.TickFetchConcurrency, 1))
+	for i, tickIndex := range tickIndexes {
+		if tickCtx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			tickInfo, err := call(tickCtx, "ticks", big.NewInt(int64(tickIndex)))
+			if err != nil {
+				logger.Error().Err(err).Int("tick", tickIndex).Msg("Failed to read tick")
+				failOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			ticks[i] = Tick{
+				Index:        tickIndex,
+				LiquidityNet: tickInfo[1].(*big.Int),
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return ticks, nil
+}
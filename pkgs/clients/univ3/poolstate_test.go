@@ -0,0 +1,220 @@
+package univ3
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPoolState(t *testing.T) {
+	testInit(t, func(s *testSuite) {
+		Convey("Given the PoolState function", t, func() {
+			ctx := context.Background()
+			poolAddr := common.HexToAddress("0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640") // USDC-WETH 0.05% pool
+			token0 := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+			token1 := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+
+			parsedABI, err := abi.JSON(strings.NewReader(uniswapV3PoolABI))
+			So(err, ShouldBeNil)
+
+			s.client.cfg.TickBitmapWordRange = 1
+			latestBlock := uint64(20000000)
+
+			// Current tick -30 with spacing 10 sits in word -1
+			bitmaps := map[int64]*big.Int{
+				-2: big.NewInt(0),
+				-1: new(big.Int).Lsh(big.NewInt(1), 250), // compressed -6, tick -60
+				0:  big.NewInt(0b101),                    // ticks 0 and 20
+			}
+			liquidityNets := map[int64]*big.Int{
+				-60: big.NewInt(500),
+				0:   big.NewInt(-200),
+				20:  big.NewInt(-300),
+			}
+
+			respond := func(c C, call ethereum.CallMsg) ([]byte, error) {
+				c.So(*call.To, ShouldEqual, poolAddr)
+
+				method, err := parsedABI.MethodById(call.Data[:4])
+				if err != nil {
+					return nil, err
+				}
+				switch method.Name {
+				case "token0":
+					return method.Outputs.Pack(token0)
+				case "token1":
+					return method.Outputs.Pack(token1)
+				case "fee":
+					return method.Outputs.Pack(big.NewInt(500))
+				case "tickSpacing":
+					return method.Outputs.Pack(big.NewInt(10))
+				case "liquidity":
+					return method.Outputs.Pack(big.NewInt(1000))
+				case "slot0":
+					return method.Outputs.Pack(
+						big.NewInt(79228162514264337), big.NewInt(-30),
+						uint16(0), uint16(1), uint16(1), uint8(0), true,
+					)
+				case "tickBitmap":
+					args, err := method.Inputs.Unpack(call.Data[4:])
+					if err != nil {
+						return nil, err
+					}
+					return method.Outputs.Pack(bitmaps[int64(args[0].(int16))])
+				case "ticks":
+					args, err := method.Inputs.Unpack(call.Data[4:])
+					if err != nil {
+						return nil, err
+					}
+					net := liquidityNets[args[0].(*big.Int).Int64()]
+					return method.Outputs.Pack(
+						new(big.Int).Abs(net), net, big.NewInt(0), big.NewInt(0),
+						big.NewInt(0), big.NewInt(0), uint32(0), true,
+					)
+				}
+				return nil, errors.New("unexpected method " + method.Name)
+			}
+
+			Convey("When reading a pool with initialized ticks around the current price", func(c C) {
+				s.gethClient.EXPECT().
+					BlockNumber(gomock.Any()).
+					Return(latestBlock, nil)
+				s.gethClient.EXPECT().
+					CallContract(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+						// Every read is pinned to the same block
+						c.So(blockNumber.Uint64(), ShouldEqual, latestBlock)
+						return respond(c, call)
+					}).
+					Times(12) // 6 pool fields, 3 bitmap words and 3 ticks
+
+				result, err := s.client.PoolState(ctx, poolAddr)
+
+				Convey("Then it should return the pool state with sorted ticks", func() {
+					So(err, ShouldBeNil)
+					So(result, ShouldNotBeNil)
+					So(result.Token0, ShouldEqual, token0)
+					So(result.Token1, ShouldEqual, token1)
+					So(result.FeePips, ShouldEqual, 500)
+					So(result.TickSpacing, ShouldEqual, 10)
+					So(result.SqrtPriceX96.String(), ShouldEqual, "79228162514264337")
+					So(result.Tick, ShouldEqual, -30)
+					So(result.Liquidity.String(), ShouldEqual, "1000")
+					So(result.MinWordPos, ShouldEqual, -2)
+					So(result.MaxWordPos, ShouldEqual, 0)
+					So(result.Ticks, ShouldHaveLength, 3)
+					So(result.Ticks[0].Index, ShouldEqual, -60)
+					So(result.Ticks[0].LiquidityNet.String(), ShouldEqual, "500")
+					So(result.Ticks[1].Index, ShouldEqual, 0)
+					So(result.Ticks[1].LiquidityNet.String(), ShouldEqual, "-200")
+					So(result.Ticks[2].Index, ShouldEqual, 20)
+					So(result.Ticks[2].LiquidityNet.String(), ShouldEqual, "-300")
+					So(result.BlockNumber, ShouldEqual, latestBlock)
+				})
+			})
+
+			Convey("When the ticks are read with bounded concurrency", func(c C) {
+				s.client.cfg.TickFetchConcurrency = 2
+
+				var inFlight, maxInFlight atomic.Int32
+				s.gethClient.EXPECT().
+					BlockNumber(gomock.Any()).
+					Return(latestBlock, nil)
+				s.gethClient.EXPECT().
+					CallContract(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, call ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+						n := inFlight.Add(1)
+						defer inFlight.Add(-1)
+						for {
+							prev := maxInFlight.Load()
+							if n <= prev || maxInFlight.CompareAndSwap(prev, n) {
+								break
+							}
+						}
+						time.Sleep(10 * time.Millisecond)
+						return respond(c, call)
+					}).
+					Times(12)
+
+				result, err := s.client.PoolState(ctx, poolAddr)
+
+				Convey("Then no more ticks should be read at once than allowed, in order", func() {
+					So(err, ShouldBeNil)
+					So(maxInFlight.Load(), ShouldEqual, 2)
+					So(result.Ticks, ShouldHaveLength, 3)
+					So(result.Ticks[0].Index, ShouldEqual, -60)
+					So(result.Ticks[1].Index, ShouldEqual, 0)
+					So(result.Ticks[2].Index, ShouldEqual, 20)
+				})
+			})
+
+			Convey("When a tick cannot be read", func(c C) {
+				s.gethClient.EXPECT().
+					BlockNumber(gomock.Any()).
+					Return(latestBlock, nil)
+				s.gethClient.EXPECT().
+					CallContract(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, call ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+						method, err := parsedABI.MethodById(call.Data[:4])
+						if err == nil && method.Name == "ticks" {
+							return nil, errors.New("503 Service Unavailable")
+						}
+						return respond(c, call)
+					}).
+					MinTimes(10).
+					MaxTimes(12)
+
+				result, err := s.client.PoolState(ctx, poolAddr)
+
+				Convey("Then it should return an error", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldContainSubstring, "failed to call ticks")
+					So(result, ShouldBeNil)
+				})
+			})
+
+			Convey("When the latest block cannot be read", func() {
+				s.gethClient.EXPECT().
+					BlockNumber(gomock.Any()).
+					Return(uint64(0), errors.New("connection refused"))
+
+				// No CallContract call expected
+
+				result, err := s.client.PoolState(ctx, poolAddr)
+
+				Convey("Then it should return an error", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldContainSubstring, "failed to get latest block")
+					So(result, ShouldBeNil)
+				})
+			})
+
+			Convey("When the contract call fails", func() {
+				s.gethClient.EXPECT().
+					BlockNumber(gomock.Any()).
+					Return(latestBlock, nil)
+				s.gethClient.EXPECT().
+					CallContract(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("execution reverted"))
+
+				result, err := s.client.PoolState(ctx, poolAddr)
+
+				Convey("Then it should return an error", func() {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldContainSubstring, "failed to call token0")
+					So(result, ShouldBeNil)
+				})
+			})
+		})
+	})
+}
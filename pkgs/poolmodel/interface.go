@@ -0,0 +1,91 @@
+package poolmodel
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+var (
+	// ErrUnknownToken is returned when a swap token does not belong to the pool.
+	ErrUnknownToken = errors.New("token does not belong to the pool")
+	// ErrStateStale is returned by ApplyLog when the log cannot be applied
+	// incrementally and the state has to be fetched again.
+	ErrStateStale = errors.New("pool state must be refetched")
+)
+
+// State is the model-specific state of a pool. Models never mutate a state
+// once returned; ApplyLog returns a new one.
+type State any
+
+// Swap describes a swap through a pool. FeeBps is the fee configured for the
+// pool; models reading their fee on-chain ignore it.
+type Swap struct {
+	Pool   common.Address
+	Src    common.Address
+	Dst    common.Address
+	FeeBps uint64
+}
+
+// SpotPrice is the price of a pool before a trade. Price is the marginal
+// amount of Dst per unit of Src, fees excluded, and FeeRate the share of the
+// input kept by liquidity providers.
+type SpotPrice struct {
+	Price   *big.Rat
+	FeeRate *big.Rat
+}
+
+// Reserves are the balances of the two tokens of a swap, in pool order, and
+// the block of the pool state they were read from. Reserve0 and Reserve1 are
+// nil for pools without per-token reserves, and the block is zero when the
+// state was read without one.
+type Reserves struct {
+	Token0      common.Address
+	Token1      common.Address
+	Reserve0    *big.Int
+	Reserve1    *big.Int
+	BlockNumber uint64
+	BlockHash   common.Hash
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+//go:generate mockgen -source=interface.go -destination=interface_mock.go -package=poolmodel
+type PoolModel interface {
+	// Name is the venue reported to callers, e.g. "uniswapv3"
+	Name() string
+	FetchState(ctx context.Context, poolAddr common.Address) (State, error)
+	// LogQuery selects the logs that update the state of the pool
+	LogQuery(poolAddr common.Address, state State) ethereum.FilterQuery
+	ApplyLog(state State, vLog types.Log) (State, error)
+	AmountOut(state State, swap Swap, amountIn *big.Int) (*big.Int, error)
+	AmountIn(state State, swap Swap, amountOut *big.Int) (*big.Int, error)
+	SpotPrice(state State, swap Swap) (*SpotPrice, error)
+	Reserves(state State, swap Swap) (*Reserves, error)
+}
+
+// HistoricalPoolModel is implemented by the models that can fetch the state
+// of a pool as of a past block. The state is not meant to be cached.
+type HistoricalPoolModel interface {
+	FetchStateAt(ctx context.Context, poolAddr common.Address, blockNumber uint64) (State, error)
+}
+
+// BatchPoolModel is implemented by the models that can fetch the states of
+// several pools at one block, so that quotes across them are consistent.
+// Pools whose state cannot be fetched are left out of the result.
+type BatchPoolModel interface {
+	FetchStates(ctx context.Context, poolAddrs []common.Address) (map[common.Address]State, error)
+}
+
+// DeployedPoolModel is implemented by the models that can tell whether a pool
+// contract is deployed, to check derived pool addresses cheaply before their
+// state is fetched.
+type DeployedPoolModel interface {
+	IsDeployed(ctx context.Context, poolAddr common.Address) (bool, error)
+}
@@ -0,0 +1,40 @@
+package poolmodel
+
+import (
+	"sort"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Registry maps pool types, such as "v2" or "curve", to their model.
+type Registry struct {
+	models map[string]PoolModel
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		models: make(map[string]PoolModel),
+	}
+}
+
+// Register adds a model under a pool type, replacing any previous one.
+func (r *Registry) Register(poolType string, model PoolModel) {
+	r.models[strings.ToLower(poolType)] = model
+}
+
+// Get returns the model of a pool type, matched case-insensitively.
+func (r *Registry) Get(poolType string) (PoolModel, bool) {
+	model, ok := r.models[strings.ToLower(poolType)]
+	return model, ok
+}
+
+// Types returns the registered pool types in sorted order.
+func (r *Registry) Types() []string {
+	types := make([]string, 0, len(r.models))
+	for poolType := range r.models {
+		types = append(types, poolType)
+	}
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,53 @@
+package poolmodel
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRegistry(t *testing.T) {
+	Convey("Given a pool model registry", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		v2Model := NewMockPoolModel(ctrl)
+		curveModel := NewMockPoolModel(ctrl)
+
+		registry := NewRegistry()
+		registry.Register("v2", v2Model)
+		registry.Register("Curve", curveModel)
+
+		Convey("When looking up registered pool types", func() {
+			v2, v2Ok := registry.Get("v2")
+			curve, curveOk := registry.Get("CURVE")
+
+			Convey("Then the models should be found case-insensitively", func() {
+				So(v2Ok, ShouldBeTrue)
+				So(v2, ShouldEqual, v2Model)
+				So(curveOk, ShouldBeTrue)
+				So(curve, ShouldEqual, curveModel)
+			})
+		})
+
+		Convey("When looking up an unknown pool type", func() {
+			_, ok := registry.Get("v4")
+
+			Convey("Then no model should be found", func() {
+				So(ok, ShouldBeFalse)
+			})
+		})
+
+		Convey("When registering a pool type again", func() {
+			replacement := NewMockPoolModel(ctrl)
+			registry.Register("v2", replacement)
+			v2, _ := registry.Get("v2")
+
+			Convey("Then the latest model should win", func() {
+				So(v2, ShouldEqual, replacement)
+				So(registry.Types(), ShouldResemble, []string{"curve", "v2"})
+			})
+		})
+	})
+}
@@ -0,0 +1,105 @@
+package tokenlist
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+type Config struct {
+	// Paths of token list files in the Uniswap token list format
+	Files []string `env:"FILES"`
+}
+
+// Token is an entry of a token list.
+type Token struct {
+	ChainID  uint64 `json:"chainId"`
+	Address  string `json:"address"`
+	Symbol   string `json:"symbol"`
+	Name     string `json:"name"`
+	Decimals uint8  `json:"decimals"`
+}
+
+// list is the part of the token list schema
+// (https://uniswap.org/tokenlist.schema.json) that is used.
+type list struct {
+	Name   string  `json:"name"`
+	Tokens []Token `json:"tokens"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// TokenList indexes the tokens of one or more lists by chain id and symbol.
+type TokenList struct {
+	// Tokens keyed by chain id, then by uppercased symbol
+	symbolsMap map[uint64]map[string][]Token
+}
+
+func New() *TokenList {
+	return &TokenList{
+		symbolsMap: make(map[uint64]map[string][]Token),
+	}
+}
+
+// Load reads the token lists of the config. Lists are merged; a token listed
+// by several of them is kept once.
+func Load(cfg Config) (*TokenList, error) {
+	tokenList := New()
+	for _, path := range cfg.Files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token list %s: %v", path, err)
+		}
+
+		var l list
+		if err := json.Unmarshal(data, &l); err != nil {
+			return nil, fmt.Errorf("failed to parse token list %s: %v", path, err)
+		}
+		if err := tokenList.Add(l.Tokens...); err != nil {
+			return nil, fmt.Errorf("invalid token list %s: %v", path, err)
+		}
+	}
+	return tokenList, nil
+}
+
+// Add indexes tokens, skipping those already listed under the same symbol.
+func (l *TokenList) Add(tokens ...Token) error {
+	for _, token := range tokens {
+		if !common.IsHexAddress(token.Address) {
+			return fmt.Errorf("invalid address %q of %s", token.Address, token.Symbol)
+		}
+		if token.Symbol == "" {
+			return fmt.Errorf("missing symbol of %s", token.Address)
+		}
+
+		symbols, ok := l.symbolsMap[token.ChainID]
+		if !ok {
+			symbols = make(map[string][]Token)
+			l.symbolsMap[token.ChainID] = symbols
+		}
+
+		key := strings.ToUpper(token.Symbol)
+		listed := false
+		for _, other := range symbols[key] {
+			if strings.EqualFold(other.Address, token.Address) {
+				listed = true
+				break
+			}
+		}
+		if !listed {
+			symbols[key] = append(symbols[key], token)
+		}
+	}
+	return nil
+}
+
+// Lookup returns the tokens of a chain with a symbol, matched
+// case-insensitively. More than one token means the symbol is ambiguous.
+func (l *TokenList) Lookup(chainID uint64, symbol string) []Token {
+	return l.symbolsMap[chainID][strings.ToUpper(symbol)]
+}
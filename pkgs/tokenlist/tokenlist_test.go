@@ -0,0 +1,82 @@
+package tokenlist
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTokenList(t *testing.T) {
+	Convey("Given token list files", t, func() {
+		dir := t.TempDir()
+		defaultList := filepath.Join(dir, "default.json")
+		extendedList := filepath.Join(dir, "extended.json")
+
+		So(os.WriteFile(defaultList, []byte(`{
+			"name": "Default",
+			"tokens": [
+				{"chainId": 1, "address": "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", "symbol": "WETH", "name": "Wrapped Ether", "decimals": 18},
+				{"chainId": 1, "address": "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", "symbol": "USDC", "name": "USD Coin", "decimals": 6},
+				{"chainId": 10, "address": "0x7F5c764cBc14f9669B88837ca1490cCa17c31607", "symbol": "USDC", "name": "USD Coin (Bridged)", "decimals": 6}
+			]
+		}`), 0o600), ShouldBeNil)
+		So(os.WriteFile(extendedList, []byte(`{
+			"name": "Extended",
+			"tokens": [
+				{"chainId": 1, "address": "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", "symbol": "USDC", "name": "USD Coin", "decimals": 6},
+				{"chainId": 1, "address": "0x0Ea8E6d6D3d0C5D3c0e6E3d3E6e0a1A0a3E3e3E3", "symbol": "weth", "name": "Fake Wrapped Ether", "decimals": 18}
+			]
+		}`), 0o600), ShouldBeNil)
+
+		Convey("When loading and looking up symbols", func() {
+			tokenList, err := Load(Config{Files: []string{defaultList, extendedList}})
+			So(err, ShouldBeNil)
+
+			Convey("Then a token listed twice should be kept once", func() {
+				tokens := tokenList.Lookup(1, "usdc")
+				So(tokens, ShouldHaveLength, 1)
+				So(tokens[0].Address, ShouldEqual, "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+			})
+
+			Convey("Then symbols should be resolved per chain", func() {
+				tokens := tokenList.Lookup(10, "USDC")
+				So(tokens, ShouldHaveLength, 1)
+				So(tokens[0].Address, ShouldEqual, "0x7F5c764cBc14f9669B88837ca1490cCa17c31607")
+				So(tokenList.Lookup(10, "WETH"), ShouldBeEmpty)
+			})
+
+			Convey("Then tokens sharing a symbol should all be returned", func() {
+				tokens := tokenList.Lookup(1, "WETH")
+				So(tokens, ShouldHaveLength, 2)
+				So(tokens[0].Name, ShouldEqual, "Wrapped Ether")
+				So(tokens[1].Name, ShouldEqual, "Fake Wrapped Ether")
+			})
+		})
+
+		Convey("When a list has an invalid address", func() {
+			invalidList := filepath.Join(dir, "invalid.json")
+			So(os.WriteFile(invalidList, []byte(`{
+				"name": "Invalid",
+				"tokens": [{"chainId": 1, "address": "0x1234", "symbol": "BAD", "name": "Bad", "decimals": 18}]
+			}`), 0o600), ShouldBeNil)
+
+			_, err := Load(Config{Files: []string{defaultList, invalidList}})
+
+			Convey("Then loading should fail", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "invalid address")
+			})
+		})
+
+		Convey("When a list file does not exist", func() {
+			_, err := Load(Config{Files: []string{filepath.Join(dir, "missing.json")}})
+
+			Convey("Then loading should fail", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "failed to read token list")
+			})
+		})
+	})
+}
@@ -4,4 +4,17 @@ package utils
 const (
 	RequestIdHeader = "X-Request-ID"
 	SessionIdHeader = "X-Session-ID"
+	DexNameHeader   = "X-Dex-Name"
+
+	// Quote details of the estimate endpoint
+	SpotPriceHeader      = "X-Spot-Price"
+	ExecutionPriceHeader = "X-Execution-Price"
+	PriceImpactBpsHeader = "X-Price-Impact-Bps"
+	LpFeeHeader          = "X-Lp-Fee"
+	MinOutHeader         = "X-Min-Out"
+	MaxInHeader          = "X-Max-In"
+	SrcTaxedHeader       = "X-Src-Taxed"
+	DstTaxedHeader       = "X-Dst-Taxed"
+
+	AmountOutDecimalHeader = "X-Amount-Out-Decimal"
 )